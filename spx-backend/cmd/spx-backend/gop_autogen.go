@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -35,10 +36,62 @@ type get_asset_id struct {
 	yap.Handler
 	*AppV2
 }
+type get_asset_id_tags struct {
+	yap.Handler
+	*AppV2
+}
+type get_asset_id_versions struct {
+	yap.Handler
+	*AppV2
+}
+type get_asset_id_views struct {
+	yap.Handler
+	*AppV2
+}
+type get_assets_access struct {
+	yap.Handler
+	*AppV2
+}
+type get_assets_batch struct {
+	yap.Handler
+	*AppV2
+}
+type get_assets_categories struct {
+	yap.Handler
+	*AppV2
+}
+type get_assets_duplicates struct {
+	yap.Handler
+	*AppV2
+}
 type get_assets_list struct {
 	yap.Handler
 	*AppV2
 }
+type get_assets_random struct {
+	yap.Handler
+	*AppV2
+}
+type get_assets_recent struct {
+	yap.Handler
+	*AppV2
+}
+type get_assets_stats struct {
+	yap.Handler
+	*AppV2
+}
+type get_assets_trending struct {
+	yap.Handler
+	*AppV2
+}
+type get_moderation_queue struct {
+	yap.Handler
+	*AppV2
+}
+type get_moderation_reviews struct {
+	yap.Handler
+	*AppV2
+}
 type get_project_owner_name struct {
 	yap.Handler
 	*AppV2
@@ -47,6 +100,14 @@ type get_projects_list struct {
 	yap.Handler
 	*AppV2
 }
+type get_reports_list struct {
+	yap.Handler
+	*AppV2
+}
+type get_tags_popular struct {
+	yap.Handler
+	*AppV2
+}
 type get_util_upinfo struct {
 	yap.Handler
 	*AppV2
@@ -68,6 +129,54 @@ type post_asset_id_click struct {
 	yap.Handler
 	*AppV2
 }
+type post_asset_id_download struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_fork struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_moderate struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_report struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_restore struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_review struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_submit struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_transfer struct {
+	yap.Handler
+	*AppV2
+}
+type post_asset_id_version_versionId_restore struct {
+	yap.Handler
+	*AppV2
+}
+type post_assets_delete struct {
+	yap.Handler
+	*AppV2
+}
+type post_assets_transfer struct {
+	yap.Handler
+	*AppV2
+}
+type post_assets_verify struct {
+	yap.Handler
+	*AppV2
+}
 type post_project struct {
 	yap.Handler
 	*AppV2
@@ -84,6 +193,18 @@ type put_asset_id struct {
 	yap.Handler
 	*AppV2
 }
+type put_asset_id_name struct {
+	yap.Handler
+	*AppV2
+}
+type put_asset_id_tags struct {
+	yap.Handler
+	*AppV2
+}
+type put_asset_id_visibility struct {
+	yap.Handler
+	*AppV2
+}
 type put_project_owner_name struct {
 	yap.Handler
 	*AppV2
@@ -117,35 +238,70 @@ func (this *AppV2) MainEntry() {
 //line cmd/spx-backend/main.yap:43:1
 	defer stop()
 //line cmd/spx-backend/main.yap:44:1
-	var serverErr error
-//line cmd/spx-backend/main.yap:45:1
 	go func() {
+//line cmd/spx-backend/main.yap:45:1
+		ticker := time.NewTicker(time.Hour)
 //line cmd/spx-backend/main.yap:46:1
-		serverErr = server.ListenAndServe()
+		defer ticker.Stop()
 //line cmd/spx-backend/main.yap:47:1
+		for {
+//line cmd/spx-backend/main.yap:48:1
+			select {
+//line cmd/spx-backend/main.yap:49:1
+			case
+//line cmd/spx-backend/main.yap:49:1
+			<-stopCtx.Done():
+//line cmd/spx-backend/main.yap:50:1
+				return
+//line cmd/spx-backend/main.yap:51:1
+			case
+//line cmd/spx-backend/main.yap:51:1
+			<-ticker.C:
+//line cmd/spx-backend/main.yap:52:1
+				this.ctrl.TrimAssetClicks(stopCtx)
+//line cmd/spx-backend/main.yap:53:1
+				this.ctrl.TrimAssetViews(stopCtx)
+//line cmd/spx-backend/main.yap:54:1
+				this.ctrl.TrimAssetEvents(stopCtx)
+//line cmd/spx-backend/main.yap:55:1
+				this.ctrl.BackfillAssetFilesMeta(stopCtx)
+//line cmd/spx-backend/main.yap:56:1
+				this.ctrl.CollectDeletedAssets(stopCtx)
+//line cmd/spx-backend/main.yap:57:1
+				this.ctrl.RenderMissingPreviews(stopCtx)
+			}
+		}
+	}()
+//line cmd/spx-backend/main.yap:61:1
+	var serverErr error
+//line cmd/spx-backend/main.yap:62:1
+	go func() {
+//line cmd/spx-backend/main.yap:63:1
+		serverErr = server.ListenAndServe()
+//line cmd/spx-backend/main.yap:64:1
 		stop()
 	}()
-//line cmd/spx-backend/main.yap:49:1
+//line cmd/spx-backend/main.yap:66:1
 	<-stopCtx.Done()
-//line cmd/spx-backend/main.yap:50:1
+//line cmd/spx-backend/main.yap:67:1
 	if serverErr != nil && !errors.Is(serverErr, http.ErrServerClosed) {
-//line cmd/spx-backend/main.yap:51:1
+//line cmd/spx-backend/main.yap:68:1
 		logger.Fatalln("Server error:", this.err)
 	}
-//line cmd/spx-backend/main.yap:54:1
+//line cmd/spx-backend/main.yap:71:1
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
-//line cmd/spx-backend/main.yap:55:1
+//line cmd/spx-backend/main.yap:72:1
 	defer cancel()
-//line cmd/spx-backend/main.yap:56:1
+//line cmd/spx-backend/main.yap:73:1
 	if
-//line cmd/spx-backend/main.yap:56:1
+//line cmd/spx-backend/main.yap:73:1
 	err := server.Shutdown(shutdownCtx); err != nil {
-//line cmd/spx-backend/main.yap:57:1
+//line cmd/spx-backend/main.yap:74:1
 		logger.Fatalln("Failed to gracefully shut down:", err)
 	}
 }
 func (this *AppV2) Main() {
-	yap.Gopt_AppV2_Main(this, new(delete_asset_id), new(delete_project_owner_name), new(get_asset_id), new(get_assets_list), new(get_project_owner_name), new(get_projects_list), new(get_util_upinfo), new(post_aigc_matting), new(post_asset), new(post_asset_id_click), new(post_project), new(post_util_fileurls), new(post_util_fmtcode), new(put_asset_id), new(put_project_owner_name))
+	yap.Gopt_AppV2_Main(this, new(delete_asset_id), new(delete_project_owner_name), new(get_asset_id), new(get_asset_id_tags), new(get_asset_id_versions), new(get_asset_id_views), new(get_assets_access), new(get_assets_batch), new(get_assets_categories), new(get_assets_duplicates), new(get_assets_list), new(get_assets_random), new(get_assets_recent), new(get_assets_stats), new(get_assets_trending), new(get_moderation_queue), new(get_moderation_reviews), new(get_project_owner_name), new(get_projects_list), new(get_reports_list), new(get_tags_popular), new(get_util_upinfo), new(post_aigc_matting), new(post_asset), new(post_asset_id_click), new(post_asset_id_download), new(post_asset_id_fork), new(post_asset_id_moderate), new(post_asset_id_report), new(post_asset_id_restore), new(post_asset_id_review), new(post_asset_id_submit), new(post_asset_id_transfer), new(post_asset_id_version_versionId_restore), new(post_assets_delete), new(post_assets_transfer), new(post_assets_verify), new(post_project), new(post_util_fileurls), new(post_util_fmtcode), new(put_asset_id), new(put_asset_id_name), new(put_asset_id_tags), new(put_asset_id_visibility), new(put_project_owner_name))
 }
 //line cmd/spx-backend/delete_asset_#id.yap:6
 func (this *delete_asset_id) Main(_gop_arg0 *yap.Context) {
@@ -207,359 +363,1466 @@ func (this *get_asset_id) Main(_gop_arg0 *yap.Context) {
 func (this *get_asset_id) Classfname() string {
 	return "get_asset_#id"
 }
-//line cmd/spx-backend/get_assets_list.yap:13
+//line cmd/spx-backend/get_asset_#id_tags.yap:6
+func (this *get_asset_id_tags) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_asset_#id_tags.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_asset_#id_tags.yap:8:1
+	tags, err := this.ctrl.GetAssetTags(ctx.Context(), this.Gop_Env("id"))
+//line cmd/spx-backend/get_asset_#id_tags.yap:9:1
+	if err != nil {
+//line cmd/spx-backend/get_asset_#id_tags.yap:10:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_asset_#id_tags.yap:11:1
+		return
+	}
+//line cmd/spx-backend/get_asset_#id_tags.yap:13:1
+	this.Json__1(tags)
+}
+func (this *get_asset_id_tags) Classfname() string {
+	return "get_asset_#id_tags"
+}
+//line cmd/spx-backend/get_asset_#id_versions.yap:10
+func (this *get_asset_id_versions) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_asset_#id_versions.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_asset_#id_versions.yap:12:1
+	if
+//line cmd/spx-backend/get_asset_#id_versions.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/get_asset_#id_versions.yap:13:1
+		return
+	}
+//line cmd/spx-backend/get_asset_#id_versions.yap:16:1
+	pagination := model.Pagination{Index: ctx.ParamInt("pageIndex", firstPageIndex), Size: ctx.ParamInt("pageSize", defaultPageSize)}
+//line cmd/spx-backend/get_asset_#id_versions.yap:21:1
+	versions, err := this.ctrl.ListAssetVersions(ctx.Context(), this.Gop_Env("id"), pagination)
+//line cmd/spx-backend/get_asset_#id_versions.yap:22:1
+	if err != nil {
+//line cmd/spx-backend/get_asset_#id_versions.yap:23:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_asset_#id_versions.yap:24:1
+		return
+	}
+//line cmd/spx-backend/get_asset_#id_versions.yap:26:1
+	this.Json__1(versions)
+}
+func (this *get_asset_id_versions) Classfname() string {
+	return "get_asset_#id_versions"
+}
+//line cmd/spx-backend/get_asset_#id_views.yap:6
+func (this *get_asset_id_views) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_asset_#id_views.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_asset_#id_views.yap:8:1
+	user, ok := ensureUser(ctx)
+//line cmd/spx-backend/get_asset_#id_views.yap:9:1
+	if !ok {
+//line cmd/spx-backend/get_asset_#id_views.yap:10:1
+		return
+	}
+//line cmd/spx-backend/get_asset_#id_views.yap:13:1
+	series, err := this.ctrl.GetAssetViewSeries(ctx.Context(), this.Gop_Env("id"), ctx.ParamInt("days", 30), user.Name)
+//line cmd/spx-backend/get_asset_#id_views.yap:14:1
+	if err != nil {
+//line cmd/spx-backend/get_asset_#id_views.yap:15:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_asset_#id_views.yap:16:1
+		return
+	}
+//line cmd/spx-backend/get_asset_#id_views.yap:18:1
+	this.Json__1(series)
+}
+func (this *get_asset_id_views) Classfname() string {
+	return "get_asset_#id_views"
+}
+//line cmd/spx-backend/get_assets_access.yap:13
+func (this *get_assets_access) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_access.yap:13:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_access.yap:15:1
+	params := &controller.CheckAssetAccessParams{}
+//line cmd/spx-backend/get_assets_access.yap:16:1
+	if
+//line cmd/spx-backend/get_assets_access.yap:16:1
+	ids := this.Gop_Env("ids"); ids != "" {
+//line cmd/spx-backend/get_assets_access.yap:17:1
+		params.IDs = strings.Split(ids, ",")
+	}
+//line cmd/spx-backend/get_assets_access.yap:19:1
+	if
+//line cmd/spx-backend/get_assets_access.yap:19:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_assets_access.yap:20:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_assets_access.yap:21:1
+		return
+	}
+//line cmd/spx-backend/get_assets_access.yap:24:1
+	var requester string
+//line cmd/spx-backend/get_assets_access.yap:25:1
+	if
+//line cmd/spx-backend/get_assets_access.yap:25:1
+	user, ok := controller.UserFromContext(ctx.Context()); ok {
+//line cmd/spx-backend/get_assets_access.yap:26:1
+		requester = user.Name
+	}
+//line cmd/spx-backend/get_assets_access.yap:28:1
+	accesses, err := this.ctrl.CheckAssetAccess(ctx.Context(), params.IDs, requester)
+//line cmd/spx-backend/get_assets_access.yap:29:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_access.yap:30:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_access.yap:31:1
+		return
+	}
+//line cmd/spx-backend/get_assets_access.yap:33:1
+	this.Json__1(accesses)
+}
+func (this *get_assets_access) Classfname() string {
+	return "get_assets_access"
+}
+//line cmd/spx-backend/get_assets_batch.yap:12
+func (this *get_assets_batch) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_batch.yap:12:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_batch.yap:14:1
+	params := &controller.GetAssetsParams{}
+//line cmd/spx-backend/get_assets_batch.yap:15:1
+	if
+//line cmd/spx-backend/get_assets_batch.yap:15:1
+	ids := this.Gop_Env("ids"); ids != "" {
+//line cmd/spx-backend/get_assets_batch.yap:16:1
+		params.IDs = strings.Split(ids, ",")
+	}
+//line cmd/spx-backend/get_assets_batch.yap:18:1
+	if
+//line cmd/spx-backend/get_assets_batch.yap:18:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_assets_batch.yap:19:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_assets_batch.yap:20:1
+		return
+	}
+//line cmd/spx-backend/get_assets_batch.yap:23:1
+	assets, err := this.ctrl.GetAssets(ctx.Context(), params)
+//line cmd/spx-backend/get_assets_batch.yap:24:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_batch.yap:25:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_batch.yap:26:1
+		return
+	}
+//line cmd/spx-backend/get_assets_batch.yap:28:1
+	this.Json__1(assets)
+}
+func (this *get_assets_batch) Classfname() string {
+	return "get_assets_batch"
+}
+//line cmd/spx-backend/get_assets_categories.yap:13
+func (this *get_assets_categories) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_categories.yap:13:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_categories.yap:15:1
+	params := &controller.ListAssetCategoriesParams{}
+//line cmd/spx-backend/get_assets_categories.yap:17:1
+	if
+//line cmd/spx-backend/get_assets_categories.yap:17:1
+	assetTypeParam := this.Gop_Env("assetType"); assetTypeParam != "" {
+//line cmd/spx-backend/get_assets_categories.yap:18:1
+		assetTypeInt, err := strconv.Atoi(assetTypeParam)
+//line cmd/spx-backend/get_assets_categories.yap:19:1
+		if err != nil {
+//line cmd/spx-backend/get_assets_categories.yap:20:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_assets_categories.yap:21:1
+			return
+		}
+//line cmd/spx-backend/get_assets_categories.yap:23:1
+		assetType := model.AssetType(assetTypeInt)
+//line cmd/spx-backend/get_assets_categories.yap:24:1
+		params.AssetType = &assetType
+	}
+//line cmd/spx-backend/get_assets_categories.yap:26:1
+	if
+//line cmd/spx-backend/get_assets_categories.yap:26:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_assets_categories.yap:27:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_assets_categories.yap:28:1
+		return
+	}
+//line cmd/spx-backend/get_assets_categories.yap:31:1
+	categoryCounts, err := this.ctrl.ListAssetCategories(ctx.Context(), params)
+//line cmd/spx-backend/get_assets_categories.yap:32:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_categories.yap:33:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_categories.yap:34:1
+		return
+	}
+//line cmd/spx-backend/get_assets_categories.yap:36:1
+	this.Json__1(categoryCounts)
+}
+func (this *get_assets_categories) Classfname() string {
+	return "get_assets_categories"
+}
+//line cmd/spx-backend/get_assets_duplicates.yap:11
+func (this *get_assets_duplicates) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_duplicates.yap:11:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_duplicates.yap:13:1
+	if
+//line cmd/spx-backend/get_assets_duplicates.yap:13:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/get_assets_duplicates.yap:14:1
+		return
+	}
+//line cmd/spx-backend/get_assets_duplicates.yap:17:1
+	pagination := model.Pagination{Index: ctx.ParamInt("pageIndex", firstPageIndex), Size: ctx.ParamInt("pageSize", defaultPageSize)}
+//line cmd/spx-backend/get_assets_duplicates.yap:22:1
+	duplicates, err := this.ctrl.ListDisplayNameDuplicates(ctx.Context(), pagination)
+//line cmd/spx-backend/get_assets_duplicates.yap:23:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_duplicates.yap:24:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_duplicates.yap:25:1
+		return
+	}
+//line cmd/spx-backend/get_assets_duplicates.yap:27:1
+	this.Json__1(duplicates)
+}
+func (this *get_assets_duplicates) Classfname() string {
+	return "get_assets_duplicates"
+}
+//line cmd/spx-backend/get_assets_list.yap:14
 func (this *get_assets_list) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
-//line cmd/spx-backend/get_assets_list.yap:13:1
+//line cmd/spx-backend/get_assets_list.yap:14:1
 	ctx := &this.Context
-//line cmd/spx-backend/get_assets_list.yap:15:1
-	user, _ := controller.UserFromContext(ctx.Context())
 //line cmd/spx-backend/get_assets_list.yap:16:1
+	user, _ := controller.UserFromContext(ctx.Context())
+//line cmd/spx-backend/get_assets_list.yap:17:1
 	params := &controller.ListAssetsParams{}
-//line cmd/spx-backend/get_assets_list.yap:18:1
+//line cmd/spx-backend/get_assets_list.yap:19:1
 	params.Keyword = this.Gop_Env("keyword")
-//line cmd/spx-backend/get_assets_list.yap:20:1
+//line cmd/spx-backend/get_assets_list.yap:21:1
 	switch
-//line cmd/spx-backend/get_assets_list.yap:20:1
-	owner := this.Gop_Env("owner"); owner {
 //line cmd/spx-backend/get_assets_list.yap:21:1
-	case "":
+	owner := this.Gop_Env("owner"); owner {
 //line cmd/spx-backend/get_assets_list.yap:22:1
-		if user == nil {
+	case "":
 //line cmd/spx-backend/get_assets_list.yap:23:1
-			replyWithCode(ctx, errorUnauthorized)
+		if user == nil {
 //line cmd/spx-backend/get_assets_list.yap:24:1
+			replyWithCode(ctx, errorUnauthorized)
+//line cmd/spx-backend/get_assets_list.yap:25:1
 			return
 		}
-//line cmd/spx-backend/get_assets_list.yap:26:1
-		params.Owner = &user.Name
 //line cmd/spx-backend/get_assets_list.yap:27:1
-	case "*":
+		params.Owner = &user.Name
 //line cmd/spx-backend/get_assets_list.yap:28:1
-		params.Owner = nil
+	case "*":
 //line cmd/spx-backend/get_assets_list.yap:29:1
-	default:
+		params.Owner = nil
 //line cmd/spx-backend/get_assets_list.yap:30:1
+	default:
+//line cmd/spx-backend/get_assets_list.yap:31:1
 		params.Owner = &owner
 	}
-//line cmd/spx-backend/get_assets_list.yap:33:1
+//line cmd/spx-backend/get_assets_list.yap:34:1
 	if
-//line cmd/spx-backend/get_assets_list.yap:33:1
-	category := this.Gop_Env("category"); category != "" {
 //line cmd/spx-backend/get_assets_list.yap:34:1
+	category := this.Gop_Env("category"); category != "" {
+//line cmd/spx-backend/get_assets_list.yap:35:1
 		params.Category = &category
 	}
-//line cmd/spx-backend/get_assets_list.yap:37:1
+//line cmd/spx-backend/get_assets_list.yap:38:1
 	if
-//line cmd/spx-backend/get_assets_list.yap:37:1
-	assetTypeParam := this.Gop_Env("assetType"); assetTypeParam != "" {
 //line cmd/spx-backend/get_assets_list.yap:38:1
-		assetTypeInt, err := strconv.Atoi(assetTypeParam)
+	assetTypeParam := this.Gop_Env("assetType"); assetTypeParam != "" {
+		for
 //line cmd/spx-backend/get_assets_list.yap:39:1
-		if err != nil {
+		_, assetTypeStr := range strings.Split(assetTypeParam, ",") {
 //line cmd/spx-backend/get_assets_list.yap:40:1
-			replyWithCode(ctx, errorInvalidArgs)
+			assetTypeInt, err := strconv.Atoi(assetTypeStr)
 //line cmd/spx-backend/get_assets_list.yap:41:1
-			return
-		}
+			if err != nil {
+//line cmd/spx-backend/get_assets_list.yap:42:1
+				replyWithCode(ctx, errorInvalidArgs)
 //line cmd/spx-backend/get_assets_list.yap:43:1
-		assetType := model.AssetType(assetTypeInt)
-//line cmd/spx-backend/get_assets_list.yap:44:1
-		params.AssetType = &assetType
+				return
+			}
+//line cmd/spx-backend/get_assets_list.yap:45:1
+			params.AssetTypes = append(params.AssetTypes, model.AssetType(assetTypeInt))
+		}
 	}
-//line cmd/spx-backend/get_assets_list.yap:47:1
+//line cmd/spx-backend/get_assets_list.yap:49:1
 	if
-//line cmd/spx-backend/get_assets_list.yap:47:1
+//line cmd/spx-backend/get_assets_list.yap:49:1
 	filesHash := this.Gop_Env("filesHash"); filesHash != "" {
-//line cmd/spx-backend/get_assets_list.yap:48:1
+//line cmd/spx-backend/get_assets_list.yap:50:1
 		params.FilesHash = &filesHash
 	}
-//line cmd/spx-backend/get_assets_list.yap:51:1
+//line cmd/spx-backend/get_assets_list.yap:53:1
 	if
-//line cmd/spx-backend/get_assets_list.yap:51:1
+//line cmd/spx-backend/get_assets_list.yap:53:1
 	isPublicParam := this.Gop_Env("isPublic"); isPublicParam != "" {
-//line cmd/spx-backend/get_assets_list.yap:52:1
+//line cmd/spx-backend/get_assets_list.yap:54:1
 		isPublicInt, err := strconv.Atoi(isPublicParam)
-//line cmd/spx-backend/get_assets_list.yap:53:1
+//line cmd/spx-backend/get_assets_list.yap:55:1
 		if err != nil {
-//line cmd/spx-backend/get_assets_list.yap:54:1
+//line cmd/spx-backend/get_assets_list.yap:56:1
 			replyWithCode(ctx, errorInvalidArgs)
-//line cmd/spx-backend/get_assets_list.yap:55:1
+//line cmd/spx-backend/get_assets_list.yap:57:1
 			return
 		}
-//line cmd/spx-backend/get_assets_list.yap:57:1
+//line cmd/spx-backend/get_assets_list.yap:59:1
 		isPublic := model.IsPublic(isPublicInt)
-//line cmd/spx-backend/get_assets_list.yap:58:1
+//line cmd/spx-backend/get_assets_list.yap:60:1
 		params.IsPublic = &isPublic
 	}
-//line cmd/spx-backend/get_assets_list.yap:61:1
+//line cmd/spx-backend/get_assets_list.yap:63:1
+	if
+//line cmd/spx-backend/get_assets_list.yap:63:1
+	isAiGeneratedParam := this.Gop_Env("isAiGenerated"); isAiGeneratedParam != "" {
+//line cmd/spx-backend/get_assets_list.yap:64:1
+		isAiGenerated, err := strconv.ParseBool(isAiGeneratedParam)
+//line cmd/spx-backend/get_assets_list.yap:65:1
+		if err != nil {
+//line cmd/spx-backend/get_assets_list.yap:66:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_assets_list.yap:67:1
+			return
+		}
+//line cmd/spx-backend/get_assets_list.yap:69:1
+		params.IsAiGenerated = &isAiGenerated
+	}
+//line cmd/spx-backend/get_assets_list.yap:72:1
+	if
+//line cmd/spx-backend/get_assets_list.yap:72:1
+	licenses := this.Gop_Env("license"); licenses != "" {
+		for
+//line cmd/spx-backend/get_assets_list.yap:73:1
+		_, license := range strings.Split(licenses, ",") {
+//line cmd/spx-backend/get_assets_list.yap:74:1
+			params.Licenses = append(params.Licenses, model.AssetLicense(license))
+		}
+	}
+//line cmd/spx-backend/get_assets_list.yap:78:1
+	if
+//line cmd/spx-backend/get_assets_list.yap:78:1
+	remixableParam := this.Gop_Env("remixable"); remixableParam != "" {
+//line cmd/spx-backend/get_assets_list.yap:79:1
+		remixable, err := strconv.ParseBool(remixableParam)
+//line cmd/spx-backend/get_assets_list.yap:80:1
+		if err != nil {
+//line cmd/spx-backend/get_assets_list.yap:81:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_assets_list.yap:82:1
+			return
+		}
+//line cmd/spx-backend/get_assets_list.yap:84:1
+		params.Remixable = &remixable
+	}
+//line cmd/spx-backend/get_assets_list.yap:87:1
+	if
+//line cmd/spx-backend/get_assets_list.yap:87:1
+	tags := this.Gop_Env("tags"); tags != "" {
+//line cmd/spx-backend/get_assets_list.yap:88:1
+		params.Tags = strings.Split(tags, ",")
+	}
+//line cmd/spx-backend/get_assets_list.yap:91:1
 	if
-//line cmd/spx-backend/get_assets_list.yap:61:1
+//line cmd/spx-backend/get_assets_list.yap:91:1
 	orderBy := this.Gop_Env("orderBy"); orderBy != "" {
-//line cmd/spx-backend/get_assets_list.yap:62:1
+//line cmd/spx-backend/get_assets_list.yap:92:1
 		params.OrderBy = controller.ListAssetsOrderBy(orderBy)
 	}
-//line cmd/spx-backend/get_assets_list.yap:65:1
-	params.Pagination.Index = ctx.ParamInt("pageIndex", firstPageIndex)
-//line cmd/spx-backend/get_assets_list.yap:66:1
-	params.Pagination.Size = ctx.ParamInt("pageSize", defaultPageSize)
-//line cmd/spx-backend/get_assets_list.yap:67:1
+//line cmd/spx-backend/get_assets_list.yap:95:1
+	if
+//line cmd/spx-backend/get_assets_list.yap:95:1
+	includeDeletedParam := this.Gop_Env("includeDeleted"); includeDeletedParam != "" {
+//line cmd/spx-backend/get_assets_list.yap:96:1
+		includeDeleted, err := strconv.ParseBool(includeDeletedParam)
+//line cmd/spx-backend/get_assets_list.yap:97:1
+		if err != nil {
+//line cmd/spx-backend/get_assets_list.yap:98:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_assets_list.yap:99:1
+			return
+		}
+//line cmd/spx-backend/get_assets_list.yap:101:1
+		params.IncludeDeleted = includeDeleted
+	}
+//line cmd/spx-backend/get_assets_list.yap:104:1
+	params.Pagination.Index = ctx.ParamInt("pageIndex", firstPageIndex)
+//line cmd/spx-backend/get_assets_list.yap:105:1
+	params.Pagination.Size = ctx.ParamInt("pageSize", defaultPageSize)
+//line cmd/spx-backend/get_assets_list.yap:106:1
+	if
+//line cmd/spx-backend/get_assets_list.yap:106:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_assets_list.yap:107:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_assets_list.yap:108:1
+		return
+	}
+//line cmd/spx-backend/get_assets_list.yap:111:1
+	assets, err := this.ctrl.ListAssets(ctx.Context(), params)
+//line cmd/spx-backend/get_assets_list.yap:112:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_list.yap:113:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_list.yap:114:1
+		return
+	}
+//line cmd/spx-backend/get_assets_list.yap:116:1
+	this.Json__1(assets)
+}
+func (this *get_assets_list) Classfname() string {
+	return "get_assets_list"
+}
+//line cmd/spx-backend/get_assets_random.yap:13
+func (this *get_assets_random) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_random.yap:13:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_random.yap:15:1
+	params := &controller.ListRandomAssetsParams{}
+//line cmd/spx-backend/get_assets_random.yap:17:1
+	assetTypeInt, err := strconv.Atoi(this.Gop_Env("assetType"))
+//line cmd/spx-backend/get_assets_random.yap:18:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_random.yap:19:1
+		replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_assets_random.yap:20:1
+		return
+	}
+//line cmd/spx-backend/get_assets_random.yap:22:1
+	params.AssetType = model.AssetType(assetTypeInt)
+//line cmd/spx-backend/get_assets_random.yap:24:1
+	params.Count = ctx.ParamInt("count", 10)
+//line cmd/spx-backend/get_assets_random.yap:25:1
+	if
+//line cmd/spx-backend/get_assets_random.yap:25:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_assets_random.yap:26:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_assets_random.yap:27:1
+		return
+	}
+//line cmd/spx-backend/get_assets_random.yap:30:1
+	assets, err := this.ctrl.ListRandomAssets(ctx.Context(), params)
+//line cmd/spx-backend/get_assets_random.yap:31:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_random.yap:32:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_random.yap:33:1
+		return
+	}
+//line cmd/spx-backend/get_assets_random.yap:35:1
+	this.Json__1(assets)
+}
+func (this *get_assets_random) Classfname() string {
+	return "get_assets_random"
+}
+//line cmd/spx-backend/get_assets_recent.yap:12
+func (this *get_assets_recent) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_recent.yap:12:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_recent.yap:14:1
+	var assetType *model.AssetType
+//line cmd/spx-backend/get_assets_recent.yap:15:1
+	if
+//line cmd/spx-backend/get_assets_recent.yap:15:1
+	assetTypeParam := this.Gop_Env("assetType"); assetTypeParam != "" {
+//line cmd/spx-backend/get_assets_recent.yap:16:1
+		assetTypeInt, err := strconv.Atoi(assetTypeParam)
+//line cmd/spx-backend/get_assets_recent.yap:17:1
+		if err != nil {
+//line cmd/spx-backend/get_assets_recent.yap:18:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_assets_recent.yap:19:1
+			return
+		}
+//line cmd/spx-backend/get_assets_recent.yap:21:1
+		at := model.AssetType(assetTypeInt)
+//line cmd/spx-backend/get_assets_recent.yap:22:1
+		switch at {
+//line cmd/spx-backend/get_assets_recent.yap:23:1
+		case model.AssetTypeSprite, model.AssetTypeBackdrop, model.AssetTypeSound:
+//line cmd/spx-backend/get_assets_recent.yap:24:1
+		default:
+//line cmd/spx-backend/get_assets_recent.yap:25:1
+			replyWithCodeMsg(ctx, errorInvalidArgs, "invalid assetType")
+//line cmd/spx-backend/get_assets_recent.yap:26:1
+			return
+		}
+//line cmd/spx-backend/get_assets_recent.yap:28:1
+		assetType = &at
+	}
+//line cmd/spx-backend/get_assets_recent.yap:31:1
+	pagination := model.Pagination{Index: ctx.ParamInt("pageIndex", firstPageIndex), Size: ctx.ParamInt("pageSize", defaultPageSize)}
+//line cmd/spx-backend/get_assets_recent.yap:36:1
+	assets, err := this.ctrl.ListRecentAssets(ctx.Context(), assetType, pagination)
+//line cmd/spx-backend/get_assets_recent.yap:37:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_recent.yap:38:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_recent.yap:39:1
+		return
+	}
+//line cmd/spx-backend/get_assets_recent.yap:41:1
+	this.Json__1(assets)
+}
+func (this *get_assets_recent) Classfname() string {
+	return "get_assets_recent"
+}
+//line cmd/spx-backend/get_assets_stats.yap:6
+func (this *get_assets_stats) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_stats.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_stats.yap:8:1
+	user, ok := ensureUser(ctx)
+//line cmd/spx-backend/get_assets_stats.yap:9:1
+	if !ok {
+//line cmd/spx-backend/get_assets_stats.yap:10:1
+		return
+	}
+//line cmd/spx-backend/get_assets_stats.yap:13:1
+	owner := this.Gop_Env("owner")
+//line cmd/spx-backend/get_assets_stats.yap:14:1
+	if owner == "" {
+//line cmd/spx-backend/get_assets_stats.yap:15:1
+		owner = user.Name
+	}
+//line cmd/spx-backend/get_assets_stats.yap:18:1
+	stats, err := this.ctrl.GetOwnerAssetStats(ctx.Context(), owner)
+//line cmd/spx-backend/get_assets_stats.yap:19:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_stats.yap:20:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_stats.yap:21:1
+		return
+	}
+//line cmd/spx-backend/get_assets_stats.yap:23:1
+	this.Json__1(stats)
+}
+func (this *get_assets_stats) Classfname() string {
+	return "get_assets_stats"
+}
+//line cmd/spx-backend/get_assets_trending.yap:14
+func (this *get_assets_trending) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_assets_trending.yap:14:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_assets_trending.yap:16:1
+	params := &controller.ListTrendingAssetsParams{}
+//line cmd/spx-backend/get_assets_trending.yap:18:1
+	if
+//line cmd/spx-backend/get_assets_trending.yap:18:1
+	assetTypeParam := this.Gop_Env("assetType"); assetTypeParam != "" {
+		for
+//line cmd/spx-backend/get_assets_trending.yap:19:1
+		_, assetTypeStr := range strings.Split(assetTypeParam, ",") {
+//line cmd/spx-backend/get_assets_trending.yap:20:1
+			assetTypeInt, err := strconv.Atoi(assetTypeStr)
+//line cmd/spx-backend/get_assets_trending.yap:21:1
+			if err != nil {
+//line cmd/spx-backend/get_assets_trending.yap:22:1
+				replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_assets_trending.yap:23:1
+				return
+			}
+//line cmd/spx-backend/get_assets_trending.yap:25:1
+			params.AssetTypes = append(params.AssetTypes, model.AssetType(assetTypeInt))
+		}
+	}
+//line cmd/spx-backend/get_assets_trending.yap:29:1
+	params.Pagination.Index = ctx.ParamInt("pageIndex", firstPageIndex)
+//line cmd/spx-backend/get_assets_trending.yap:30:1
+	params.Pagination.Size = ctx.ParamInt("pageSize", defaultPageSize)
+//line cmd/spx-backend/get_assets_trending.yap:31:1
+	if
+//line cmd/spx-backend/get_assets_trending.yap:31:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_assets_trending.yap:32:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_assets_trending.yap:33:1
+		return
+	}
+//line cmd/spx-backend/get_assets_trending.yap:36:1
+	assets, err := this.ctrl.ListTrendingAssets(ctx.Context(), params)
+//line cmd/spx-backend/get_assets_trending.yap:37:1
+	if err != nil {
+//line cmd/spx-backend/get_assets_trending.yap:38:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_assets_trending.yap:39:1
+		return
+	}
+//line cmd/spx-backend/get_assets_trending.yap:41:1
+	this.Json__1(assets)
+}
+func (this *get_assets_trending) Classfname() string {
+	return "get_assets_trending"
+}
+//line cmd/spx-backend/get_moderation_queue.yap:13
+func (this *get_moderation_queue) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_moderation_queue.yap:13:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_moderation_queue.yap:15:1
+	if
+//line cmd/spx-backend/get_moderation_queue.yap:15:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/get_moderation_queue.yap:16:1
+		return
+	}
+//line cmd/spx-backend/get_moderation_queue.yap:19:1
+	params := &controller.ListModerationQueueParams{}
+//line cmd/spx-backend/get_moderation_queue.yap:21:1
+	if
+//line cmd/spx-backend/get_moderation_queue.yap:21:1
+	moderationStatusParam := this.Gop_Env("moderationStatus"); moderationStatusParam != "" {
+//line cmd/spx-backend/get_moderation_queue.yap:22:1
+		moderationStatusInt, err := strconv.Atoi(moderationStatusParam)
+//line cmd/spx-backend/get_moderation_queue.yap:23:1
+		if err != nil {
+//line cmd/spx-backend/get_moderation_queue.yap:24:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_moderation_queue.yap:25:1
+			return
+		}
+//line cmd/spx-backend/get_moderation_queue.yap:27:1
+		moderationStatus := model.ModerationStatus(moderationStatusInt)
+//line cmd/spx-backend/get_moderation_queue.yap:28:1
+		params.ModerationStatus = &moderationStatus
+	}
+//line cmd/spx-backend/get_moderation_queue.yap:31:1
+	params.Pagination.Index = ctx.ParamInt("pageIndex", firstPageIndex)
+//line cmd/spx-backend/get_moderation_queue.yap:32:1
+	params.Pagination.Size = ctx.ParamInt("pageSize", defaultPageSize)
+//line cmd/spx-backend/get_moderation_queue.yap:33:1
+	if
+//line cmd/spx-backend/get_moderation_queue.yap:33:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_moderation_queue.yap:34:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_moderation_queue.yap:35:1
+		return
+	}
+//line cmd/spx-backend/get_moderation_queue.yap:38:1
+	queue, err := this.ctrl.ListModerationQueue(ctx.Context(), params)
+//line cmd/spx-backend/get_moderation_queue.yap:39:1
+	if err != nil {
+//line cmd/spx-backend/get_moderation_queue.yap:40:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_moderation_queue.yap:41:1
+		return
+	}
+//line cmd/spx-backend/get_moderation_queue.yap:43:1
+	this.Json__1(queue)
+}
+func (this *get_moderation_queue) Classfname() string {
+	return "get_moderation_queue"
+}
+//line cmd/spx-backend/get_moderation_reviews.yap:10
+func (this *get_moderation_reviews) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_moderation_reviews.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_moderation_reviews.yap:12:1
+	if
+//line cmd/spx-backend/get_moderation_reviews.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/get_moderation_reviews.yap:13:1
+		return
+	}
+//line cmd/spx-backend/get_moderation_reviews.yap:16:1
+	pagination := model.Pagination{Index: ctx.ParamInt("pageIndex", firstPageIndex), Size: ctx.ParamInt("pageSize", defaultPageSize)}
+//line cmd/spx-backend/get_moderation_reviews.yap:21:1
+	assets, err := this.ctrl.ListReviewQueue(ctx.Context(), pagination)
+//line cmd/spx-backend/get_moderation_reviews.yap:22:1
+	if err != nil {
+//line cmd/spx-backend/get_moderation_reviews.yap:23:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_moderation_reviews.yap:24:1
+		return
+	}
+//line cmd/spx-backend/get_moderation_reviews.yap:26:1
+	this.Json__1(assets)
+}
+func (this *get_moderation_reviews) Classfname() string {
+	return "get_moderation_reviews"
+}
+//line cmd/spx-backend/get_project_#owner_#name.yap:6
+func (this *get_project_owner_name) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_project_#owner_#name.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_project_#owner_#name.yap:8:1
+	project, err := this.ctrl.GetProject(ctx.Context(), this.Gop_Env("owner"), this.Gop_Env("name"))
+//line cmd/spx-backend/get_project_#owner_#name.yap:9:1
+	if err != nil {
+//line cmd/spx-backend/get_project_#owner_#name.yap:10:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_project_#owner_#name.yap:11:1
+		return
+	}
+//line cmd/spx-backend/get_project_#owner_#name.yap:13:1
+	this.Json__1(project)
+}
+func (this *get_project_owner_name) Classfname() string {
+	return "get_project_#owner_#name"
+}
+//line cmd/spx-backend/get_projects_list.yap:13
+func (this *get_projects_list) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_projects_list.yap:13:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_projects_list.yap:15:1
+	user, _ := controller.UserFromContext(ctx.Context())
+//line cmd/spx-backend/get_projects_list.yap:16:1
+	params := &controller.ListProjectsParams{}
+//line cmd/spx-backend/get_projects_list.yap:18:1
+	if
+//line cmd/spx-backend/get_projects_list.yap:18:1
+	isPublicParam := this.Gop_Env("isPublic"); isPublicParam != "" {
+//line cmd/spx-backend/get_projects_list.yap:19:1
+		isPublicInt, err := strconv.Atoi(isPublicParam)
+//line cmd/spx-backend/get_projects_list.yap:20:1
+		if err != nil {
+//line cmd/spx-backend/get_projects_list.yap:21:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_projects_list.yap:22:1
+			return
+		}
+//line cmd/spx-backend/get_projects_list.yap:24:1
+		isPublic := model.IsPublic(isPublicInt)
+//line cmd/spx-backend/get_projects_list.yap:25:1
+		params.IsPublic = &isPublic
+	}
+//line cmd/spx-backend/get_projects_list.yap:28:1
+	switch
+//line cmd/spx-backend/get_projects_list.yap:28:1
+	owner := this.Gop_Env("owner"); owner {
+//line cmd/spx-backend/get_projects_list.yap:29:1
+	case "":
+//line cmd/spx-backend/get_projects_list.yap:30:1
+		if user == nil {
+//line cmd/spx-backend/get_projects_list.yap:31:1
+			replyWithCode(ctx, errorUnauthorized)
+//line cmd/spx-backend/get_projects_list.yap:32:1
+			return
+		}
+//line cmd/spx-backend/get_projects_list.yap:34:1
+		params.Owner = &user.Name
+//line cmd/spx-backend/get_projects_list.yap:35:1
+	case "*":
+//line cmd/spx-backend/get_projects_list.yap:36:1
+		params.Owner = nil
+//line cmd/spx-backend/get_projects_list.yap:37:1
+	default:
+//line cmd/spx-backend/get_projects_list.yap:38:1
+		params.Owner = &owner
+	}
+//line cmd/spx-backend/get_projects_list.yap:41:1
+	params.Pagination.Index = this.ParamInt("pageIndex", firstPageIndex)
+//line cmd/spx-backend/get_projects_list.yap:42:1
+	params.Pagination.Size = this.ParamInt("pageSize", defaultPageSize)
+//line cmd/spx-backend/get_projects_list.yap:43:1
+	if
+//line cmd/spx-backend/get_projects_list.yap:43:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_projects_list.yap:44:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_projects_list.yap:45:1
+		return
+	}
+//line cmd/spx-backend/get_projects_list.yap:48:1
+	projects, err := this.ctrl.ListProjects(ctx.Context(), params)
+//line cmd/spx-backend/get_projects_list.yap:49:1
+	if err != nil {
+//line cmd/spx-backend/get_projects_list.yap:50:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_projects_list.yap:51:1
+		return
+	}
+//line cmd/spx-backend/get_projects_list.yap:53:1
+	this.Json__1(projects)
+}
+func (this *get_projects_list) Classfname() string {
+	return "get_projects_list"
+}
+//line cmd/spx-backend/get_reports_list.yap:13
+func (this *get_reports_list) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_reports_list.yap:13:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_reports_list.yap:15:1
+	if
+//line cmd/spx-backend/get_reports_list.yap:15:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/get_reports_list.yap:16:1
+		return
+	}
+//line cmd/spx-backend/get_reports_list.yap:19:1
+	params := &controller.ListAssetReportsParams{}
+//line cmd/spx-backend/get_reports_list.yap:21:1
+	if
+//line cmd/spx-backend/get_reports_list.yap:21:1
+	assetID := this.Gop_Env("assetId"); assetID != "" {
+//line cmd/spx-backend/get_reports_list.yap:22:1
+		params.AssetID = &assetID
+	}
+//line cmd/spx-backend/get_reports_list.yap:25:1
+	if
+//line cmd/spx-backend/get_reports_list.yap:25:1
+	stateParam := this.Gop_Env("state"); stateParam != "" {
+//line cmd/spx-backend/get_reports_list.yap:26:1
+		stateInt, err := strconv.Atoi(stateParam)
+//line cmd/spx-backend/get_reports_list.yap:27:1
+		if err != nil {
+//line cmd/spx-backend/get_reports_list.yap:28:1
+			replyWithCode(ctx, errorInvalidArgs)
+//line cmd/spx-backend/get_reports_list.yap:29:1
+			return
+		}
+//line cmd/spx-backend/get_reports_list.yap:31:1
+		state := model.AssetReportState(stateInt)
+//line cmd/spx-backend/get_reports_list.yap:32:1
+		params.State = &state
+	}
+//line cmd/spx-backend/get_reports_list.yap:35:1
+	params.Pagination.Index = ctx.ParamInt("pageIndex", firstPageIndex)
+//line cmd/spx-backend/get_reports_list.yap:36:1
+	params.Pagination.Size = ctx.ParamInt("pageSize", defaultPageSize)
+//line cmd/spx-backend/get_reports_list.yap:37:1
+	if
+//line cmd/spx-backend/get_reports_list.yap:37:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/get_reports_list.yap:38:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/get_reports_list.yap:39:1
+		return
+	}
+//line cmd/spx-backend/get_reports_list.yap:42:1
+	reports, err := this.ctrl.ListAssetReports(ctx.Context(), params)
+//line cmd/spx-backend/get_reports_list.yap:43:1
+	if err != nil {
+//line cmd/spx-backend/get_reports_list.yap:44:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_reports_list.yap:45:1
+		return
+	}
+//line cmd/spx-backend/get_reports_list.yap:47:1
+	this.Json__1(reports)
+}
+func (this *get_reports_list) Classfname() string {
+	return "get_reports_list"
+}
+//line cmd/spx-backend/get_tags_popular.yap:6
+func (this *get_tags_popular) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_tags_popular.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_tags_popular.yap:8:1
+	limit := ctx.ParamInt("limit", defaultPageSize)
+//line cmd/spx-backend/get_tags_popular.yap:9:1
+	if limit < 1 || limit > 100 {
+//line cmd/spx-backend/get_tags_popular.yap:10:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, "invalid limit")
+//line cmd/spx-backend/get_tags_popular.yap:11:1
+		return
+	}
+//line cmd/spx-backend/get_tags_popular.yap:14:1
+	tagCounts, err := this.ctrl.ListPopularTags(ctx.Context(), limit)
+//line cmd/spx-backend/get_tags_popular.yap:15:1
+	if err != nil {
+//line cmd/spx-backend/get_tags_popular.yap:16:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_tags_popular.yap:17:1
+		return
+	}
+//line cmd/spx-backend/get_tags_popular.yap:19:1
+	this.Json__1(tagCounts)
+}
+func (this *get_tags_popular) Classfname() string {
+	return "get_tags_popular"
+}
+//line cmd/spx-backend/get_util_upinfo.yap:6
+func (this *get_util_upinfo) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/get_util_upinfo.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/get_util_upinfo.yap:8:1
+	if
+//line cmd/spx-backend/get_util_upinfo.yap:8:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/get_util_upinfo.yap:9:1
+		return
+	}
+//line cmd/spx-backend/get_util_upinfo.yap:11:1
+	upInfo, err := this.ctrl.GetUpInfo(ctx.Context())
+//line cmd/spx-backend/get_util_upinfo.yap:12:1
+	if err != nil {
+//line cmd/spx-backend/get_util_upinfo.yap:13:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/get_util_upinfo.yap:14:1
+		return
+	}
+//line cmd/spx-backend/get_util_upinfo.yap:16:1
+	this.Json__1(upInfo)
+}
+func (this *get_util_upinfo) Classfname() string {
+	return "get_util_upinfo"
+}
+//line cmd/spx-backend/post_aigc_matting.yap:10
+func (this *post_aigc_matting) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_aigc_matting.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_aigc_matting.yap:12:1
+	_, ok := ensureUser(ctx)
+//line cmd/spx-backend/post_aigc_matting.yap:13:1
+	if !ok {
+//line cmd/spx-backend/post_aigc_matting.yap:14:1
+		return
+	}
+//line cmd/spx-backend/post_aigc_matting.yap:17:1
+	params := &controller.MattingParams{}
+//line cmd/spx-backend/post_aigc_matting.yap:18:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/post_aigc_matting.yap:19:1
+		return
+	}
+//line cmd/spx-backend/post_aigc_matting.yap:21:1
+	if
+//line cmd/spx-backend/post_aigc_matting.yap:21:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/post_aigc_matting.yap:22:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/post_aigc_matting.yap:23:1
+		return
+	}
+//line cmd/spx-backend/post_aigc_matting.yap:26:1
+	result, err := this.ctrl.Matting(ctx.Context(), params)
+//line cmd/spx-backend/post_aigc_matting.yap:27:1
+	if err != nil {
+//line cmd/spx-backend/post_aigc_matting.yap:28:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_aigc_matting.yap:29:1
+		return
+	}
+//line cmd/spx-backend/post_aigc_matting.yap:31:1
+	this.Json__1(result)
+}
+func (this *post_aigc_matting) Classfname() string {
+	return "post_aigc_matting"
+}
+//line cmd/spx-backend/post_asset.yap:10
+func (this *post_asset) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset.yap:12:1
+	user, ok := ensureUser(ctx)
+//line cmd/spx-backend/post_asset.yap:13:1
+	if !ok {
+//line cmd/spx-backend/post_asset.yap:14:1
+		return
+	}
+//line cmd/spx-backend/post_asset.yap:17:1
+	params := &controller.AddAssetParams{}
+//line cmd/spx-backend/post_asset.yap:18:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/post_asset.yap:19:1
+		return
+	}
+//line cmd/spx-backend/post_asset.yap:21:1
+	params.Owner = user.Name
+//line cmd/spx-backend/post_asset.yap:22:1
+	if
+//line cmd/spx-backend/post_asset.yap:22:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/post_asset.yap:23:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/post_asset.yap:24:1
+		return
+	}
+//line cmd/spx-backend/post_asset.yap:27:1
+	asset, err := this.ctrl.AddAsset(ctx.Context(), params)
+//line cmd/spx-backend/post_asset.yap:28:1
+	if err != nil {
+//line cmd/spx-backend/post_asset.yap:29:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_asset.yap:30:1
+		return
+	}
+//line cmd/spx-backend/post_asset.yap:32:1
+	this.Json__1(asset)
+}
+func (this *post_asset) Classfname() string {
+	return "post_asset"
+}
+//line cmd/spx-backend/post_asset_#id_click.yap:6
+func (this *post_asset_id_click) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset_#id_click.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset_#id_click.yap:8:1
+	clickCount, err := this.ctrl.IncrementAssetClickCount(ctx.Context(), this.Gop_Env("id"), clientIP(ctx.Request))
+//line cmd/spx-backend/post_asset_#id_click.yap:9:1
+	if err != nil {
+//line cmd/spx-backend/post_asset_#id_click.yap:10:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_asset_#id_click.yap:11:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_click.yap:13:1
+	this.Json__1(map[string]int64{"clickCount": clickCount})
+}
+func (this *post_asset_id_click) Classfname() string {
+	return "post_asset_#id_click"
+}
+//line cmd/spx-backend/post_asset_#id_download.yap:6
+func (this *post_asset_id_download) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset_#id_download.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset_#id_download.yap:8:1
+	downloadCount, err := this.ctrl.IncrementAssetDownloadCount(ctx.Context(), this.Gop_Env("id"))
+//line cmd/spx-backend/post_asset_#id_download.yap:9:1
+	if err != nil {
+//line cmd/spx-backend/post_asset_#id_download.yap:10:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_asset_#id_download.yap:11:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_download.yap:13:1
+	this.Json__1(map[string]int64{"downloadCount": downloadCount})
+}
+func (this *post_asset_id_download) Classfname() string {
+	return "post_asset_#id_download"
+}
+//line cmd/spx-backend/post_asset_#id_fork.yap:6
+func (this *post_asset_id_fork) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset_#id_fork.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset_#id_fork.yap:8:1
+	user, ok := ensureUser(ctx)
+//line cmd/spx-backend/post_asset_#id_fork.yap:9:1
+	if !ok {
+//line cmd/spx-backend/post_asset_#id_fork.yap:10:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_fork.yap:13:1
+	asset, err := this.ctrl.ForkAsset(ctx.Context(), this.Gop_Env("id"), user.Name)
+//line cmd/spx-backend/post_asset_#id_fork.yap:14:1
+	if err != nil {
+//line cmd/spx-backend/post_asset_#id_fork.yap:15:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_asset_#id_fork.yap:16:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_fork.yap:18:1
+	this.Json__1(asset)
+}
+func (this *post_asset_id_fork) Classfname() string {
+	return "post_asset_#id_fork"
+}
+//line cmd/spx-backend/post_asset_#id_moderate.yap:10
+func (this *post_asset_id_moderate) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset_#id_moderate.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset_#id_moderate.yap:12:1
+	if
+//line cmd/spx-backend/post_asset_#id_moderate.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/post_asset_#id_moderate.yap:13:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_moderate.yap:16:1
+	params := &controller.ModerateAssetParams{}
+//line cmd/spx-backend/post_asset_#id_moderate.yap:17:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/post_asset_#id_moderate.yap:18:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_moderate.yap:20:1
+	if
+//line cmd/spx-backend/post_asset_#id_moderate.yap:20:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/post_asset_#id_moderate.yap:21:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/post_asset_#id_moderate.yap:22:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_moderate.yap:25:1
+	moderation, err := this.ctrl.ModerateAsset(ctx.Context(), this.Gop_Env("id"), params)
+//line cmd/spx-backend/post_asset_#id_moderate.yap:26:1
+	if err != nil {
+//line cmd/spx-backend/post_asset_#id_moderate.yap:27:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_asset_#id_moderate.yap:28:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_moderate.yap:30:1
+	this.Json__1(moderation)
+}
+func (this *post_asset_id_moderate) Classfname() string {
+	return "post_asset_#id_moderate"
+}
+//line cmd/spx-backend/post_asset_#id_report.yap:10
+func (this *post_asset_id_report) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset_#id_report.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset_#id_report.yap:12:1
+	if
+//line cmd/spx-backend/post_asset_#id_report.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/post_asset_#id_report.yap:13:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_report.yap:16:1
+	params := &controller.ReportAssetParams{}
+//line cmd/spx-backend/post_asset_#id_report.yap:17:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/post_asset_#id_report.yap:18:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_report.yap:20:1
+	if
+//line cmd/spx-backend/post_asset_#id_report.yap:20:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/post_asset_#id_report.yap:21:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/post_asset_#id_report.yap:22:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_report.yap:25:1
+	if
+//line cmd/spx-backend/post_asset_#id_report.yap:25:1
+	err := this.ctrl.ReportAsset(ctx.Context(), this.Gop_Env("id"), params); err != nil {
+//line cmd/spx-backend/post_asset_#id_report.yap:26:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_asset_#id_report.yap:27:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_report.yap:29:1
+	this.Json__1(nil)
+}
+func (this *post_asset_id_report) Classfname() string {
+	return "post_asset_#id_report"
+}
+//line cmd/spx-backend/post_asset_#id_restore.yap:6
+func (this *post_asset_id_restore) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset_#id_restore.yap:6:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset_#id_restore.yap:8:1
+	if
+//line cmd/spx-backend/post_asset_#id_restore.yap:8:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/post_asset_#id_restore.yap:9:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_restore.yap:12:1
+	asset, err := this.ctrl.RestoreAsset(ctx.Context(), this.Gop_Env("id"))
+//line cmd/spx-backend/post_asset_#id_restore.yap:13:1
+	if err != nil {
+//line cmd/spx-backend/post_asset_#id_restore.yap:14:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/post_asset_#id_restore.yap:15:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_restore.yap:17:1
+	this.Json__1(asset)
+}
+func (this *post_asset_id_restore) Classfname() string {
+	return "post_asset_#id_restore"
+}
+//line cmd/spx-backend/post_asset_#id_review.yap:10
+func (this *post_asset_id_review) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/post_asset_#id_review.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/post_asset_#id_review.yap:12:1
+	if
+//line cmd/spx-backend/post_asset_#id_review.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/post_asset_#id_review.yap:13:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_review.yap:16:1
+	params := &controller.ModerateAssetParams{}
+//line cmd/spx-backend/post_asset_#id_review.yap:17:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/post_asset_#id_review.yap:18:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_review.yap:20:1
 	if
-//line cmd/spx-backend/get_assets_list.yap:67:1
+//line cmd/spx-backend/post_asset_#id_review.yap:20:1
 	ok, msg := params.Validate(); !ok {
-//line cmd/spx-backend/get_assets_list.yap:68:1
+//line cmd/spx-backend/post_asset_#id_review.yap:21:1
 		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
-//line cmd/spx-backend/get_assets_list.yap:69:1
+//line cmd/spx-backend/post_asset_#id_review.yap:22:1
 		return
 	}
-//line cmd/spx-backend/get_assets_list.yap:72:1
-	assets, err := this.ctrl.ListAssets(ctx.Context(), params)
-//line cmd/spx-backend/get_assets_list.yap:73:1
+//line cmd/spx-backend/post_asset_#id_review.yap:25:1
+	moderation, err := this.ctrl.ReviewAsset(ctx.Context(), this.Gop_Env("id"), params.Decision, params.Note)
+//line cmd/spx-backend/post_asset_#id_review.yap:26:1
 	if err != nil {
-//line cmd/spx-backend/get_assets_list.yap:74:1
+//line cmd/spx-backend/post_asset_#id_review.yap:27:1
 		replyWithInnerError(ctx, err)
-//line cmd/spx-backend/get_assets_list.yap:75:1
+//line cmd/spx-backend/post_asset_#id_review.yap:28:1
 		return
 	}
-//line cmd/spx-backend/get_assets_list.yap:77:1
-	this.Json__1(assets)
+//line cmd/spx-backend/post_asset_#id_review.yap:30:1
+	this.Json__1(moderation)
 }
-func (this *get_assets_list) Classfname() string {
-	return "get_assets_list"
+func (this *post_asset_id_review) Classfname() string {
+	return "post_asset_#id_review"
 }
-//line cmd/spx-backend/get_project_#owner_#name.yap:6
-func (this *get_project_owner_name) Main(_gop_arg0 *yap.Context) {
+//line cmd/spx-backend/post_asset_#id_submit.yap:7
+func (this *post_asset_id_submit) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
-//line cmd/spx-backend/get_project_#owner_#name.yap:6:1
+//line cmd/spx-backend/post_asset_#id_submit.yap:7:1
 	ctx := &this.Context
-//line cmd/spx-backend/get_project_#owner_#name.yap:8:1
-	project, err := this.ctrl.GetProject(ctx.Context(), this.Gop_Env("owner"), this.Gop_Env("name"))
-//line cmd/spx-backend/get_project_#owner_#name.yap:9:1
+//line cmd/spx-backend/post_asset_#id_submit.yap:9:1
+	user, ok := ensureUser(ctx)
+//line cmd/spx-backend/post_asset_#id_submit.yap:10:1
+	if !ok {
+//line cmd/spx-backend/post_asset_#id_submit.yap:11:1
+		return
+	}
+//line cmd/spx-backend/post_asset_#id_submit.yap:14:1
+	moderation, err := this.ctrl.SubmitAssetForReview(ctx.Context(), this.Gop_Env("id"), user.Name)
+//line cmd/spx-backend/post_asset_#id_submit.yap:15:1
 	if err != nil {
-//line cmd/spx-backend/get_project_#owner_#name.yap:10:1
+//line cmd/spx-backend/post_asset_#id_submit.yap:16:1
 		replyWithInnerError(ctx, err)
-//line cmd/spx-backend/get_project_#owner_#name.yap:11:1
+//line cmd/spx-backend/post_asset_#id_submit.yap:17:1
 		return
 	}
-//line cmd/spx-backend/get_project_#owner_#name.yap:13:1
-	this.Json__1(project)
+//line cmd/spx-backend/post_asset_#id_submit.yap:19:1
+	this.Json__1(moderation)
 }
-func (this *get_project_owner_name) Classfname() string {
-	return "get_project_#owner_#name"
+func (this *post_asset_id_submit) Classfname() string {
+	return "post_asset_#id_submit"
 }
-//line cmd/spx-backend/get_projects_list.yap:13
-func (this *get_projects_list) Main(_gop_arg0 *yap.Context) {
+//line cmd/spx-backend/post_asset_#id_transfer.yap:10
+func (this *post_asset_id_transfer) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
-//line cmd/spx-backend/get_projects_list.yap:13:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:10:1
 	ctx := &this.Context
-//line cmd/spx-backend/get_projects_list.yap:15:1
-	user, _ := controller.UserFromContext(ctx.Context())
-//line cmd/spx-backend/get_projects_list.yap:16:1
-	params := &controller.ListProjectsParams{}
-//line cmd/spx-backend/get_projects_list.yap:18:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:12:1
 	if
-//line cmd/spx-backend/get_projects_list.yap:18:1
-	isPublicParam := this.Gop_Env("isPublic"); isPublicParam != "" {
-//line cmd/spx-backend/get_projects_list.yap:19:1
-		isPublicInt, err := strconv.Atoi(isPublicParam)
-//line cmd/spx-backend/get_projects_list.yap:20:1
-		if err != nil {
-//line cmd/spx-backend/get_projects_list.yap:21:1
-			replyWithCode(ctx, errorInvalidArgs)
-//line cmd/spx-backend/get_projects_list.yap:22:1
-			return
-		}
-//line cmd/spx-backend/get_projects_list.yap:24:1
-		isPublic := model.IsPublic(isPublicInt)
-//line cmd/spx-backend/get_projects_list.yap:25:1
-		params.IsPublic = &isPublic
+//line cmd/spx-backend/post_asset_#id_transfer.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/post_asset_#id_transfer.yap:13:1
+		return
 	}
-//line cmd/spx-backend/get_projects_list.yap:28:1
-	switch
-//line cmd/spx-backend/get_projects_list.yap:28:1
-	owner := this.Gop_Env("owner"); owner {
-//line cmd/spx-backend/get_projects_list.yap:29:1
-	case "":
-//line cmd/spx-backend/get_projects_list.yap:30:1
-		if user == nil {
-//line cmd/spx-backend/get_projects_list.yap:31:1
-			replyWithCode(ctx, errorUnauthorized)
-//line cmd/spx-backend/get_projects_list.yap:32:1
-			return
-		}
-//line cmd/spx-backend/get_projects_list.yap:34:1
-		params.Owner = &user.Name
-//line cmd/spx-backend/get_projects_list.yap:35:1
-	case "*":
-//line cmd/spx-backend/get_projects_list.yap:36:1
-		params.Owner = nil
-//line cmd/spx-backend/get_projects_list.yap:37:1
-	default:
-//line cmd/spx-backend/get_projects_list.yap:38:1
-		params.Owner = &owner
+//line cmd/spx-backend/post_asset_#id_transfer.yap:16:1
+	params := &controller.TransferAssetOwnershipParams{}
+//line cmd/spx-backend/post_asset_#id_transfer.yap:17:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/post_asset_#id_transfer.yap:18:1
+		return
 	}
-//line cmd/spx-backend/get_projects_list.yap:41:1
-	params.Pagination.Index = this.ParamInt("pageIndex", firstPageIndex)
-//line cmd/spx-backend/get_projects_list.yap:42:1
-	params.Pagination.Size = this.ParamInt("pageSize", defaultPageSize)
-//line cmd/spx-backend/get_projects_list.yap:43:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:20:1
 	if
-//line cmd/spx-backend/get_projects_list.yap:43:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:20:1
 	ok, msg := params.Validate(); !ok {
-//line cmd/spx-backend/get_projects_list.yap:44:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:21:1
 		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
-//line cmd/spx-backend/get_projects_list.yap:45:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:22:1
 		return
 	}
-//line cmd/spx-backend/get_projects_list.yap:48:1
-	projects, err := this.ctrl.ListProjects(ctx.Context(), params)
-//line cmd/spx-backend/get_projects_list.yap:49:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:25:1
+	asset, err := this.ctrl.TransferAssetOwnership(ctx.Context(), this.Gop_Env("id"), params)
+//line cmd/spx-backend/post_asset_#id_transfer.yap:26:1
 	if err != nil {
-//line cmd/spx-backend/get_projects_list.yap:50:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:27:1
 		replyWithInnerError(ctx, err)
-//line cmd/spx-backend/get_projects_list.yap:51:1
+//line cmd/spx-backend/post_asset_#id_transfer.yap:28:1
 		return
 	}
-//line cmd/spx-backend/get_projects_list.yap:53:1
-	this.Json__1(projects)
+//line cmd/spx-backend/post_asset_#id_transfer.yap:30:1
+	this.Json__1(asset)
 }
-func (this *get_projects_list) Classfname() string {
-	return "get_projects_list"
+func (this *post_asset_id_transfer) Classfname() string {
+	return "post_asset_#id_transfer"
 }
-//line cmd/spx-backend/get_util_upinfo.yap:6
-func (this *get_util_upinfo) Main(_gop_arg0 *yap.Context) {
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:6
+func (this *post_asset_id_version_versionId_restore) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
-//line cmd/spx-backend/get_util_upinfo.yap:6:1
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:6:1
 	ctx := &this.Context
-//line cmd/spx-backend/get_util_upinfo.yap:8:1
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:8:1
 	if
-//line cmd/spx-backend/get_util_upinfo.yap:8:1
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:8:1
 	_, ok := ensureUser(ctx); !ok {
-//line cmd/spx-backend/get_util_upinfo.yap:9:1
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:9:1
 		return
 	}
-//line cmd/spx-backend/get_util_upinfo.yap:11:1
-	upInfo, err := this.ctrl.GetUpInfo(ctx.Context())
-//line cmd/spx-backend/get_util_upinfo.yap:12:1
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:12:1
+	asset, err := this.ctrl.RestoreAssetVersion(ctx.Context(), this.Gop_Env("id"), this.Gop_Env("versionId"))
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:13:1
 	if err != nil {
-//line cmd/spx-backend/get_util_upinfo.yap:13:1
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:14:1
 		replyWithInnerError(ctx, err)
-//line cmd/spx-backend/get_util_upinfo.yap:14:1
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:15:1
 		return
 	}
-//line cmd/spx-backend/get_util_upinfo.yap:16:1
-	this.Json__1(upInfo)
+//line cmd/spx-backend/post_asset_#id_version_#versionId_restore.yap:17:1
+	this.Json__1(asset)
 }
-func (this *get_util_upinfo) Classfname() string {
-	return "get_util_upinfo"
+func (this *post_asset_id_version_versionId_restore) Classfname() string {
+	return "post_asset_#id_version_#versionId_restore"
 }
-//line cmd/spx-backend/post_aigc_matting.yap:10
-func (this *post_aigc_matting) Main(_gop_arg0 *yap.Context) {
+//line cmd/spx-backend/post_assets_delete.yap:10
+func (this *post_assets_delete) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
-//line cmd/spx-backend/post_aigc_matting.yap:10:1
+//line cmd/spx-backend/post_assets_delete.yap:10:1
 	ctx := &this.Context
-//line cmd/spx-backend/post_aigc_matting.yap:12:1
-	_, ok := ensureUser(ctx)
-//line cmd/spx-backend/post_aigc_matting.yap:13:1
+//line cmd/spx-backend/post_assets_delete.yap:12:1
+	user, ok := ensureUser(ctx)
+//line cmd/spx-backend/post_assets_delete.yap:13:1
 	if !ok {
-//line cmd/spx-backend/post_aigc_matting.yap:14:1
+//line cmd/spx-backend/post_assets_delete.yap:14:1
 		return
 	}
-//line cmd/spx-backend/post_aigc_matting.yap:17:1
-	params := &controller.MattingParams{}
-//line cmd/spx-backend/post_aigc_matting.yap:18:1
+//line cmd/spx-backend/post_assets_delete.yap:17:1
+	params := &controller.DeleteAssetsParams{}
+//line cmd/spx-backend/post_assets_delete.yap:18:1
 	if !parseJSON(ctx, params) {
-//line cmd/spx-backend/post_aigc_matting.yap:19:1
+//line cmd/spx-backend/post_assets_delete.yap:19:1
 		return
 	}
-//line cmd/spx-backend/post_aigc_matting.yap:21:1
+//line cmd/spx-backend/post_assets_delete.yap:21:1
 	if
-//line cmd/spx-backend/post_aigc_matting.yap:21:1
+//line cmd/spx-backend/post_assets_delete.yap:21:1
 	ok, msg := params.Validate(); !ok {
-//line cmd/spx-backend/post_aigc_matting.yap:22:1
+//line cmd/spx-backend/post_assets_delete.yap:22:1
 		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
-//line cmd/spx-backend/post_aigc_matting.yap:23:1
+//line cmd/spx-backend/post_assets_delete.yap:23:1
 		return
 	}
-//line cmd/spx-backend/post_aigc_matting.yap:26:1
-	result, err := this.ctrl.Matting(ctx.Context(), params)
-//line cmd/spx-backend/post_aigc_matting.yap:27:1
+//line cmd/spx-backend/post_assets_delete.yap:26:1
+	results, err := this.ctrl.DeleteAssets(ctx.Context(), params.IDs, user.Name)
+//line cmd/spx-backend/post_assets_delete.yap:27:1
 	if err != nil {
-//line cmd/spx-backend/post_aigc_matting.yap:28:1
+//line cmd/spx-backend/post_assets_delete.yap:28:1
 		replyWithInnerError(ctx, err)
-//line cmd/spx-backend/post_aigc_matting.yap:29:1
+//line cmd/spx-backend/post_assets_delete.yap:29:1
 		return
 	}
-//line cmd/spx-backend/post_aigc_matting.yap:31:1
-	this.Json__1(result)
+//line cmd/spx-backend/post_assets_delete.yap:31:1
+	this.Json__1(results)
 }
-func (this *post_aigc_matting) Classfname() string {
-	return "post_aigc_matting"
+func (this *post_assets_delete) Classfname() string {
+	return "post_assets_delete"
 }
-//line cmd/spx-backend/post_asset.yap:10
-func (this *post_asset) Main(_gop_arg0 *yap.Context) {
+//line cmd/spx-backend/post_assets_transfer.yap:11
+func (this *post_assets_transfer) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
-//line cmd/spx-backend/post_asset.yap:10:1
+//line cmd/spx-backend/post_assets_transfer.yap:11:1
 	ctx := &this.Context
-//line cmd/spx-backend/post_asset.yap:12:1
-	user, ok := ensureUser(ctx)
-//line cmd/spx-backend/post_asset.yap:13:1
-	if !ok {
-//line cmd/spx-backend/post_asset.yap:14:1
+//line cmd/spx-backend/post_assets_transfer.yap:13:1
+	if
+//line cmd/spx-backend/post_assets_transfer.yap:13:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/post_assets_transfer.yap:14:1
 		return
 	}
-//line cmd/spx-backend/post_asset.yap:17:1
-	params := &controller.AddAssetParams{}
-//line cmd/spx-backend/post_asset.yap:18:1
+//line cmd/spx-backend/post_assets_transfer.yap:17:1
+	params := &controller.TransferAssetsParams{}
+//line cmd/spx-backend/post_assets_transfer.yap:18:1
 	if !parseJSON(ctx, params) {
-//line cmd/spx-backend/post_asset.yap:19:1
+//line cmd/spx-backend/post_assets_transfer.yap:19:1
 		return
 	}
-//line cmd/spx-backend/post_asset.yap:21:1
-	params.Owner = user.Name
-//line cmd/spx-backend/post_asset.yap:22:1
+//line cmd/spx-backend/post_assets_transfer.yap:21:1
 	if
-//line cmd/spx-backend/post_asset.yap:22:1
+//line cmd/spx-backend/post_assets_transfer.yap:21:1
 	ok, msg := params.Validate(); !ok {
-//line cmd/spx-backend/post_asset.yap:23:1
+//line cmd/spx-backend/post_assets_transfer.yap:22:1
 		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
-//line cmd/spx-backend/post_asset.yap:24:1
+//line cmd/spx-backend/post_assets_transfer.yap:23:1
 		return
 	}
-//line cmd/spx-backend/post_asset.yap:27:1
-	asset, err := this.ctrl.AddAsset(ctx.Context(), params)
-//line cmd/spx-backend/post_asset.yap:28:1
+//line cmd/spx-backend/post_assets_transfer.yap:26:1
+	result, err := this.ctrl.TransferAssets(ctx.Context(), params)
+//line cmd/spx-backend/post_assets_transfer.yap:27:1
 	if err != nil {
-//line cmd/spx-backend/post_asset.yap:29:1
+//line cmd/spx-backend/post_assets_transfer.yap:28:1
 		replyWithInnerError(ctx, err)
-//line cmd/spx-backend/post_asset.yap:30:1
+//line cmd/spx-backend/post_assets_transfer.yap:29:1
 		return
 	}
-//line cmd/spx-backend/post_asset.yap:32:1
-	this.Json__1(asset)
+//line cmd/spx-backend/post_assets_transfer.yap:31:1
+	this.Json__1(result)
 }
-func (this *post_asset) Classfname() string {
-	return "post_asset"
+func (this *post_assets_transfer) Classfname() string {
+	return "post_assets_transfer"
 }
-//line cmd/spx-backend/post_asset_#id_click.yap:6
-func (this *post_asset_id_click) Main(_gop_arg0 *yap.Context) {
+//line cmd/spx-backend/post_assets_verify.yap:11
+func (this *post_assets_verify) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
-//line cmd/spx-backend/post_asset_#id_click.yap:6:1
+//line cmd/spx-backend/post_assets_verify.yap:11:1
 	ctx := &this.Context
-//line cmd/spx-backend/post_asset_#id_click.yap:8:1
+//line cmd/spx-backend/post_assets_verify.yap:13:1
 	if
-//line cmd/spx-backend/post_asset_#id_click.yap:8:1
-	err := this.ctrl.IncreaseAssetClickCount(ctx.Context(), this.Gop_Env("id")); err != nil {
-//line cmd/spx-backend/post_asset_#id_click.yap:9:1
+//line cmd/spx-backend/post_assets_verify.yap:13:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/post_assets_verify.yap:14:1
+		return
+	}
+//line cmd/spx-backend/post_assets_verify.yap:17:1
+	params := &controller.VerifyAssetFilesParams{}
+//line cmd/spx-backend/post_assets_verify.yap:18:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/post_assets_verify.yap:19:1
+		return
+	}
+//line cmd/spx-backend/post_assets_verify.yap:21:1
+	if
+//line cmd/spx-backend/post_assets_verify.yap:21:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/post_assets_verify.yap:22:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/post_assets_verify.yap:23:1
+		return
+	}
+//line cmd/spx-backend/post_assets_verify.yap:26:1
+	result, err := this.ctrl.VerifyAssetFiles(ctx.Context(), params)
+//line cmd/spx-backend/post_assets_verify.yap:27:1
+	if err != nil {
+//line cmd/spx-backend/post_assets_verify.yap:28:1
 		replyWithInnerError(ctx, err)
-//line cmd/spx-backend/post_asset_#id_click.yap:10:1
+//line cmd/spx-backend/post_assets_verify.yap:29:1
 		return
 	}
-//line cmd/spx-backend/post_asset_#id_click.yap:12:1
-	this.Json__1(nil)
+//line cmd/spx-backend/post_assets_verify.yap:31:1
+	this.Json__1(result)
 }
-func (this *post_asset_id_click) Classfname() string {
-	return "post_asset_#id_click"
+func (this *post_assets_verify) Classfname() string {
+	return "post_assets_verify"
 }
 //line cmd/spx-backend/post_project.yap:10
 func (this *post_project) Main(_gop_arg0 *yap.Context) {
@@ -721,6 +1984,135 @@ func (this *put_asset_id) Main(_gop_arg0 *yap.Context) {
 func (this *put_asset_id) Classfname() string {
 	return "put_asset_#id"
 }
+//line cmd/spx-backend/put_asset_#id_name.yap:10
+func (this *put_asset_id_name) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/put_asset_#id_name.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/put_asset_#id_name.yap:12:1
+	if
+//line cmd/spx-backend/put_asset_#id_name.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/put_asset_#id_name.yap:13:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_name.yap:16:1
+	params := &controller.RenameAssetParams{}
+//line cmd/spx-backend/put_asset_#id_name.yap:17:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/put_asset_#id_name.yap:18:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_name.yap:20:1
+	if
+//line cmd/spx-backend/put_asset_#id_name.yap:20:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/put_asset_#id_name.yap:21:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/put_asset_#id_name.yap:22:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_name.yap:25:1
+	asset, err := this.ctrl.RenameAsset(ctx.Context(), this.Gop_Env("id"), params)
+//line cmd/spx-backend/put_asset_#id_name.yap:26:1
+	if err != nil {
+//line cmd/spx-backend/put_asset_#id_name.yap:27:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/put_asset_#id_name.yap:28:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_name.yap:30:1
+	this.Json__1(asset)
+}
+func (this *put_asset_id_name) Classfname() string {
+	return "put_asset_#id_name"
+}
+//line cmd/spx-backend/put_asset_#id_tags.yap:10
+func (this *put_asset_id_tags) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/put_asset_#id_tags.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/put_asset_#id_tags.yap:12:1
+	if
+//line cmd/spx-backend/put_asset_#id_tags.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/put_asset_#id_tags.yap:13:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_tags.yap:16:1
+	params := &controller.SetAssetTagsParams{}
+//line cmd/spx-backend/put_asset_#id_tags.yap:17:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/put_asset_#id_tags.yap:18:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_tags.yap:20:1
+	if
+//line cmd/spx-backend/put_asset_#id_tags.yap:20:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/put_asset_#id_tags.yap:21:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/put_asset_#id_tags.yap:22:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_tags.yap:25:1
+	tags, err := this.ctrl.SetAssetTags(ctx.Context(), this.Gop_Env("id"), params)
+//line cmd/spx-backend/put_asset_#id_tags.yap:26:1
+	if err != nil {
+//line cmd/spx-backend/put_asset_#id_tags.yap:27:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/put_asset_#id_tags.yap:28:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_tags.yap:30:1
+	this.Json__1(tags)
+}
+func (this *put_asset_id_tags) Classfname() string {
+	return "put_asset_#id_tags"
+}
+//line cmd/spx-backend/put_asset_#id_visibility.yap:10
+func (this *put_asset_id_visibility) Main(_gop_arg0 *yap.Context) {
+	this.Handler.Main(_gop_arg0)
+//line cmd/spx-backend/put_asset_#id_visibility.yap:10:1
+	ctx := &this.Context
+//line cmd/spx-backend/put_asset_#id_visibility.yap:12:1
+	if
+//line cmd/spx-backend/put_asset_#id_visibility.yap:12:1
+	_, ok := ensureUser(ctx); !ok {
+//line cmd/spx-backend/put_asset_#id_visibility.yap:13:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_visibility.yap:16:1
+	params := &controller.UpdateAssetVisibilityParams{}
+//line cmd/spx-backend/put_asset_#id_visibility.yap:17:1
+	if !parseJSON(ctx, params) {
+//line cmd/spx-backend/put_asset_#id_visibility.yap:18:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_visibility.yap:20:1
+	if
+//line cmd/spx-backend/put_asset_#id_visibility.yap:20:1
+	ok, msg := params.Validate(); !ok {
+//line cmd/spx-backend/put_asset_#id_visibility.yap:21:1
+		replyWithCodeMsg(ctx, errorInvalidArgs, msg)
+//line cmd/spx-backend/put_asset_#id_visibility.yap:22:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_visibility.yap:25:1
+	asset, err := this.ctrl.UpdateAssetVisibility(ctx.Context(), this.Gop_Env("id"), params)
+//line cmd/spx-backend/put_asset_#id_visibility.yap:26:1
+	if err != nil {
+//line cmd/spx-backend/put_asset_#id_visibility.yap:27:1
+		replyWithInnerError(ctx, err)
+//line cmd/spx-backend/put_asset_#id_visibility.yap:28:1
+		return
+	}
+//line cmd/spx-backend/put_asset_#id_visibility.yap:30:1
+	this.Json__1(asset)
+}
+func (this *put_asset_id_visibility) Classfname() string {
+	return "put_asset_#id_visibility"
+}
 //line cmd/spx-backend/put_project_#owner_#name.yap:10
 func (this *put_project_owner_name) Main(_gop_arg0 *yap.Context) {
 	this.Handler.Main(_gop_arg0)
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
+	"net/http"
+	"strings"
 
 	"github.com/goplus/builder/spx-backend/internal/controller"
 	"github.com/goplus/builder/spx-backend/internal/model"
@@ -33,6 +36,21 @@ func parseJSON(ctx *yap.Context, target any) (ok bool) {
 	return true
 }
 
+// clientIP returns the IP address of the client. The X-Real-IP header set by
+// the reverse proxy in front of the service is preferred. X-Forwarded-For is
+// ignored since its leading entries are supplied by the client and can be
+// spoofed.
+func clientIP(r *http.Request) string {
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // replyWithCode replies to the client with the error code.
 func replyWithCode(ctx *yap.Context, code errorCode) {
 	msg := errorMsgs[errorUnknown]
@@ -53,15 +71,24 @@ func replyWithCodeMsg(ctx *yap.Context, code errorCode, msg string) {
 
 // replyWithInnerError replies to the client with the inner error.
 func replyWithInnerError(ctx *yap.Context, err error) {
+	var displayNameConflictErr *model.DisplayNameConflictError
 	switch {
+	case errors.As(err, &displayNameConflictErr):
+		replyWithCodeMsg(ctx, errorInvalidArgs, displayNameConflictErr.Error())
 	case errors.Is(err, model.ErrExist):
 		replyWithCode(ctx, errorInvalidArgs)
+	case errors.Is(err, model.ErrInvalidTransition):
+		replyWithCodeMsg(ctx, errorInvalidArgs, err.Error())
+	case errors.Is(err, controller.ErrInvalidArgs):
+		replyWithCodeMsg(ctx, errorInvalidArgs, err.Error())
 	case errors.Is(err, controller.ErrUnauthorized):
 		replyWithCode(ctx, errorUnauthorized)
 	case errors.Is(err, controller.ErrForbidden):
 		replyWithCode(ctx, errorForbidden)
 	case errors.Is(err, controller.ErrNotExist), errors.Is(err, model.ErrNotExist):
 		replyWithCode(ctx, errorNotFound)
+	case errors.Is(err, controller.ErrTooManyRequests):
+		replyWithCode(ctx, errorTooManyRequests)
 	default:
 		replyWithCode(ctx, errorUnknown)
 	}
@@ -80,18 +107,20 @@ type errorCode int
 //
 // The first 3 digits of the value are the corresponding HTTP status code.
 const (
-	errorInvalidArgs  errorCode = 40001
-	errorUnauthorized errorCode = 40100
-	errorForbidden    errorCode = 40300
-	errorNotFound     errorCode = 40400
-	errorUnknown      errorCode = 50000
+	errorInvalidArgs     errorCode = 40001
+	errorUnauthorized    errorCode = 40100
+	errorForbidden       errorCode = 40300
+	errorNotFound        errorCode = 40400
+	errorTooManyRequests errorCode = 42900
+	errorUnknown         errorCode = 50000
 )
 
 // errorMsgs defines messages for error codes.
 var errorMsgs = map[errorCode]string{
-	errorInvalidArgs:  "Invalid args",
-	errorUnauthorized: "Unauthorized",
-	errorForbidden:    "Forbidden",
-	errorNotFound:     "Not found",
-	errorUnknown:      "Internal error",
+	errorInvalidArgs:     "Invalid args",
+	errorUnauthorized:    "Unauthorized",
+	errorForbidden:       "Forbidden",
+	errorNotFound:        "Not found",
+	errorTooManyRequests: "Too many requests",
+	errorUnknown:         "Internal error",
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	t.Run("RemoteAddr", func(t *testing.T) {
+		req := httptest.NewRequest("", "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		assert.Equal(t, "192.0.2.1", clientIP(req))
+	})
+
+	t.Run("XRealIP", func(t *testing.T) {
+		req := httptest.NewRequest("", "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Real-IP", "203.0.113.1")
+		assert.Equal(t, "203.0.113.1", clientIP(req))
+	})
+
+	t.Run("SpoofedXForwardedFor", func(t *testing.T) {
+		req := httptest.NewRequest("", "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.1")
+		req.Header.Set("X-Real-IP", "203.0.113.1")
+		assert.Equal(t, "203.0.113.1", clientIP(req))
+	})
+
+	t.Run("XForwardedForOnly", func(t *testing.T) {
+		req := httptest.NewRequest("", "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		assert.Equal(t, "192.0.2.1", clientIP(req))
+	})
+
+	t.Run("InvalidRemoteAddr", func(t *testing.T) {
+		req := httptest.NewRequest("", "/", nil)
+		req.RemoteAddr = "192.0.2.1"
+		assert.Equal(t, "192.0.2.1", clientIP(req))
+	})
+}
@@ -10,16 +10,196 @@ CREATE TABLE `asset`  (
                           `c_time` datetime NULL DEFAULT NULL,
                           `u_time` datetime NULL DEFAULT NULL,
                           `display_name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL,
+                          `description` varchar(1000) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
                           `owner` varchar(255) NULL DEFAULT NULL,
                           `category` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL,
                           `asset_type` int NULL DEFAULT NULL,
                           `files` json NULL,
                           `files_hash` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL,
+                          `files_meta` json NULL,
                           `preview` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL,
+                          `is_ai_generated` tinyint NOT NULL DEFAULT 0,
+                          `ai_provider` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+                          `license` varchar(64) NOT NULL DEFAULT 'all-rights-reserved',
                           `click_count` int NULL DEFAULT 0,
+                          `download_count` int NOT NULL DEFAULT 0,
+                          `forked_from` varchar(255) NOT NULL DEFAULT '',
+                          `fork_count` int NOT NULL DEFAULT 0,
                           `is_public` tinyint NULL DEFAULT NULL,
+                          `moderation_status` int NOT NULL DEFAULT 0,
+                          `deleted_at` datetime NULL DEFAULT NULL,
                           `status` int NULL DEFAULT NULL,
-                          PRIMARY KEY (`id`) USING BTREE
+                          PRIMARY KEY (`id`) USING BTREE,
+                          INDEX `idx_status_deleted_at`(`status`, `deleted_at`) USING BTREE,
+                          INDEX `idx_owner_display_name`(`owner`, `display_name`) USING BTREE,
+                          INDEX `idx_files`((CAST(JSON_EXTRACT(`files`, '$.*') AS CHAR(512) ARRAY))),
+                          INDEX `idx_preview`(`preview`(255)) USING BTREE,
+                          INDEX `idx_is_public_moderation_status_u_time`(`is_public`, `moderation_status`, `u_time`) USING BTREE,
+                          FULLTEXT INDEX `ft_display_name_description`(`display_name`, `description`) WITH PARSER ngram,
+                          FULLTEXT INDEX `ft_display_name`(`display_name`) WITH PARSER ngram
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_version
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_version`;
+CREATE TABLE `asset_version`  (
+                          `id` int NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NULL DEFAULT NULL,
+                          `u_time` datetime NULL DEFAULT NULL,
+                          `asset_id` int NOT NULL,
+                          `display_name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL,
+                          `description` varchar(1000) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+                          `category` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL,
+                          `asset_type` int NULL DEFAULT NULL,
+                          `files` json NULL,
+                          `files_hash` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL,
+                          `files_meta` json NULL,
+                          `preview` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL,
+                          `license` varchar(64) NOT NULL DEFAULT 'all-rights-reserved',
+                          `is_public` tinyint NOT NULL DEFAULT 0,
+                          `editor` varchar(255) NULL DEFAULT NULL,
+                          `status` int NULL DEFAULT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          INDEX `idx_asset_id`(`asset_id`) USING BTREE,
+                          INDEX `idx_files`((CAST(JSON_EXTRACT(`files`, '$.*') AS CHAR(512) ARRAY))),
+                          INDEX `idx_preview`(`preview`(255)) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for tag
+-- ----------------------------
+DROP TABLE IF EXISTS `tag`;
+CREATE TABLE `tag`  (
+                          `id` int NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NULL DEFAULT NULL,
+                          `u_time` datetime NULL DEFAULT NULL,
+                          `name` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL,
+                          `status` int NULL DEFAULT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          UNIQUE INDEX `uk_name`(`name`) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_tag
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_tag`;
+CREATE TABLE `asset_tag`  (
+                          `id` int NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NULL DEFAULT NULL,
+                          `u_time` datetime NULL DEFAULT NULL,
+                          `asset_id` int NOT NULL,
+                          `tag_id` int NOT NULL,
+                          `status` int NULL DEFAULT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          UNIQUE INDEX `uk_asset_id_tag_id`(`asset_id`, `tag_id`) USING BTREE,
+                          INDEX `idx_tag_id`(`tag_id`) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_event
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_event`;
+CREATE TABLE `asset_event`  (
+                          `id` bigint NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NOT NULL,
+                          `asset_id` int NOT NULL,
+                          `event_type` int NOT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          INDEX `idx_c_time_asset_id`(`c_time`, `asset_id`) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_click
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_click`;
+CREATE TABLE `asset_click`  (
+                          `id` bigint NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NOT NULL,
+                          `asset_id` int NOT NULL,
+                          `viewer` varchar(255) NOT NULL,
+                          `click_date` date NOT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          UNIQUE INDEX `uk_asset_id_viewer_click_date`(`asset_id`, `viewer`, `click_date`) USING BTREE,
+                          INDEX `idx_click_date`(`click_date`) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_view_daily
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_view_daily`;
+CREATE TABLE `asset_view_daily`  (
+                          `asset_id` int NOT NULL,
+                          `view_date` date NOT NULL,
+                          `views` bigint NOT NULL DEFAULT 0,
+                          `unique_viewers` bigint NOT NULL DEFAULT 0,
+                          PRIMARY KEY (`asset_id`, `view_date`) USING BTREE,
+                          INDEX `idx_view_date`(`view_date`) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_preview_failure
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_preview_failure`;
+CREATE TABLE `asset_preview_failure`  (
+                          `asset_id` int NOT NULL,
+                          `attempts` int NOT NULL DEFAULT 0,
+                          `failed_at` datetime NOT NULL,
+                          `permanent` tinyint NOT NULL DEFAULT 0,
+                          PRIMARY KEY (`asset_id`) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_report
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_report`;
+CREATE TABLE `asset_report`  (
+                          `id` int NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NULL DEFAULT NULL,
+                          `u_time` datetime NULL DEFAULT NULL,
+                          `asset_id` int NOT NULL,
+                          `reporter` varchar(255) NOT NULL,
+                          `reason` varchar(32) NOT NULL,
+                          `details` varchar(1000) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+                          `state` int NOT NULL DEFAULT 0,
+                          `status` int NULL DEFAULT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          UNIQUE INDEX `uk_asset_id_reporter`(`asset_id`, `reporter`) USING BTREE,
+                          INDEX `idx_reporter_c_time`(`reporter`, `c_time`) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_moderation
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_moderation`;
+CREATE TABLE `asset_moderation`  (
+                          `id` int NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NULL DEFAULT NULL,
+                          `u_time` datetime NULL DEFAULT NULL,
+                          `asset_id` int NOT NULL,
+                          `moderator` varchar(255) NOT NULL,
+                          `decision` varchar(32) NOT NULL,
+                          `note` varchar(1000) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+                          `status` int NULL DEFAULT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          INDEX `idx_asset_id`(`asset_id`) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
+
+-- ----------------------------
+-- Table structure for asset_transfer
+-- ----------------------------
+DROP TABLE IF EXISTS `asset_transfer`;
+CREATE TABLE `asset_transfer`  (
+                          `id` int NOT NULL AUTO_INCREMENT,
+                          `c_time` datetime NULL DEFAULT NULL,
+                          `u_time` datetime NULL DEFAULT NULL,
+                          `asset_id` int NOT NULL,
+                          `from_owner` varchar(255) NOT NULL,
+                          `to_owner` varchar(255) NOT NULL,
+                          `actor` varchar(255) NOT NULL,
+                          `status` int NULL DEFAULT NULL,
+                          PRIMARY KEY (`id`) USING BTREE,
+                          INDEX `idx_asset_id`(`asset_id`) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
 
 -- ----------------------------
@@ -36,7 +216,8 @@ CREATE TABLE `project`  (
                             `files` json NULL,
                             `is_public` tinyint NULL DEFAULT NULL,
                             `status` int NULL DEFAULT NULL,
-                            PRIMARY KEY (`id`) USING BTREE
+                            PRIMARY KEY (`id`) USING BTREE,
+                            INDEX `idx_files`((CAST(JSON_EXTRACT(`files`, '$.*') AS CHAR(512) ARRAY)))
 ) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = DYNAMIC;
 
 SET FOREIGN_KEY_CHECKS = 1;
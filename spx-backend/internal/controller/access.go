@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// AssetAccessReason is the reason a requester may or may not use an asset.
+type AssetAccessReason string
+
+const (
+	AssetAccessPublic    AssetAccessReason = "public"    // the asset is public
+	AssetAccessOwner     AssetAccessReason = "owner"     // the requester owns the asset
+	AssetAccessForbidden AssetAccessReason = "forbidden" // the asset is personal to someone else
+	AssetAccessNotFound  AssetAccessReason = "notFound"  // the asset does not exist, or is rejected or a draft
+	AssetAccessDeleted   AssetAccessReason = "deleted"   // the asset is deleted
+)
+
+// Allowed reports whether the reason grants access.
+func (r AssetAccessReason) Allowed() bool {
+	return r == AssetAccessPublic || r == AssetAccessOwner
+}
+
+// assetAccessReason decides whether requester may use asset, which exists and
+// is not deleted. An empty requester stands for an anonymous user.
+//
+// This is the single place the access policy of assets is defined.
+func assetAccessReason(asset *model.Asset, requester string) AssetAccessReason {
+	switch {
+	case requester != "" && asset.Owner == requester:
+		return AssetAccessOwner
+	case asset.IsPublic == model.Personal:
+		return AssetAccessForbidden
+	case asset.ModerationStatus == model.ModerationRejected, asset.ModerationStatus == model.ModerationDraft:
+		// Rejected and draft assets are gone for everyone but their owners.
+		return AssetAccessNotFound
+	}
+	return AssetAccessPublic
+}
+
+// maxCheckAssetAccess is the maximum number of assets whose access can be
+// checked at once.
+const maxCheckAssetAccess = 100
+
+// CheckAssetAccessParams holds parameters for checking access to assets.
+type CheckAssetAccessParams struct {
+	// IDs is the list of asset ids, duplicates allowed.
+	IDs []string
+}
+
+// Validate validates the parameters.
+func (p *CheckAssetAccessParams) Validate() (ok bool, msg string) {
+	if len(p.IDs) == 0 {
+		return false, "missing ids"
+	}
+	if len(p.IDs) > maxCheckAssetAccess {
+		return false, "too many ids"
+	}
+	return true, ""
+}
+
+// AssetAccess is the access of a requester to an asset.
+type AssetAccess struct {
+	// ID is the id of the asset.
+	ID string `json:"id"`
+
+	// Allowed indicates if the requester may use the asset.
+	Allowed bool `json:"allowed"`
+
+	// Reason is why the requester may or may not use the asset.
+	Reason AssetAccessReason `json:"reason"`
+}
+
+// CheckAssetAccess checks whether requester may use each of the assets with
+// given ids, e.g., before a project references them, with a single query.
+// An empty requester stands for an anonymous user, and any other requester
+// must be the user in the context. Results are in the order of the given ids.
+func (ctrl *Controller) CheckAssetAccess(ctx context.Context, assetIDs []string, requester string) ([]AssetAccess, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if requester != "" {
+		if _, err := EnsureUser(ctx, requester); err != nil {
+			return nil, err
+		}
+	}
+
+	assets, err := model.ListAssetsByIDsWithDeleted(ctx, ctrl.db, assetIDs)
+	if err != nil {
+		logger.Printf("failed to list assets by ids: %v", err)
+		return nil, err
+	}
+	assetsByID := make(map[string]*model.Asset, len(assets))
+	for i := range assets {
+		assetsByID[assets[i].ID] = &assets[i]
+	}
+
+	accesses := make([]AssetAccess, 0, len(assetIDs))
+	for _, id := range assetIDs {
+		var reason AssetAccessReason
+		switch asset, ok := assetsByID[id]; {
+		case !ok:
+			reason = AssetAccessNotFound
+		case asset.Status == model.StatusDeleted:
+			reason = AssetAccessDeleted
+		default:
+			reason = assetAccessReason(asset, requester)
+		}
+		accesses = append(accesses, AssetAccess{ID: id, Allowed: reason.Allowed(), Reason: reason})
+	}
+	return accesses, nil
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetAccessReason(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		asset     model.Asset
+		requester string
+		want      AssetAccessReason
+	}{
+		{"Public", model.Asset{Owner: "fake-name", IsPublic: model.Public}, "another-fake-name", AssetAccessPublic},
+		{"PublicAnonymous", model.Asset{Owner: "fake-name", IsPublic: model.Public}, "", AssetAccessPublic},
+		{"Owner", model.Asset{Owner: "fake-name", IsPublic: model.Personal}, "fake-name", AssetAccessOwner},
+		{"OwnerRejected", model.Asset{Owner: "fake-name", IsPublic: model.Public, ModerationStatus: model.ModerationRejected}, "fake-name", AssetAccessOwner},
+		{"Personal", model.Asset{Owner: "fake-name", IsPublic: model.Personal}, "another-fake-name", AssetAccessForbidden},
+		{"PersonalAnonymous", model.Asset{Owner: "fake-name", IsPublic: model.Personal}, "", AssetAccessForbidden},
+		{"OwnerDraft", model.Asset{Owner: "fake-name", IsPublic: model.Public, ModerationStatus: model.ModerationDraft}, "fake-name", AssetAccessOwner},
+		{"Draft", model.Asset{Owner: "fake-name", IsPublic: model.Public, ModerationStatus: model.ModerationDraft}, "another-fake-name", AssetAccessNotFound},
+		{"Rejected", model.Asset{Owner: "fake-name", IsPublic: model.Public, ModerationStatus: model.ModerationRejected}, "another-fake-name", AssetAccessNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, assetAccessReason(&tt.asset, tt.requester))
+		})
+	}
+}
+
+func TestCheckAssetAccessParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &CheckAssetAccessParams{IDs: []string{"1", "2"}}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("MissingIDs", func(t *testing.T) {
+		params := &CheckAssetAccessParams{}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing ids", msg)
+	})
+
+	t.Run("TooManyIDs", func(t *testing.T) {
+		params := &CheckAssetAccessParams{IDs: make([]string, maxCheckAssetAccess+1)}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "too many ids", msg)
+	})
+}
+
+func TestControllerCheckAssetAccess(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?,\?,\?,\?,\?,\?\)$`).
+			WithArgs("1", "2", "3", "4", "5", "1").
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "is_public", "moderation_status", "status"}).
+				AddRow(1, "another-fake-name", model.Public, model.ModerationVisible, model.StatusNormal).
+				AddRow(2, "fake-name", model.Personal, model.ModerationVisible, model.StatusNormal).
+				AddRow(3, "another-fake-name", model.Personal, model.ModerationVisible, model.StatusNormal).
+				AddRow(4, "another-fake-name", model.Public, model.ModerationVisible, model.StatusDeleted))
+		accesses, err := ctrl.CheckAssetAccess(ctx, []string{"1", "2", "3", "4", "5", "1"}, "fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, []AssetAccess{
+			{ID: "1", Allowed: true, Reason: AssetAccessPublic},
+			{ID: "2", Allowed: true, Reason: AssetAccessOwner},
+			{ID: "3", Allowed: false, Reason: AssetAccessForbidden},
+			{ID: "4", Allowed: false, Reason: AssetAccessDeleted},
+			{ID: "5", Allowed: false, Reason: AssetAccessNotFound},
+			{ID: "1", Allowed: true, Reason: AssetAccessPublic},
+		}, accesses)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\)$`).
+			WithArgs("1").
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "is_public", "status"}).
+				AddRow(1, "fake-name", model.Personal, model.StatusNormal))
+		accesses, err := ctrl.CheckAssetAccess(context.Background(), []string{"1"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, []AssetAccess{{ID: "1", Allowed: false, Reason: AssetAccessForbidden}}, accesses)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.CheckAssetAccess(ctx, []string{"1"}, "another-fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\)$`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.CheckAssetAccess(ctx, []string{"1"}, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
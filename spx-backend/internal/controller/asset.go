@@ -2,7 +2,14 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/goplus/builder/spx-backend/internal/log"
 	"github.com/goplus/builder/spx-backend/internal/model"
@@ -11,6 +18,12 @@ import (
 // assetDisplayNameRE is the regular expression for asset display name.
 var assetDisplayNameRE = regexp.MustCompile(`^.{1,100}$`)
 
+// assetDescriptionRE is the regular expression for asset description.
+var assetDescriptionRE = regexp.MustCompile(`^(?s).{0,1000}$`)
+
+// assetRestoreWindow is how long a deleted asset can still be restored.
+const assetRestoreWindow = 30 * 24 * time.Hour
+
 // ensureAsset ensures the asset exists and the user has access to it.
 func (ctrl *Controller) ensureAsset(ctx context.Context, id string, ownedOnly bool) (*model.Asset, error) {
 	logger := log.GetReqLogger(ctx)
@@ -20,14 +33,30 @@ func (ctrl *Controller) ensureAsset(ctx context.Context, id string, ownedOnly bo
 		logger.Printf("failed to get asset: %v", err)
 		return nil, err
 	}
+	if err := checkAssetAccess(ctx, asset, ownedOnly); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
 
-	if ownedOnly || asset.IsPublic == model.Personal {
-		if _, err := EnsureUser(ctx, asset.Owner); err != nil {
-			return nil, err
-		}
+// checkAssetAccess checks if the user has access to the asset according to
+// [assetAccessReason]. If ownedOnly is true, only the owner has access.
+func checkAssetAccess(ctx context.Context, asset *model.Asset, ownedOnly bool) error {
+	var requester string
+	if user, ok := UserFromContext(ctx); ok {
+		requester = user.Name
 	}
 
-	return asset, nil
+	switch reason := assetAccessReason(asset, requester); {
+	case reason == AssetAccessOwner:
+		return nil
+	case ownedOnly || reason == AssetAccessForbidden:
+		_, err := EnsureUser(ctx, asset.Owner)
+		return err
+	case reason == AssetAccessPublic:
+		return nil
+	}
+	return ErrNotExist
 }
 
 // GetAsset gets asset by id.
@@ -35,18 +64,88 @@ func (ctrl *Controller) GetAsset(ctx context.Context, id string) (*model.Asset,
 	return ctrl.ensureAsset(ctx, id, false)
 }
 
+// maxBatchGetAssets is the maximum number of assets that can be got at once.
+const maxBatchGetAssets = 100
+
+// GetAssetsParams holds parameters for getting assets by ids.
+type GetAssetsParams struct {
+	// IDs is the list of asset ids, duplicates allowed.
+	IDs []string
+}
+
+// Validate validates the parameters.
+func (p *GetAssetsParams) Validate() (ok bool, msg string) {
+	if len(p.IDs) == 0 {
+		return false, "missing ids"
+	}
+	if len(p.IDs) > maxBatchGetAssets {
+		return false, "too many ids"
+	}
+	return true, ""
+}
+
+// AssetsByIDs holds assets got by ids.
+type AssetsByIDs struct {
+	// Data is the list of assets in the order of the requested ids, with nil
+	// for ids in Missing.
+	Data []*model.Asset `json:"data"`
+
+	// Missing is the list of requested ids of assets that do not exist or
+	// are not accessible.
+	Missing []string `json:"missing"`
+}
+
+// GetAssets gets assets by ids with a single query. Assets the user has no
+// access to are reported as missing, just like deleted ones.
+func (ctrl *Controller) GetAssets(ctx context.Context, params *GetAssetsParams) (*AssetsByIDs, error) {
+	logger := log.GetReqLogger(ctx)
+
+	assets, err := model.ListAssetsByIDs(ctx, ctrl.db, params.IDs)
+	if err != nil {
+		logger.Printf("failed to list assets by ids: %v", err)
+		return nil, err
+	}
+	assetsByID := make(map[string]*model.Asset, len(assets))
+	for i := range assets {
+		asset := &assets[i]
+		if checkAssetAccess(ctx, asset, false) == nil {
+			assetsByID[asset.ID] = asset
+		}
+	}
+
+	result := &AssetsByIDs{
+		Data:    make([]*model.Asset, len(params.IDs)),
+		Missing: []string{},
+	}
+	for i, id := range params.IDs {
+		asset, ok := assetsByID[id]
+		if !ok {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+		result.Data[i] = asset
+	}
+	return result, nil
+}
+
 // ListAssetsOrderBy is the order by condition for listing assets.
 type ListAssetsOrderBy string
 
 var (
-	DefaultOrder   ListAssetsOrderBy = "default"
-	TimeDesc       ListAssetsOrderBy = "time"
-	ClickCountDesc ListAssetsOrderBy = "clickCount"
+	DefaultOrder      ListAssetsOrderBy = "default"
+	TimeDesc          ListAssetsOrderBy = "time"
+	UpdateTimeDesc    ListAssetsOrderBy = "updateTime"
+	ClickCountDesc    ListAssetsOrderBy = "clickCount"
+	DownloadCountDesc ListAssetsOrderBy = "downloadCount"
+	NameAsc           ListAssetsOrderBy = "nameAsc"
+	NameDesc          ListAssetsOrderBy = "nameDesc"
+	Relevance         ListAssetsOrderBy = "relevance"
 )
 
 // ListAssetsParams holds parameters for listing assets.
 type ListAssetsParams struct {
-	// Keyword is the keyword filter for the display name, applied only if non-empty.
+	// Keyword is the keyword filter for the display name, description and
+	// tag names, applied only if non-empty.
 	Keyword string
 
 	// Owner is the owner filter, applied only if non-nil.
@@ -55,8 +154,8 @@ type ListAssetsParams struct {
 	// Category is the category filter, applied only if non-nil.
 	Category *string
 
-	// AccessType is the access type filter, applied only if non-nil.
-	AssetType *model.AssetType
+	// AssetTypes is the asset type filter, applied only if non-empty.
+	AssetTypes []model.AssetType
 
 	// FilesHash is the files hash filter, applied only if non-nil.
 	FilesHash *string
@@ -64,59 +163,323 @@ type ListAssetsParams struct {
 	// IsPublic is the visibility filter, applied only if non-nil.
 	IsPublic *model.IsPublic
 
+	// ModerationStatus is the moderation status filter, applied only if
+	// non-nil.
+	ModerationStatus *model.ModerationStatus
+
+	// IsAiGenerated is the AI generated filter, applied only if non-nil.
+	IsAiGenerated *bool
+
+	// Licenses is the license filter, applied only if non-empty.
+	Licenses []model.AssetLicense
+
+	// Remixable is the remixability filter, applied only if non-nil. Assets
+	// with restrictive licenses are not remixable.
+	Remixable *bool
+
+	// Tags is the tag filter, applied only if non-empty. Only assets with all
+	// of the tags are matched.
+	Tags []string
+
 	// OrderBy is the order by condition.
 	OrderBy ListAssetsOrderBy
 
+	// IncludeDeleted tells whether to include deleted assets. Only admins may
+	// set it.
+	IncludeDeleted bool
+
 	// Pagination is the pagination information.
 	Pagination model.Pagination
 }
 
 // Validate validates the parameters.
 func (p *ListAssetsParams) Validate() (ok bool, msg string) {
+	for _, assetType := range p.AssetTypes {
+		switch assetType {
+		case model.AssetTypeSprite, model.AssetTypeBackdrop, model.AssetTypeSound:
+		default:
+			return false, "invalid assetType"
+		}
+	}
+	for _, license := range p.Licenses {
+		if !license.IsValid() {
+			return false, "invalid license"
+		}
+	}
+	if ok, msg := validateTags(p.Tags); !ok {
+		return false, msg
+	}
+	switch p.OrderBy {
+	case "", DefaultOrder, TimeDesc, UpdateTimeDesc, ClickCountDesc, DownloadCountDesc, NameAsc, NameDesc, Relevance:
+	default:
+		return false, "invalid orderBy"
+	}
 	return true, ""
 }
 
+// conditions builds the filter and order by conditions for listing assets.
+//
+// The keyword is matched with the full-text index using fullTextQuery if it is
+// non-empty, and with substring matching otherwise.
+func (p *ListAssetsParams) conditions(fullTextQuery string) ([]model.FilterCondition, []model.OrderByCondition) {
+	var wheres []model.FilterCondition
+	if p.Keyword != "" {
+		textFilter := model.FilterCondition{Column: "CONCAT_WS(' ', display_name, description)", Operation: "LIKE", Value: "%" + p.Keyword + "%"}
+		if fullTextQuery != "" {
+			textFilter = model.FilterCondition{Column: model.AssetFullTextColumns, Operation: "MATCH", Value: fullTextQuery}
+		}
+		wheres = append(wheres, model.FilterCondition{Operation: "OR", Value: []model.FilterCondition{
+			textFilter,
+			model.AssetTagNameFilter("%" + strings.ToLower(strings.TrimSpace(p.Keyword)) + "%"),
+		}})
+	}
+	if p.Owner != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "owner", Operation: "=", Value: *p.Owner})
+	}
+	if p.Category != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "category", Operation: "=", Value: *p.Category})
+	}
+	if len(p.AssetTypes) > 0 {
+		wheres = append(wheres, model.FilterCondition{Column: "asset_type", Operation: "IN", Value: p.AssetTypes})
+	}
+	if p.FilesHash != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "files_hash", Operation: "=", Value: *p.FilesHash})
+	}
+	if p.IsPublic != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "is_public", Operation: "=", Value: *p.IsPublic})
+	}
+	if p.ModerationStatus != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "moderation_status", Operation: "=", Value: *p.ModerationStatus})
+	}
+	if p.IsAiGenerated != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "is_ai_generated", Operation: "=", Value: *p.IsAiGenerated})
+	}
+	if len(p.Licenses) > 0 {
+		wheres = append(wheres, model.FilterCondition{Column: "license", Operation: "IN", Value: p.Licenses})
+	}
+	if p.Remixable != nil {
+		var licenses []model.AssetLicense
+		for _, license := range model.AssetLicenses {
+			if license.Remixable() == *p.Remixable {
+				licenses = append(licenses, license)
+			}
+		}
+		wheres = append(wheres, model.FilterCondition{Column: "license", Operation: "IN", Value: licenses})
+	}
+	if tags := normalizeTags(p.Tags); len(tags) > 0 {
+		wheres = append(wheres, model.AssetTagsFilter(tags))
+	}
+
+	// Every order ends with the id, so rows with equal sort values keep the
+	// same order across pages instead of being repeated or skipped.
+	var orders []model.OrderByCondition
+	switch p.OrderBy {
+	case TimeDesc:
+		orders = append(orders,
+			model.OrderByCondition{Column: "c_time", Direction: "DESC"},
+			model.OrderByCondition{Column: "id", Direction: "DESC"},
+		)
+	case UpdateTimeDesc:
+		orders = append(orders,
+			model.OrderByCondition{Column: "u_time", Direction: "DESC"},
+			model.OrderByCondition{Column: "id", Direction: "DESC"},
+		)
+	case ClickCountDesc:
+		orders = append(orders,
+			model.OrderByCondition{Column: "click_count", Direction: "DESC"},
+			model.OrderByCondition{Column: "id", Direction: "DESC"},
+		)
+	case DownloadCountDesc:
+		orders = append(orders,
+			model.OrderByCondition{Column: "download_count", Direction: "DESC"},
+			model.OrderByCondition{Column: "id", Direction: "DESC"},
+		)
+	case NameAsc:
+		// The display_name column uses a case-insensitive collation, so no
+		// extra folding is needed.
+		orders = append(orders,
+			model.OrderByCondition{Column: "display_name", Direction: "ASC"},
+			model.OrderByCondition{Column: "id", Direction: "ASC"},
+		)
+	case NameDesc:
+		orders = append(orders,
+			model.OrderByCondition{Column: "display_name", Direction: "DESC"},
+			model.OrderByCondition{Column: "id", Direction: "DESC"},
+		)
+	case Relevance:
+		// Name matches come first, then matches in the description. Assets
+		// matched by tags only come last. Without a keyword there is no
+		// relevance to order by, so the default order is kept.
+		if fullTextQuery != "" {
+			orders = append(orders,
+				model.MatchRelevanceOrder(model.AssetNameFullTextColumns, fullTextQuery),
+				model.MatchRelevanceOrder(model.AssetFullTextColumns, fullTextQuery),
+				model.OrderByCondition{Column: "id", Direction: "ASC"},
+			)
+		} else if p.Keyword != "" {
+			orders = append(orders,
+				model.OrderByCondition{Column: "display_name LIKE ?", Direction: "DESC", Args: []any{"%" + p.Keyword + "%"}},
+				model.OrderByCondition{Column: "description LIKE ?", Direction: "DESC", Args: []any{"%" + p.Keyword + "%"}},
+				model.OrderByCondition{Column: "id", Direction: "ASC"},
+			)
+		}
+	}
+	return wheres, orders
+
+}
+
 // ListAssets lists assets.
 func (ctrl *Controller) ListAssets(ctx context.Context, params *ListAssetsParams) (*model.ByPage[model.Asset], error) {
 	logger := log.GetReqLogger(ctx)
 
-	// Ensure non-owners can only see public assets.
+	// Ensure only admins can see deleted assets.
+	listAssets := model.ListAssets
+	if params.IncludeDeleted {
+		if _, err := EnsureAdmin(ctx); err != nil {
+			return nil, err
+		}
+		listAssets = model.ListAssetsWithDeleted
+	}
+
+	// Ensure non-owners can only see public assets not hidden by moderation.
 	if user, ok := UserFromContext(ctx); !ok || params.Owner == nil || user.Name != *params.Owner {
 		public := model.Public
 		params.IsPublic = &public
+		visible := model.ModerationVisible
+		params.ModerationStatus = &visible
 	}
 
-	var wheres []model.FilterCondition
-	if params.Keyword != "" {
-		wheres = append(wheres, model.FilterCondition{Column: "display_name", Operation: "LIKE", Value: "%" + params.Keyword + "%"})
+	fullTextQuery, _ := model.FullTextQuery(params.Keyword)
+	wheres, orders := params.conditions(fullTextQuery)
+	assets, err := listAssets(ctx, ctrl.db, params.Pagination, wheres, orders)
+	if err != nil && fullTextQuery != "" && model.IsFullTextIndexMissing(err) {
+		logger.Printf("full-text index is missing, falling back to substring matching: %v", err)
+		wheres, orders = params.conditions("")
+		assets, err = listAssets(ctx, ctrl.db, params.Pagination, wheres, orders)
+	}
+	if err != nil {
+		logger.Printf("failed to list assets : %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
+
+// ListTrendingAssetsParams holds parameters for listing trending assets.
+type ListTrendingAssetsParams struct {
+	// AssetTypes is the asset type filter, applied only if non-empty.
+	AssetTypes []model.AssetType
+
+	// Pagination is the pagination information.
+	Pagination model.Pagination
+}
+
+// Validate validates the parameters.
+func (p *ListTrendingAssetsParams) Validate() (ok bool, msg string) {
+	for _, assetType := range p.AssetTypes {
+		switch assetType {
+		case model.AssetTypeSprite, model.AssetTypeBackdrop, model.AssetTypeSound:
+		default:
+			return false, "invalid assetType"
+		}
 	}
-	if params.Owner != nil {
-		wheres = append(wheres, model.FilterCondition{Column: "owner", Operation: "=", Value: *params.Owner})
+	return true, ""
+}
+
+// ListTrendingAssets lists visible public assets ordered by their recent popularity.
+func (ctrl *Controller) ListTrendingAssets(ctx context.Context, params *ListTrendingAssetsParams) (*model.ByPage[model.Asset], error) {
+	logger := log.GetReqLogger(ctx)
+
+	wheres := []model.FilterCondition{
+		{Column: "is_public", Operation: "=", Value: model.Public},
+		{Column: "moderation_status", Operation: "=", Value: model.ModerationVisible},
+	}
+	if len(params.AssetTypes) > 0 {
+		wheres = append(wheres, model.FilterCondition{Column: "asset_type", Operation: "IN", Value: params.AssetTypes})
+	}
+
+	now := time.Now().UTC()
+	assets, err := model.ListTrendingAssets(ctx, ctrl.db, params.Pagination, wheres, model.TrendingScoring{
+		Now:      now,
+		Since:    now.Add(-ctrl.trending.window),
+		HalfLife: ctrl.trending.halfLife,
+		Weights:  ctrl.trending.weights,
+	})
+	if err != nil {
+		logger.Printf("failed to list trending assets: %v", err)
+		return nil, err
 	}
-	if params.Category != nil {
-		wheres = append(wheres, model.FilterCondition{Column: "category", Operation: "=", Value: *params.Category})
+	return assets, nil
+}
+
+// ListRecentAssets lists visible public assets of the given type, or of all
+// types if assetType is nil, most recently updated first. AI generated assets
+// that have no files yet are left out.
+func (ctrl *Controller) ListRecentAssets(ctx context.Context, assetType *model.AssetType, pagination model.Pagination) (*model.ByPage[model.Asset], error) {
+	logger := log.GetReqLogger(ctx)
+
+	// Only plain column comparisons are used, so that the rows can be walked
+	// in order through the index on (is_public, moderation_status, u_time).
+	wheres := []model.FilterCondition{
+		{Column: "is_public", Operation: "=", Value: model.Public},
+		{Column: "moderation_status", Operation: "=", Value: model.ModerationVisible},
+		{Operation: "OR", Value: []model.FilterCondition{
+			{Column: "is_ai_generated", Operation: "=", Value: false},
+			{Column: "files_hash", Operation: "!=", Value: ""},
+		}},
 	}
-	if params.AssetType != nil {
-		wheres = append(wheres, model.FilterCondition{Column: "asset_type", Operation: "=", Value: *params.AssetType})
+	if assetType != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "asset_type", Operation: "=", Value: *assetType})
 	}
-	if params.FilesHash != nil {
-		wheres = append(wheres, model.FilterCondition{Column: "files_hash", Operation: "=", Value: *params.FilesHash})
+	orders := []model.OrderByCondition{
+		{Column: "u_time", Direction: "DESC"},
+		{Column: "id", Direction: "DESC"},
 	}
-	if params.IsPublic != nil {
-		wheres = append(wheres, model.FilterCondition{Column: "is_public", Operation: "=", Value: *params.IsPublic})
+	assets, err := model.ListAssets(ctx, ctrl.db, pagination, wheres, orders)
+	if err != nil {
+		logger.Printf("failed to list recent assets: %v", err)
+		return nil, err
 	}
+	return assets, nil
+}
 
-	var orders []model.OrderByCondition
-	switch params.OrderBy {
-	case TimeDesc:
-		orders = append(orders, model.OrderByCondition{Column: "c_time", Direction: "DESC"})
-	case ClickCountDesc:
-		orders = append(orders, model.OrderByCondition{Column: "click_count", Direction: "DESC"})
+// maxRandomAssets is the maximum number of random assets that can be listed at
+// once.
+const maxRandomAssets = 50
+
+// ListRandomAssetsParams holds parameters for listing random assets.
+type ListRandomAssetsParams struct {
+	// AssetType is the asset type filter.
+	AssetType model.AssetType
+
+	// Count is the maximum number of assets to list.
+	Count int
+}
+
+// Validate validates the parameters.
+func (p *ListRandomAssetsParams) Validate() (ok bool, msg string) {
+	switch p.AssetType {
+	case model.AssetTypeSprite, model.AssetTypeBackdrop, model.AssetTypeSound:
+	default:
+		return false, "invalid assetType"
 	}
+	if p.Count < 1 || p.Count > maxRandomAssets {
+		return false, "invalid count"
+	}
+	return true, ""
+}
+
+// ListRandomAssets lists random visible public assets of the given type.
+func (ctrl *Controller) ListRandomAssets(ctx context.Context, params *ListRandomAssetsParams) ([]model.Asset, error) {
+	logger := log.GetReqLogger(ctx)
 
-	assets, err := model.ListAssets(ctx, ctrl.db, params.Pagination, wheres, orders)
+	wheres := []model.FilterCondition{
+		{Column: "is_public", Operation: "=", Value: model.Public},
+		{Column: "moderation_status", Operation: "=", Value: model.ModerationVisible},
+		{Column: "asset_type", Operation: "=", Value: params.AssetType},
+	}
+	assets, err := model.ListRandomAssets(ctx, ctrl.db, wheres, params.Count)
 	if err != nil {
-		logger.Printf("failed to list assets : %v", err)
+		logger.Printf("failed to list random assets: %v", err)
 		return nil, err
 	}
 	return assets, nil
@@ -124,14 +487,18 @@ func (ctrl *Controller) ListAssets(ctx context.Context, params *ListAssetsParams
 
 // AddAssetParams holds parameters for adding an asset.
 type AddAssetParams struct {
-	DisplayName string               `json:"displayName"`
-	Owner       string               `json:"owner"`
-	Category    string               `json:"category"`
-	AssetType   model.AssetType      `json:"assetType"`
-	Files       model.FileCollection `json:"files"`
-	FilesHash   string               `json:"filesHash"`
-	Preview     string               `json:"preview"`
-	IsPublic    model.IsPublic       `json:"isPublic"`
+	DisplayName   string               `json:"displayName"`
+	Description   string               `json:"description"`
+	Owner         string               `json:"owner"`
+	Category      string               `json:"category"`
+	AssetType     model.AssetType      `json:"assetType"`
+	Files         model.FileCollection `json:"files"`
+	FilesHash     string               `json:"filesHash"`
+	Preview       string               `json:"preview"`
+	IsPublic      model.IsPublic       `json:"isPublic"`
+	IsAiGenerated bool                 `json:"isAiGenerated"`
+	AiProvider    string               `json:"aiProvider"`
+	License       model.AssetLicense   `json:"license"`
 }
 
 // Validate validates the parameters.
@@ -141,6 +508,9 @@ func (p *AddAssetParams) Validate() (ok bool, msg string) {
 	} else if !assetDisplayNameRE.Match([]byte(p.DisplayName)) {
 		return false, "invalid displayName"
 	}
+	if !assetDescriptionRE.MatchString(p.Description) {
+		return false, "invalid description"
+	}
 	if p.Owner == "" {
 		return false, "missing owner"
 	}
@@ -160,10 +530,21 @@ func (p *AddAssetParams) Validate() (ok bool, msg string) {
 	default:
 		return false, "invalid isPublic"
 	}
+	if !p.IsAiGenerated && p.AiProvider != "" {
+		return false, "unexpected aiProvider"
+	}
+	if p.License != "" && !p.License.IsValid() {
+		return false, "invalid license"
+	}
 	return true, ""
 }
 
-// AddAsset adds an asset.
+// AddAsset adds an asset. New assets are drafts, hidden from everyone but the
+// owner until submitted for review and approved. Assets added without a
+// license have all rights reserved.
+//
+// Assets added without a preview get one rendered from their files in the
+// background, so rendering never holds up or fails the addition.
 func (ctrl *Controller) AddAsset(ctx context.Context, params *AddAssetParams) (*model.Asset, error) {
 	logger := log.GetReqLogger(ctx)
 
@@ -172,32 +553,146 @@ func (ctrl *Controller) AddAsset(ctx context.Context, params *AddAssetParams) (*
 		return nil, err
 	}
 
+	license := params.License
+	if license == "" {
+		license = model.LicenseAllRightsReserved
+	}
+
 	asset, err := model.AddAsset(ctx, ctrl.db, &model.Asset{
-		DisplayName: params.DisplayName,
-		Owner:       user.Name,
-		Category:    params.Category,
-		AssetType:   params.AssetType,
-		Files:       params.Files,
-		FilesHash:   params.FilesHash,
-		Preview:     params.Preview,
-		IsPublic:    params.IsPublic,
-	})
+		DisplayName:      params.DisplayName,
+		Description:      params.Description,
+		Owner:            user.Name,
+		Category:         params.Category,
+		AssetType:        params.AssetType,
+		Files:            params.Files,
+		FilesHash:        params.FilesHash,
+		FilesMeta:        ctrl.probeFiles(ctx, params.Files),
+		Preview:          params.Preview,
+		IsPublic:         params.IsPublic,
+		IsAiGenerated:    params.IsAiGenerated,
+		AiProvider:       params.AiProvider,
+		License:          license,
+		ModerationStatus: model.ModerationDraft,
+	}, ctrl.displayNamePolicy)
 	if err != nil {
 		logger.Printf("failed to add asset: %v", err)
 		return nil, err
 	}
+	if params.Preview == "" {
+		ctrl.renderAssetPreviewAsync(ctx, asset)
+	}
 	return asset, nil
 }
 
+// ForkAsset forks asset with given id into an asset owned by owner, and
+// returns the fork. The source asset must be public or owned by owner.
+//
+// Files stored in the bucket are duplicated, so the fork keeps working after
+// the source asset is deleted. The fork starts as a personal asset.
+func (ctrl *Controller) ForkAsset(ctx context.Context, id string, owner string) (*model.Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	user, err := EnsureUser(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := ctrl.ensureAsset(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies are deleted again if the fork fails, as no asset would reference
+	// them for the garbage collector to find.
+	var copies []string
+	copyObject := func(object string) (string, error) {
+		copied, err := ctrl.copyObject(object)
+		if err == nil && copied != object {
+			copies = append(copies, copied)
+		}
+		return copied, err
+	}
+	deleteCopies := func() {
+		for _, copied := range copies {
+			key, _ := ctrl.parseKodoObject(copied)
+			if err := ctrl.deleteObject(key); err != nil {
+				logger.Printf("failed to delete copied object %s: %v", copied, err)
+			}
+		}
+	}
+
+	files := make(model.FileCollection, len(source.Files))
+	for path, object := range source.Files {
+		files[path], err = copyObject(object)
+		if err != nil {
+			logger.Printf("failed to copy file %q: %v", path, err)
+			deleteCopies()
+			return nil, err
+		}
+	}
+	preview, err := copyObject(source.Preview)
+	if err != nil {
+		logger.Printf("failed to copy preview: %v", err)
+		deleteCopies()
+		return nil, err
+	}
+
+	fork, err := model.ForkAsset(ctx, ctrl.db, &model.Asset{
+		DisplayName:      source.DisplayName,
+		Description:      source.Description,
+		Owner:            user.Name,
+		Category:         source.Category,
+		AssetType:        source.AssetType,
+		Files:            files,
+		FilesHash:        source.FilesHash,
+		FilesMeta:        source.FilesMeta,
+		Preview:          preview,
+		IsAiGenerated:    source.IsAiGenerated,
+		AiProvider:       source.AiProvider,
+		License:          source.License,
+		ForkedFrom:       source.ID,
+		IsPublic:         model.Personal,
+		ModerationStatus: model.ModerationDraft,
+	}, ctrl.displayNamePolicy)
+	if err != nil {
+		logger.Printf("failed to fork asset: %v", err)
+		deleteCopies()
+		return nil, err
+	}
+	return fork, nil
+}
+
+// copyObject copies the object with given universal URL to a new key in the
+// bucket, and returns the universal URL of the copy. Objects outside the
+// bucket are returned as is.
+func (ctrl *Controller) copyObject(object string) (string, error) {
+	srcKey, ok := ctrl.parseKodoObject(object)
+	if !ok {
+		return object, nil
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	destKey := fmt.Sprintf("forks/%s-%s", hex.EncodeToString(suffix), path.Base(srcKey))
+	if err := ctrl.bucketManager.Copy(ctrl.kodo.bucket, srcKey, ctrl.kodo.bucket, destKey, false); err != nil {
+		return "", fmt.Errorf("failed to copy object %s: %w", object, err)
+	}
+	return (&url.URL{Scheme: "kodo", Host: ctrl.kodo.bucket, Path: "/" + destKey}).String(), nil
+}
+
 // UpdateAssetParams holds parameters for updating an asset.
 type UpdateAssetParams struct {
 	DisplayName string               `json:"displayName"`
+	Description string               `json:"description"`
 	Category    string               `json:"category"`
 	AssetType   model.AssetType      `json:"assetType"`
 	Files       model.FileCollection `json:"files"`
 	FilesHash   string               `json:"filesHash"`
 	Preview     string               `json:"preview"`
 	IsPublic    model.IsPublic       `json:"isPublic"`
+	License     model.AssetLicense   `json:"license"`
 }
 
 // Validate validates the parameters.
@@ -207,6 +702,9 @@ func (p *UpdateAssetParams) Validate() (ok bool, msg string) {
 	} else if !assetDisplayNameRE.Match([]byte(p.DisplayName)) {
 		return false, "invalid displayName"
 	}
+	if !assetDescriptionRE.MatchString(p.Description) {
+		return false, "invalid description"
+	}
 	if p.Category == "" {
 		return false, "missing category"
 	}
@@ -223,10 +721,15 @@ func (p *UpdateAssetParams) Validate() (ok bool, msg string) {
 	default:
 		return false, "invalid isPublic"
 	}
+	if p.License != "" && !p.License.IsValid() {
+		return false, "invalid license"
+	}
 	return true, ""
 }
 
-// UpdateAsset updates an asset.
+// UpdateAsset updates an asset. The license is kept as is if not given. Like
+// [Controller.AddAsset], assets updated without a preview get one rendered in
+// the background.
 func (ctrl *Controller) UpdateAsset(ctx context.Context, id string, updates *UpdateAssetParams) (*model.Asset, error) {
 	logger := log.GetReqLogger(ctx)
 
@@ -235,36 +738,165 @@ func (ctrl *Controller) UpdateAsset(ctx context.Context, id string, updates *Upd
 		return nil, err
 	}
 
+	// Files with the same hash need not be probed again.
+	filesMeta := asset.FilesMeta
+	if updates.FilesHash != asset.FilesHash || filesMeta == nil {
+		filesMeta = ctrl.probeFiles(ctx, updates.Files)
+	}
+	license := updates.License
+	if license == "" {
+		license = asset.License
+	}
+
 	updatedAsset, err := model.UpdateAssetByID(ctx, ctrl.db, asset.ID, &model.Asset{
 		DisplayName: updates.DisplayName,
+		Description: updates.Description,
 		Category:    updates.Category,
 		AssetType:   updates.AssetType,
 		Files:       updates.Files,
 		FilesHash:   updates.FilesHash,
+		FilesMeta:   filesMeta,
 		Preview:     updates.Preview,
 		IsPublic:    updates.IsPublic,
-	})
+		License:     license,
+	}, asset.Owner, ctrl.assetVersionLimit, ctrl.displayNamePolicy)
 	if err != nil {
 		logger.Printf("failed to update asset: %v", err)
 		return nil, err
 	}
+	if updates.Preview == "" {
+		// Failures to render the preview from the previous files do not
+		// apply to the new ones.
+		if err := model.DeleteAssetPreviewFailure(ctx, ctrl.db, asset.ID); err != nil {
+			logger.Printf("failed to delete asset preview failure: %v", err)
+		}
+		ctrl.renderAssetPreviewAsync(ctx, updatedAsset)
+	}
+	return updatedAsset, nil
+}
+
+// UpdateAssetVisibilityParams holds parameters for updating visibility of an
+// asset.
+type UpdateAssetVisibilityParams struct {
+	IsPublic model.IsPublic `json:"isPublic"`
+}
+
+// Validate validates the parameters.
+func (p *UpdateAssetVisibilityParams) Validate() (ok bool, msg string) {
+	switch p.IsPublic {
+	case model.Personal, model.Public:
+	default:
+		return false, "invalid isPublic"
+	}
+	return true, ""
+}
+
+// UpdateAssetVisibility updates only the visibility of an asset. Only the owner
+// is allowed.
+func (ctrl *Controller) UpdateAssetVisibility(ctx context.Context, id string, params *UpdateAssetVisibilityParams) (*model.Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := ctrl.ensureAsset(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAsset, err := model.UpdateAssetVisibilityByID(ctx, ctrl.db, asset.ID, params.IsPublic)
+	if err != nil {
+		logger.Printf("failed to update asset visibility: %v", err)
+		return nil, err
+	}
 	return updatedAsset, nil
 }
 
-// IncreaseAssetClickCount increases the click count of an asset.
-func (ctrl *Controller) IncreaseAssetClickCount(ctx context.Context, id string) error {
+// RenameAssetParams holds parameters for renaming an asset.
+type RenameAssetParams struct {
+	DisplayName string `json:"displayName"`
+}
+
+// Validate validates the parameters. Leading and trailing spaces of the
+// display name are ignored.
+func (p *RenameAssetParams) Validate() (ok bool, msg string) {
+	displayName := strings.TrimSpace(p.DisplayName)
+	if displayName == "" {
+		return false, "missing displayName"
+	} else if !assetDisplayNameRE.MatchString(displayName) {
+		return false, "invalid displayName"
+	}
+	return true, ""
+}
+
+// RenameAsset updates only the display name of an asset, leaving other fields
+// untouched. Only the owner is allowed. The previous name is kept in the
+// version history of the asset.
+func (ctrl *Controller) RenameAsset(ctx context.Context, id string, params *RenameAssetParams) (*model.Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := ctrl.ensureAsset(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAsset, err := model.UpdateAssetDisplayNameByID(ctx, ctrl.db, asset.ID, strings.TrimSpace(params.DisplayName), asset.Owner, ctrl.assetVersionLimit, ctrl.displayNamePolicy)
+	if err != nil {
+		logger.Printf("failed to rename asset: %v", err)
+		return nil, err
+	}
+	return updatedAsset, nil
+}
+
+// ListDisplayNameDuplicates lists display names shared by multiple assets of
+// the same owner, so they can be cleaned up. Only admins are allowed.
+func (ctrl *Controller) ListDisplayNameDuplicates(ctx context.Context, pagination model.Pagination) ([]model.DisplayNameDuplicate, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	duplicates, err := model.ListDisplayNameDuplicates(ctx, ctrl.db, pagination)
+	if err != nil {
+		logger.Printf("failed to list display name duplicates: %v", err)
+		return nil, err
+	}
+	return duplicates, nil
+}
+
+// IncrementAssetClickCount increases the click count of an asset and returns
+// the new click count. Repeated clicks of the same viewer on the same day are
+// counted only once. Anonymous viewers are identified by clientIP.
+func (ctrl *Controller) IncrementAssetClickCount(ctx context.Context, id string, clientIP string) (int64, error) {
 	logger := log.GetReqLogger(ctx)
 
 	asset, err := ctrl.ensureAsset(ctx, id, false)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if err := model.IncreaseAssetClickCount(ctx, ctrl.db, asset.ID); err != nil {
-		logger.Printf("failed to increase asset click count: %v", err)
-		return err
+	clickCount, err := model.IncrementAssetClickCount(ctx, ctrl.db, asset.ID, ctrl.clickViewer(ctx, clientIP))
+	if err != nil {
+		logger.Printf("failed to increment asset click count: %v", err)
+		return 0, err
 	}
-	return nil
+	return clickCount, nil
+}
+
+// IncrementAssetDownloadCount increases the download count of an asset and
+// returns the new download count.
+func (ctrl *Controller) IncrementAssetDownloadCount(ctx context.Context, id string) (int64, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := ctrl.ensureAsset(ctx, id, false)
+	if err != nil {
+		return 0, err
+	}
+
+	downloadCount, err := model.IncrementAssetDownloadCount(ctx, ctrl.db, asset.ID)
+	if err != nil {
+		logger.Printf("failed to increment asset download count: %v", err)
+		return 0, err
+	}
+	return downloadCount, nil
 }
 
 // DeleteAsset deletes an asset.
@@ -280,5 +912,184 @@ func (ctrl *Controller) DeleteAsset(ctx context.Context, id string) error {
 		logger.Printf("failed to delete asset: %v", err)
 		return err
 	}
+	ctrl.categoryCache.reset()
 	return nil
 }
+
+// maxDeleteAssets is the maximum number of assets that can be deleted at once.
+const maxDeleteAssets = 500
+
+// assetDeleteBatchSize is the number of assets deleted per transaction when
+// deleting assets in bulk.
+const assetDeleteBatchSize = 100
+
+// DeleteAssetsParams holds parameters for deleting assets in bulk.
+type DeleteAssetsParams struct {
+	// IDs is the ids of the assets to delete.
+	IDs []string `json:"ids"`
+}
+
+// Validate validates the parameters.
+func (p *DeleteAssetsParams) Validate() (ok bool, msg string) {
+	if len(p.IDs) == 0 {
+		return false, "missing ids"
+	}
+	if len(p.IDs) > maxDeleteAssets {
+		return false, fmt.Sprintf("too many ids (max %d)", maxDeleteAssets)
+	}
+	for _, id := range p.IDs {
+		if !assetIDRE.MatchString(id) {
+			return false, "invalid ids"
+		}
+	}
+	return true, ""
+}
+
+// DeleteAssetStatus is the outcome of deleting a single asset in bulk.
+type DeleteAssetStatus string
+
+const (
+	DeleteAssetDeleted   DeleteAssetStatus = "deleted"
+	DeleteAssetNotFound  DeleteAssetStatus = "notFound"
+	DeleteAssetForbidden DeleteAssetStatus = "forbidden"
+)
+
+// DeleteAssetResult is the outcome of deleting an asset in bulk.
+type DeleteAssetResult struct {
+	// ID is the id of the asset.
+	ID string `json:"id"`
+
+	// Status is the outcome for the asset.
+	Status DeleteAssetStatus `json:"status"`
+}
+
+// DeleteAssets deletes assets with given ids owned by owner, one transaction
+// per batch. Only the owner is allowed. Assets of other owners are left
+// untouched. It returns the outcome for each id, in the given order.
+func (ctrl *Controller) DeleteAssets(ctx context.Context, ids []string, owner string) ([]DeleteAssetResult, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureUser(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]DeleteAssetStatus, len(ids))
+	var uniqueIDs []string
+	for _, id := range ids {
+		if _, ok := statuses[id]; !ok {
+			statuses[id] = DeleteAssetNotFound
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+	var deleted bool
+	for len(uniqueIDs) > 0 {
+		batch := uniqueIDs[:min(assetDeleteBatchSize, len(uniqueIDs))]
+		uniqueIDs = uniqueIDs[len(batch):]
+
+		owners, err := model.DeleteOwnedAssets(ctx, ctrl.db, batch, owner)
+		if err != nil {
+			logger.Printf("failed to delete assets: %v", err)
+			return nil, err
+		}
+		for id, assetOwner := range owners {
+			if assetOwner == owner {
+				statuses[id] = DeleteAssetDeleted
+				deleted = true
+			} else {
+				statuses[id] = DeleteAssetForbidden
+			}
+		}
+	}
+	if deleted {
+		// Deleted assets drop out of public listings, so their categories
+		// must not be served from cache.
+		ctrl.categoryCache.reset()
+	}
+
+	results := make([]DeleteAssetResult, 0, len(ids))
+	for _, id := range ids {
+		results = append(results, DeleteAssetResult{ID: id, Status: statuses[id]})
+	}
+	return results, nil
+}
+
+// RestoreAsset restores a deleted asset. Only assets deleted within
+// [assetRestoreWindow] can be restored.
+func (ctrl *Controller) RestoreAsset(ctx context.Context, id string) (*model.Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := model.DeletedAssetByID(ctx, ctrl.db, id)
+	if err != nil {
+		logger.Printf("failed to get deleted asset: %v", err)
+		return nil, err
+	}
+	if _, err := EnsureUser(ctx, asset.Owner); err != nil {
+		return nil, err
+	}
+	if asset.DeletedAt == nil || time.Since(*asset.DeletedAt) > assetRestoreWindow {
+		return nil, ErrNotExist
+	}
+
+	restoredAsset, err := model.RestoreAssetByID(ctx, ctrl.db, asset.ID)
+	if err != nil {
+		logger.Printf("failed to restore asset: %v", err)
+		return nil, err
+	}
+	ctrl.categoryCache.reset()
+	return restoredAsset, nil
+}
+
+// ListAssetVersions lists versions of an asset, newest first.
+func (ctrl *Controller) ListAssetVersions(ctx context.Context, id string, pagination model.Pagination) (*model.ByPage[model.AssetVersion], error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := ctrl.ensureAsset(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := model.ListAssetVersions(ctx, ctrl.db, asset.ID, pagination)
+	if err != nil {
+		logger.Printf("failed to list asset versions: %v", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
+// RestoreAssetVersion restores an asset to one of its versions. The state
+// being replaced is kept as a new version.
+func (ctrl *Controller) RestoreAssetVersion(ctx context.Context, id string, versionID string) (*model.Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := ctrl.ensureAsset(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := model.AssetVersionByID(ctx, ctrl.db, versionID)
+	if err != nil {
+		logger.Printf("failed to get asset version: %v", err)
+		return nil, err
+	}
+	if version.AssetID != asset.ID {
+		return nil, ErrNotExist
+	}
+
+	updatedAsset, err := model.UpdateAssetByID(ctx, ctrl.db, asset.ID, &model.Asset{
+		DisplayName: version.DisplayName,
+		Description: version.Description,
+		Category:    version.Category,
+		AssetType:   version.AssetType,
+		Files:       version.Files,
+		FilesHash:   version.FilesHash,
+		FilesMeta:   version.FilesMeta,
+		Preview:     version.Preview,
+		IsPublic:    version.IsPublic,
+		License:     version.License,
+	}, asset.Owner, ctrl.assetVersionLimit, ctrl.displayNamePolicy)
+	if err != nil {
+		logger.Printf("failed to restore asset version: %v", err)
+		return nil, err
+	}
+	return updatedAsset, nil
+}
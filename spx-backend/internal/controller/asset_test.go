@@ -1,12 +1,20 @@
 package controller
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"errors"
+	"math/rand"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/goplus/builder/spx-backend/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -119,6 +127,108 @@ func TestControllerGetAsset(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorIs(t, err, model.ErrNotExist)
 	})
+
+	t.Run("RejectedByOwner", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public", "moderation_status"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public, model.ModerationRejected))
+		asset, err := ctrl.GetAsset(ctx, "1")
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, model.ModerationRejected, asset.ModerationStatus)
+	})
+
+	t.Run("RejectedByOthers", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public", "moderation_status"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public, model.ModerationRejected))
+		_, err = ctrl.GetAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+}
+
+func TestGetAssetsParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &GetAssetsParams{IDs: []string{"1", "2"}}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("MissingIDs", func(t *testing.T) {
+		params := &GetAssetsParams{}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing ids", msg)
+	})
+
+	t.Run("TooManyIDs", func(t *testing.T) {
+		params := &GetAssetsParams{IDs: make([]string, maxBatchGetAssets+1)}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "too many ids", msg)
+	})
+}
+
+func TestControllerGetAssets(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?,\?,\?,\?,\?\) AND status != \?`).
+			WithArgs("3", "1", "2", "4", "1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow("1", "fake-asset", "another-fake-name", model.Public).
+				AddRow("2", "another-fake-asset", "another-fake-name", model.Personal).
+				AddRow("3", "my-fake-asset", "fake-name", model.Personal))
+		assets, err := ctrl.GetAssets(ctx, &GetAssetsParams{IDs: []string{"3", "1", "2", "4", "1"}})
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		require.Len(t, assets.Data, 5)
+		assert.Equal(t, "3", assets.Data[0].ID)
+		assert.Equal(t, "1", assets.Data[1].ID)
+		assert.Nil(t, assets.Data[2])
+		assert.Nil(t, assets.Data[3])
+		assert.Equal(t, "1", assets.Data[4].ID)
+		assert.Equal(t, []string{"2", "4"}, assets.Missing)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?,\?\) AND status != \?`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public", "moderation_status"}).
+				AddRow("1", "fake-asset", "fake-name", model.Personal, model.ModerationVisible).
+				AddRow("2", "another-fake-asset", "fake-name", model.Public, model.ModerationRejected))
+		assets, err := ctrl.GetAssets(context.Background(), &GetAssetsParams{IDs: []string{"1", "2"}})
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Equal(t, []*model.Asset{nil, nil}, assets.Data)
+		assert.Equal(t, []string{"1", "2"}, assets.Missing)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\) AND status != \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.GetAssets(ctx, &GetAssetsParams{IDs: []string{"1"}})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
 }
 
 func TestListAssetsParamsValidate(t *testing.T) {
@@ -131,7 +241,7 @@ func TestListAssetsParamsValidate(t *testing.T) {
 			Keyword:    "fake",
 			Owner:      &paramsOwner,
 			Category:   &paramsCategory,
-			AssetType:  &paramsAssetType,
+			AssetTypes: []model.AssetType{paramsAssetType},
 			IsPublic:   &paramsIsPublic,
 			OrderBy:    DefaultOrder,
 			Pagination: model.Pagination{Index: 1, Size: 10},
@@ -140,6 +250,60 @@ func TestListAssetsParamsValidate(t *testing.T) {
 		assert.True(t, ok)
 		assert.Empty(t, msg)
 	})
+
+	t.Run("InvalidAssetType", func(t *testing.T) {
+		params := &ListAssetsParams{
+			AssetTypes: []model.AssetType{model.AssetTypeSprite, -1},
+			OrderBy:    DefaultOrder,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid assetType", msg)
+	})
+
+	t.Run("InvalidTag", func(t *testing.T) {
+		params := &ListAssetsParams{
+			Tags:       []string{"winter", strings.Repeat("x", 33)},
+			OrderBy:    DefaultOrder,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid tag", msg)
+	})
+
+	t.Run("OrderBy", func(t *testing.T) {
+		for _, orderBy := range []ListAssetsOrderBy{"", DefaultOrder, TimeDesc, UpdateTimeDesc, ClickCountDesc, DownloadCountDesc, NameAsc, NameDesc, Relevance} {
+			params := &ListAssetsParams{
+				OrderBy:    orderBy,
+				Pagination: model.Pagination{Index: 1, Size: 10},
+			}
+			ok, msg := params.Validate()
+			assert.True(t, ok, "orderBy %q", orderBy)
+			assert.Empty(t, msg)
+		}
+	})
+
+	t.Run("InvalidOrderBy", func(t *testing.T) {
+		params := &ListAssetsParams{
+			OrderBy:    "likes",
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid orderBy", msg)
+	})
+
+	t.Run("InvalidLicense", func(t *testing.T) {
+		params := &ListAssetsParams{
+			Licenses:   []model.AssetLicense{model.LicenseCC0, "GPL"},
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid license", msg)
+	})
 }
 
 func TestControllerListAssets(t *testing.T) {
@@ -157,18 +321,18 @@ func TestControllerListAssets(t *testing.T) {
 			Keyword:    "fake",
 			Owner:      &paramsOwner,
 			Category:   &paramsCategory,
-			AssetType:  &paramsAssetType,
+			AssetTypes: []model.AssetType{paramsAssetType},
 			FilesHash:  &paramsFilesHash,
 			IsPublic:   &paramsIsPublic,
 			OrderBy:    DefaultOrder,
 			Pagination: model.Pagination{Index: 1, Size: 10},
 		}
-		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE display_name LIKE \? AND owner = \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \?`).
-			WithArgs("%"+params.Keyword+"%", params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Personal, model.StatusDeleted).
-			WillReturnRows(mock.NewRows([]string{"COUNT(1)"}).
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND owner = \? AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND status != \?`).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Personal, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
 				AddRow(1))
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE display_name LIKE \? AND owner = \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \? ORDER BY id ASC LIMIT \?, \? `).
-			WithArgs("%"+params.Keyword+"%", params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Personal, model.StatusDeleted, 0, 10).
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND owner = \? AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND status != \? ORDER BY id ASC LIMIT \?, \? `).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Personal, model.StatusDeleted, 0, 10).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
 				AddRow(1, "fake-asset", "fake-name"))
 		assets, err := ctrl.ListAssets(ctx, params)
@@ -192,18 +356,18 @@ func TestControllerListAssets(t *testing.T) {
 			Keyword:    "fake",
 			Owner:      &paramsOwner,
 			Category:   &paramsCategory,
-			AssetType:  &paramsAssetType,
+			AssetTypes: []model.AssetType{paramsAssetType},
 			FilesHash:  &paramsFilesHash,
 			IsPublic:   &paramsIsPublic,
 			OrderBy:    TimeDesc,
 			Pagination: model.Pagination{Index: 1, Size: 10},
 		}
-		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE display_name LIKE \? AND owner = \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \?`).
-			WithArgs("%"+params.Keyword+"%", params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.StatusDeleted).
-			WillReturnRows(mock.NewRows([]string{"COUNT(1)"}).
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND owner = \? AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
 				AddRow(1))
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE display_name LIKE \? AND owner = \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \? ORDER BY c_time DESC LIMIT \?, \? `).
-			WithArgs("%"+params.Keyword+"%", params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.StatusDeleted, 0, 10).
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND owner = \? AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY c_time DESC, id DESC LIMIT \?, \? `).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.ModerationVisible, model.StatusDeleted, 0, 10).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
 				AddRow(1, "fake-asset", "fake-name"))
 		assets, err := ctrl.ListAssets(ctx, params)
@@ -225,18 +389,18 @@ func TestControllerListAssets(t *testing.T) {
 		params := &ListAssetsParams{
 			Keyword:    "fake",
 			Category:   &paramsCategory,
-			AssetType:  &paramsAssetType,
+			AssetTypes: []model.AssetType{paramsAssetType},
 			FilesHash:  &paramsFilesHash,
 			IsPublic:   &paramsIsPublic,
 			OrderBy:    ClickCountDesc,
 			Pagination: model.Pagination{Index: 1, Size: 10},
 		}
-		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE display_name LIKE \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \?`).
-			WithArgs("%"+params.Keyword+"%", params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.StatusDeleted).
-			WillReturnRows(mock.NewRows([]string{"COUNT(1)"}).
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
 				AddRow(1))
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE display_name LIKE \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \? ORDER BY click_count DESC LIMIT \?, \? `).
-			WithArgs("%"+params.Keyword+"%", params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.StatusDeleted, 0, 10).
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY click_count DESC, id DESC LIMIT \?, \? `).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.ModerationVisible, model.StatusDeleted, 0, 10).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
 				AddRow(1, "fake-asset", "fake-name"))
 		assets, err := ctrl.ListAssets(ctx, params)
@@ -246,6 +410,30 @@ func TestControllerListAssets(t *testing.T) {
 		assert.Equal(t, "1", assets.Data[0].ID)
 	})
 
+	t.Run("DownloadCountDesc", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			OrderBy:    DownloadCountDesc,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE is_public = \? AND moderation_status = \? AND status != \? ORDER BY download_count DESC, id DESC LIMIT \?, \? `).
+			WithArgs(model.Public, model.ModerationVisible, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "download_count"}).
+				AddRow(1, "fake-asset", "fake-name", 7))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 1)
+		assert.Equal(t, int64(7), assets.Data[0].DownloadCount)
+	})
+
 	t.Run("DifferentOwner", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
@@ -260,18 +448,18 @@ func TestControllerListAssets(t *testing.T) {
 			Keyword:    "fake",
 			Owner:      &paramsOwner,
 			Category:   &paramsCategory,
-			AssetType:  &paramsAssetType,
+			AssetTypes: []model.AssetType{paramsAssetType},
 			FilesHash:  &paramsFilesHash,
 			IsPublic:   &paramsIsPublic,
 			OrderBy:    DefaultOrder,
 			Pagination: model.Pagination{Index: 1, Size: 10},
 		}
-		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE display_name LIKE \? AND owner = \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \?`).
-			WithArgs("%"+params.Keyword+"%", params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.StatusDeleted).
-			WillReturnRows(mock.NewRows([]string{"COUNT(1)"}).
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND owner = \? AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
 				AddRow(1))
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE display_name LIKE \? AND owner = \? AND category = \? AND asset_type = \? AND files_hash = \? AND is_public = \? AND status != \? ORDER BY id ASC LIMIT \?, \? `).
-			WithArgs("%"+params.Keyword+"%", params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.StatusDeleted, 0, 10).
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND owner = \? AND category = \? AND asset_type IN \(\?\) AND files_hash = \? AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY id ASC LIMIT \?, \? `).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, params.Owner, params.Category, model.AssetTypeSprite, params.FilesHash, model.Public, model.ModerationVisible, model.StatusDeleted, 0, 10).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
 				AddRow(1, "fake-asset", "another-fake-name"))
 		assets, err := ctrl.ListAssets(ctx, params)
@@ -281,6 +469,228 @@ func TestControllerListAssets(t *testing.T) {
 		assert.Equal(t, "1", assets.Data[0].ID)
 	})
 
+	t.Run("MultipleAssetTypes", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			AssetTypes: []model.AssetType{model.AssetTypeSprite, model.AssetTypeBackdrop},
+			OrderBy:    DefaultOrder,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE asset_type IN \(\?,\?\) AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(model.AssetTypeSprite, model.AssetTypeBackdrop, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(2))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE asset_type IN \(\?,\?\) AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY id ASC LIMIT \?, \? `).
+			WithArgs(model.AssetTypeSprite, model.AssetTypeBackdrop, model.Public, model.ModerationVisible, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "asset_type"}).
+				AddRow(1, "fake-sprite", "fake-name", model.AssetTypeSprite).
+				AddRow(2, "fake-backdrop", "fake-name", model.AssetTypeBackdrop))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 2)
+	})
+
+	t.Run("NameAsc", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			OrderBy:    NameAsc,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(2))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE is_public = \? AND moderation_status = \? AND status != \? ORDER BY display_name ASC, id ASC LIMIT \?, \? `).
+			WithArgs(model.Public, model.ModerationVisible, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "a", "fake-name").
+				AddRow(2, "B", "fake-name"))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 2)
+	})
+
+	t.Run("NameDesc", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			OrderBy:    NameDesc,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(2))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE is_public = \? AND moderation_status = \? AND status != \? ORDER BY display_name DESC, id DESC LIMIT \?, \? `).
+			WithArgs(model.Public, model.ModerationVisible, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(2, "B", "fake-name").
+				AddRow(1, "a", "fake-name"))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 2)
+	})
+
+	t.Run("IsAiGenerated", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		paramsIsAiGenerated := true
+		params := &ListAssetsParams{
+			IsAiGenerated: &paramsIsAiGenerated,
+			OrderBy:       DefaultOrder,
+			Pagination:    model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE is_public = \? AND moderation_status = \? AND is_ai_generated = \? AND status != \?`).
+			WithArgs(model.Public, model.ModerationVisible, true, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE is_public = \? AND moderation_status = \? AND is_ai_generated = \? AND status != \? ORDER BY id ASC LIMIT \?, \? `).
+			WithArgs(model.Public, model.ModerationVisible, true, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_ai_generated"}).
+				AddRow(1, "fake-asset", "fake-name", true))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 1)
+		assert.True(t, assets.Data[0].IsAiGenerated)
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			Keyword:    "fake",
+			Tags:       []string{" Winter", "boss", "winter"},
+			OrderBy:    DefaultOrder,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name IN \(\?,\?\) AND tag.status != \? GROUP BY asset_tag.asset_id HAVING COUNT\(DISTINCT tag.id\) = \?\) AND status != \?`).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, model.Public, model.ModerationVisible, "winter", "boss", model.StatusDeleted, 2, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND id IN \(.+\) AND status != \? ORDER BY id ASC LIMIT \?, \? `).
+			WithArgs(`+"fake"`, "%fake%", model.StatusDeleted, model.Public, model.ModerationVisible, "winter", "boss", model.StatusDeleted, 2, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 1)
+	})
+
+	t.Run("Relevance", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			Keyword:    "winter boss",
+			OrderBy:    Relevance,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(`+"winter" +"boss"`, "%winter boss%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY MATCH \(display_name\) AGAINST \(\? IN BOOLEAN MODE\) DESC, MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) DESC, id ASC LIMIT \?, \? `).
+			WithArgs(`+"winter" +"boss"`, "%winter boss%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted, `+"winter" +"boss"`, `+"winter" +"boss"`, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 1)
+	})
+
+	t.Run("KeywordInDescriptionOnly", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			Keyword:    "snowy",
+			OrderBy:    Relevance,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(.+\)\) AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(`+"snowy"`, "%snowy%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(.+\)\) AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY MATCH \(display_name\) AGAINST \(\? IN BOOLEAN MODE\) DESC, MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) DESC, id ASC LIMIT \?, \? `).
+			WithArgs(`+"snowy"`, "%snowy%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted, `+"snowy"`, `+"snowy"`, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "description", "owner"}).
+				AddRow(1, "fake-backdrop", "a snowy mountain", "fake-name"))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, assets.Data, 1)
+		assert.Equal(t, "a snowy mountain", assets.Data[0].Description)
+	})
+
+	t.Run("ShortKeyword", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			Keyword:    "a",
+			OrderBy:    Relevance,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(CONCAT_WS\(' ', display_name, description\) LIKE \? OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs("%a%", "%a%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(CONCAT_WS\(' ', display_name, description\) LIKE \? OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY display_name LIKE \? DESC, description LIKE \? DESC, id ASC LIMIT \?, \? `).
+			WithArgs("%a%", "%a%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted, "%a%", "%a%", 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 1)
+	})
+
+	t.Run("FullTextIndexMissing", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &ListAssetsParams{
+			Keyword:    "winter",
+			OrderBy:    Relevance,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(MATCH \(display_name, description\) AGAINST \(\? IN BOOLEAN MODE\) OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WillReturnError(&mysql.MySQLError{Number: 1191, Message: "Can't find FULLTEXT index matching the column list"})
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE \(CONCAT_WS\(' ', display_name, description\) LIKE \? OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs("%winter%", "%winter%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE \(CONCAT_WS\(' ', display_name, description\) LIKE \? OR id IN \(SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE \? AND tag.status != \?\)\) AND is_public = \? AND moderation_status = \? AND status != \? ORDER BY display_name LIKE \? DESC, description LIKE \? DESC, id ASC LIMIT \?, \? `).
+			WithArgs("%winter%", "%winter%", model.StatusDeleted, model.Public, model.ModerationVisible, model.StatusDeleted, "%winter%", "%winter%", 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Len(t, assets.Data, 1)
+	})
+
 	t.Run("ClosedDB", func(t *testing.T) {
 		ctrl, _, err := newTestController(t)
 		require.NoError(t, err)
@@ -296,7 +706,7 @@ func TestControllerListAssets(t *testing.T) {
 			Keyword:    "fake",
 			Owner:      &paramsOwner,
 			Category:   &paramsCategory,
-			AssetType:  &paramsAssetType,
+			AssetTypes: []model.AssetType{paramsAssetType},
 			FilesHash:  &paramsFilesHash,
 			IsPublic:   &paramsIsPublic,
 			OrderBy:    DefaultOrder,
@@ -306,19 +716,387 @@ func TestControllerListAssets(t *testing.T) {
 		require.Error(t, err)
 		assert.EqualError(t, err, "sql: database is closed")
 	})
-}
+	t.Run("IncludeDeleted", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
 
-func TestAddAssetParamsValidate(t *testing.T) {
-	t.Run("Normal", func(t *testing.T) {
-		params := &AddAssetParams{
-			DisplayName: "fake-display-name",
-			Owner:       "fake-owner",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
+		ctx := newContextWithTestAdmin(context.Background())
+		params := &ListAssetsParams{
+			IncludeDeleted: true,
+			Pagination:     model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE is_public = \? AND moderation_status = \?$`).
+			WithArgs(model.Public, model.ModerationVisible).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE is_public = \? AND moderation_status = \? ORDER BY id ASC LIMIT \?, \?`).
+			WithArgs(model.Public, model.ModerationVisible, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "status"}).
+				AddRow(1, "fake-asset", "fake-name", model.StatusDeleted))
+		assets, err := ctrl.ListAssets(ctx, params)
+		require.NoError(t, err)
+		require.Len(t, assets.Data, 1)
+		assert.Equal(t, model.StatusDeleted, assets.Data[0].Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("IncludeDeletedByNonAdmin", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		paramsOwner := "fake-name"
+		params := &ListAssetsParams{
+			Owner:          &paramsOwner,
+			IncludeDeleted: true,
+			Pagination:     model.Pagination{Index: 1, Size: 10},
+		}
+		_, err = ctrl.ListAssets(ctx, params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("IncludeDeletedWithoutUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		params := &ListAssetsParams{
+			IncludeDeleted: true,
+			Pagination:     model.Pagination{Index: 1, Size: 10},
+		}
+		_, err = ctrl.ListAssets(context.Background(), params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestListAssetsParamsConditionsOrders(t *testing.T) {
+	for _, tt := range []struct {
+		orderBy ListAssetsOrderBy
+		want    []model.OrderByCondition
+	}{
+		{DefaultOrder, nil},
+		{TimeDesc, []model.OrderByCondition{{Column: "c_time", Direction: "DESC"}, {Column: "id", Direction: "DESC"}}},
+		{UpdateTimeDesc, []model.OrderByCondition{{Column: "u_time", Direction: "DESC"}, {Column: "id", Direction: "DESC"}}},
+		{ClickCountDesc, []model.OrderByCondition{{Column: "click_count", Direction: "DESC"}, {Column: "id", Direction: "DESC"}}},
+		{DownloadCountDesc, []model.OrderByCondition{{Column: "download_count", Direction: "DESC"}, {Column: "id", Direction: "DESC"}}},
+		{NameAsc, []model.OrderByCondition{{Column: "display_name", Direction: "ASC"}, {Column: "id", Direction: "ASC"}}},
+		{NameDesc, []model.OrderByCondition{{Column: "display_name", Direction: "DESC"}, {Column: "id", Direction: "DESC"}}},
+		{Relevance, nil},
+	} {
+		t.Run(string(tt.orderBy), func(t *testing.T) {
+			params := &ListAssetsParams{OrderBy: tt.orderBy}
+			_, orders := params.conditions("")
+			assert.Equal(t, tt.want, orders)
+		})
+	}
+}
+
+func TestListAssetsParamsConditionsLicense(t *testing.T) {
+	t.Run("Licenses", func(t *testing.T) {
+		params := &ListAssetsParams{Licenses: []model.AssetLicense{model.LicenseCC0, model.LicenseCCBY}}
+		wheres, _ := params.conditions("")
+		assert.Equal(t, []model.FilterCondition{
+			{Column: "license", Operation: "IN", Value: []model.AssetLicense{model.LicenseCC0, model.LicenseCCBY}},
+		}, wheres)
+	})
+
+	t.Run("Remixable", func(t *testing.T) {
+		remixable := true
+		params := &ListAssetsParams{Remixable: &remixable}
+		wheres, _ := params.conditions("")
+		assert.Equal(t, []model.FilterCondition{
+			{Column: "license", Operation: "IN", Value: []model.AssetLicense{model.LicenseCC0, model.LicenseCCBY, model.LicenseCCBYSA}},
+		}, wheres)
+	})
+
+	t.Run("NotRemixable", func(t *testing.T) {
+		remixable := false
+		params := &ListAssetsParams{Remixable: &remixable}
+		wheres, _ := params.conditions("")
+		assert.Equal(t, []model.FilterCondition{
+			{Column: "license", Operation: "IN", Value: []model.AssetLicense{model.LicenseCCBYNC, model.LicenseCCBYND, model.LicenseAllRightsReserved}},
+		}, wheres)
+	})
+}
+
+func TestListAssetsParamsConditionsStableOrder(t *testing.T) {
+	// All assets share the same sort values, so only the id tells them apart.
+	cTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var assets []model.Asset
+	for i := 1; i <= 50; i++ {
+		assets = append(assets, model.Asset{
+			ID:            strconv.Itoa(i),
+			CTime:         cTime,
+			UTime:         cTime,
+			DisplayName:   "fake-asset",
+			ClickCount:    7,
+			DownloadCount: 7,
+		})
+	}
+
+	// compare compares a and b by column like the database does.
+	compare := func(a, b *model.Asset, column string) int {
+		switch column {
+		case "c_time":
+			return a.CTime.Compare(b.CTime)
+		case "u_time":
+			return a.UTime.Compare(b.UTime)
+		case "click_count":
+			return cmp.Compare(a.ClickCount, b.ClickCount)
+		case "download_count":
+			return cmp.Compare(a.DownloadCount, b.DownloadCount)
+		case "display_name":
+			return strings.Compare(strings.ToLower(a.DisplayName), strings.ToLower(b.DisplayName))
+		case "id":
+			aID, _ := strconv.Atoi(a.ID)
+			bID, _ := strconv.Atoi(b.ID)
+			return cmp.Compare(aID, bID)
+		}
+		t.Fatalf("unexpected order column: %s", column)
+		return 0
+	}
+
+	// queryPage mimics a paginated query. Rows are shuffled before sorting,
+	// as the database makes no promise about the order of rows that compare
+	// equal.
+	queryPage := func(orders []model.OrderByCondition, pagination model.Pagination) []model.Asset {
+		rows := slices.Clone(assets)
+		rand.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+		if len(orders) == 0 {
+			orders = []model.OrderByCondition{{Column: "id", Direction: "ASC"}}
+		}
+		slices.SortStableFunc(rows, func(a, b model.Asset) int {
+			for _, order := range orders {
+				c := compare(&a, &b, order.Column)
+				if order.Direction == "DESC" {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+		offset := (pagination.Index - 1) * pagination.Size
+		return rows[offset:min(offset+pagination.Size, len(rows))]
+	}
+
+	for _, orderBy := range []ListAssetsOrderBy{DefaultOrder, TimeDesc, UpdateTimeDesc, ClickCountDesc, DownloadCountDesc, NameAsc, NameDesc, Relevance} {
+		t.Run(string(orderBy), func(t *testing.T) {
+			params := &ListAssetsParams{OrderBy: orderBy}
+			_, orders := params.conditions("")
+
+			seen := make(map[string]bool)
+			for index := 1; index <= 7; index++ {
+				for _, asset := range queryPage(orders, model.Pagination{Index: index, Size: 8}) {
+					assert.False(t, seen[asset.ID], "asset %s is repeated", asset.ID)
+					seen[asset.ID] = true
+				}
+			}
+			assert.Len(t, seen, len(assets))
+		})
+	}
+}
+
+func TestListTrendingAssetsParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &ListTrendingAssetsParams{
+			AssetTypes: []model.AssetType{model.AssetTypeSprite, model.AssetTypeSound},
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidAssetType", func(t *testing.T) {
+		params := &ListTrendingAssetsParams{
+			AssetTypes: []model.AssetType{-1},
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid assetType", msg)
+	})
+}
+
+func TestControllerListTrendingAssets(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		params := &ListTrendingAssetsParams{
+			AssetTypes: []model.AssetType{model.AssetTypeSprite},
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset JOIN \(.+\) AS trend ON trend.asset_id = asset.id WHERE is_public = \? AND moderation_status = \? AND asset_type IN \(\?\) AND status != \?`).
+			WithArgs(model.AssetEventClick, 1.0, model.AssetEventDownload, 3.0, sqlmock.AnyArg(), (48 * time.Hour).Seconds(), sqlmock.AnyArg(), model.Public, model.ModerationVisible, model.AssetTypeSprite, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT asset.\* FROM asset JOIN \(.+\) AS trend ON trend.asset_id = asset.id WHERE is_public = \? AND moderation_status = \? AND asset_type IN \(\?\) AND status != \? ORDER BY trend.score DESC, asset.id DESC LIMIT \?, \?`).
+			WithArgs(model.AssetEventClick, 1.0, model.AssetEventDownload, 3.0, sqlmock.AnyArg(), (48 * time.Hour).Seconds(), sqlmock.AnyArg(), model.Public, model.ModerationVisible, model.AssetTypeSprite, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		assets, err := ctrl.ListTrendingAssets(context.Background(), params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Equal(t, 1, assets.Total)
+		assert.Len(t, assets.Data, 1)
+	})
+
+	t.Run("CustomWeights", func(t *testing.T) {
+		t.Setenv("TRENDING_HALF_LIFE", "24h")
+		t.Setenv("TRENDING_CLICK_WEIGHT", "2")
+		t.Setenv("TRENDING_DOWNLOAD_WEIGHT", "5")
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		params := &ListTrendingAssetsParams{
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset JOIN \(.+\) AS trend ON trend.asset_id = asset.id WHERE is_public = \? AND moderation_status = \? AND status != \?`).
+			WithArgs(model.AssetEventClick, 2.0, model.AssetEventDownload, 5.0, sqlmock.AnyArg(), (24 * time.Hour).Seconds(), sqlmock.AnyArg(), model.Public, model.ModerationVisible, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(0))
+		mock.ExpectQuery(`SELECT asset.\* FROM asset JOIN`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		assets, err := ctrl.ListTrendingAssets(context.Background(), params)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Empty(t, assets.Data)
+	})
+
+	t.Run("ClosedDB", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.db.Close()
+
+		params := &ListTrendingAssetsParams{
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		_, err = ctrl.ListTrendingAssets(context.Background(), params)
+		require.Error(t, err)
+	})
+}
+
+func TestControllerListRecentAssets(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		assetType := model.AssetTypeSprite
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE is_public = \? AND moderation_status = \? AND \(is_ai_generated = \? OR files_hash != \?\) AND asset_type = \? AND status != \?`).
+			WithArgs(model.Public, model.ModerationVisible, false, "", model.AssetTypeSprite, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(2))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE is_public = \? AND moderation_status = \? AND \(is_ai_generated = \? OR files_hash != \?\) AND asset_type = \? AND status != \? ORDER BY u_time DESC, id DESC LIMIT \?, \?`).
+			WithArgs(model.Public, model.ModerationVisible, false, "", model.AssetTypeSprite, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(2, "fake-asset-2", "fake-name", model.Public).
+				AddRow(1, "fake-asset-1", "fake-name", model.Public))
+		assets, err := ctrl.ListRecentAssets(context.Background(), &assetType, model.Pagination{Index: 1, Size: 10})
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Equal(t, 2, assets.Total)
+		require.Len(t, assets.Data, 2)
+		assert.Equal(t, "2", assets.Data[0].ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AllTypes", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE is_public = \? AND moderation_status = \? AND \(is_ai_generated = \? OR files_hash != \?\) AND status != \?`).
+			WithArgs(model.Public, model.ModerationVisible, false, "", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(0))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE .+ ORDER BY u_time DESC, id DESC LIMIT \?, \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		assets, err := ctrl.ListRecentAssets(context.Background(), nil, model.Pagination{Index: 1, Size: 10})
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Empty(t, assets.Data)
+	})
+
+	t.Run("ClosedDB", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.db.Close()
+
+		_, err = ctrl.ListRecentAssets(context.Background(), nil, model.Pagination{Index: 1, Size: 10})
+		require.Error(t, err)
+	})
+}
+
+func TestListRandomAssetsParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &ListRandomAssetsParams{AssetType: model.AssetTypeBackdrop, Count: 10}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidAssetType", func(t *testing.T) {
+		params := &ListRandomAssetsParams{AssetType: -1, Count: 10}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid assetType", msg)
+	})
+
+	t.Run("InvalidCount", func(t *testing.T) {
+		params := &ListRandomAssetsParams{AssetType: model.AssetTypeBackdrop, Count: maxRandomAssets + 1}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid count", msg)
+	})
+}
+
+func TestControllerListRandomAssets(t *testing.T) {
+	params := &ListRandomAssetsParams{AssetType: model.AssetTypeBackdrop, Count: 1}
+
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT MIN\(id\), MAX\(id\) FROM asset WHERE is_public = \? AND moderation_status = \? AND asset_type = \? AND status != \?`).
+			WithArgs(model.Public, model.ModerationVisible, model.AssetTypeBackdrop, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"MIN(id)", "MAX(id)"}).
+				AddRow(1, 100))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id >= \? AND is_public = \? AND moderation_status = \? AND asset_type = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WithArgs(sqlmock.AnyArg(), model.Public, model.ModerationVisible, model.AssetTypeBackdrop, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(42, "fake-asset", "fake-name"))
+		assets, err := ctrl.ListRandomAssets(context.Background(), params)
+		require.NoError(t, err)
+		require.Len(t, assets, 1)
+		assert.Equal(t, "42", assets[0].ID)
+	})
+
+	t.Run("ClosedDB", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.db.Close()
+
+		_, err = ctrl.ListRandomAssets(context.Background(), params)
+		require.Error(t, err)
+	})
+}
+
+func TestAddAssetParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &AddAssetParams{
+			DisplayName: "fake-display-name",
+			Owner:       "fake-owner",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
 		}
 		ok, msg := params.Validate()
 		assert.True(t, ok)
@@ -357,6 +1135,23 @@ func TestAddAssetParamsValidate(t *testing.T) {
 		assert.Equal(t, "invalid displayName", msg)
 	})
 
+	t.Run("InvalidDescription", func(t *testing.T) {
+		params := &AddAssetParams{
+			DisplayName: "fake-asset",
+			Description: strings.Repeat("x", 1001),
+			Owner:       "fake-owner",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid description", msg)
+	})
+
 	t.Run("EmptyOwner", func(t *testing.T) {
 		params := &AddAssetParams{
 			DisplayName: "fake-display-name",
@@ -436,6 +1231,58 @@ func TestAddAssetParamsValidate(t *testing.T) {
 		assert.False(t, ok)
 		assert.Equal(t, "invalid isPublic", msg)
 	})
+
+	t.Run("AiGenerated", func(t *testing.T) {
+		params := &AddAssetParams{
+			DisplayName:   "fake-display-name",
+			Owner:         "fake-owner",
+			Category:      "fake-category",
+			AssetType:     model.AssetTypeSprite,
+			Files:         model.FileCollection{},
+			FilesHash:     "fake-files-hash",
+			Preview:       "fake-preview",
+			IsPublic:      model.Personal,
+			IsAiGenerated: true,
+			AiProvider:    "fake-provider",
+		}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("UnexpectedAiProvider", func(t *testing.T) {
+		params := &AddAssetParams{
+			DisplayName: "fake-display-name",
+			Owner:       "fake-owner",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+			AiProvider:  "fake-provider",
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "unexpected aiProvider", msg)
+	})
+
+	t.Run("InvalidLicense", func(t *testing.T) {
+		params := &AddAssetParams{
+			DisplayName: "fake-display-name",
+			Owner:       "fake-owner",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+			License:     "GPL",
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid license", msg)
+	})
 }
 
 func TestControllerAddAsset(t *testing.T) {
@@ -454,15 +1301,52 @@ func TestControllerAddAsset(t *testing.T) {
 			Preview:     "fake-preview",
 			IsPublic:    model.Personal,
 		}
-		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WithArgs("fake-name", "", model.StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "Fake-Asset"))
+		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
-				AddRow(1, "fake-asset", "fake-name"))
+				AddRow(1, "fake-asset (2)", "fake-name"))
+		mock.ExpectCommit()
 		asset, err := ctrl.AddAsset(ctx, params)
 		require.NoError(t, err)
 		require.NotNil(t, asset)
 		assert.Equal(t, "1", asset.ID)
+		assert.Equal(t, "fake-asset (2)", asset.DisplayName)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DisplayNameTaken", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.displayNamePolicy = model.DisplayNameReject
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &AddAssetParams{
+			DisplayName: "fake-asset",
+			Owner:       "fake-name",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "fake-asset"))
+		mock.ExpectRollback()
+		_, err = ctrl.AddAsset(ctx, params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrExist)
+		var conflictErr *model.DisplayNameConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, "fake-asset (2)", conflictErr.Suggestion)
 	})
 
 	t.Run("NoUser", func(t *testing.T) {
@@ -523,146 +1407,1233 @@ func TestControllerAddAsset(t *testing.T) {
 		}
 		_, err = ctrl.AddAsset(ctx, params)
 		require.Error(t, err)
-		assert.EqualError(t, err, "sql: database is closed")
+		assert.EqualError(t, err, "sql: database is closed")
+	})
+}
+
+func TestUpdateAssetParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("EmptyDisplayName", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing displayName", msg)
+	})
+
+	t.Run("InvalidDisplayName", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: strings.Repeat("fake-asset", 11),
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid displayName", msg)
+	})
+
+	t.Run("MultilineDescription", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Description: "fake\ndescription",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidDescription", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Description: strings.Repeat("x", 1001),
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid description", msg)
+	})
+
+	t.Run("EmptyCategory", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing category", msg)
+	})
+
+	t.Run("InvalidAssetType", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   -1,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid assetType", msg)
+	})
+
+	t.Run("EmptyFilesHash", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing filesHash", msg)
+	})
+
+	t.Run("InvalidIsPublic", func(t *testing.T) {
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    -1,
+		}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid isPublic", msg)
+	})
+}
+
+func TestControllerUpdateAsset(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(1, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), params.DisplayName, sqlmock.AnyArg(), params.Category, params.AssetType, []byte("{}"), params.FilesHash, sqlmock.AnyArg(), params.Preview, params.IsPublic, sqlmock.AnyArg(), sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Public))
+		asset, err := ctrl.UpdateAsset(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "1", asset.ID)
+		assert.Equal(t, model.Public, asset.IsPublic)
+	})
+
+	t.Run("ApprovedContentChanged", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		// Swapping the preview of an approved asset sends it back to review.
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Public,
+		}
+		prevRows := func() *sqlmock.Rows {
+			return mock.NewRows([]string{"id", "display_name", "owner", "category", "files", "files_hash", "preview", "is_public", "moderation_status"}).
+				AddRow(1, "fake-asset", "fake-name", "fake-category", []byte("{}"), "fake-files-hash", "old-fake-preview", model.Public, model.ModerationVisible)
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(prevRows())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(prevRows())
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(1, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), params.DisplayName, sqlmock.AnyArg(), params.Category, params.AssetType, []byte("{}"), params.FilesHash, sqlmock.AnyArg(), params.Preview, params.IsPublic, sqlmock.AnyArg(), model.ModerationPending, "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "moderation_status"}).
+				AddRow(1, model.ModerationPending))
+		asset, err := ctrl.UpdateAsset(ctx, "1", params)
+		require.NoError(t, err)
+		assert.Equal(t, model.ModerationPending, asset.ModerationStatus)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ApprovedMetadataChanged", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		// Recategorizing an approved asset keeps it approved.
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Public,
+		}
+		prevRows := func() *sqlmock.Rows {
+			return mock.NewRows([]string{"id", "display_name", "owner", "category", "files", "files_hash", "preview", "is_public", "moderation_status"}).
+				AddRow(1, "fake-asset", "fake-name", "old-fake-category", []byte("{}"), "fake-files-hash", "fake-preview", model.Public, model.ModerationVisible)
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(prevRows())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(prevRows())
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(1, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), params.DisplayName, sqlmock.AnyArg(), params.Category, params.AssetType, []byte("{}"), params.FilesHash, sqlmock.AnyArg(), params.Preview, params.IsPublic, sqlmock.AnyArg(), model.ModerationVisible, "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "moderation_status"}).
+				AddRow(1, model.ModerationVisible))
+		asset, err := ctrl.UpdateAsset(ctx, "1", params)
+		require.NoError(t, err)
+		assert.Equal(t, model.ModerationVisible, asset.ModerationStatus)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WithoutPreview", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		// Rendering in the background is skipped, so no more queries are
+		// made after the update.
+		for i := 0; i < maxConcurrentPreviewRenders; i++ {
+			ctrl.previewRenders <- struct{}{}
+		}
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeBackdrop,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(1, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "asset_type", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.AssetTypeBackdrop, []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectExec(`DELETE FROM asset_preview_failure WHERE asset_id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		asset, err := ctrl.UpdateAsset(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("KeepLicense", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public", "license"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal, model.LicenseCC0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public", "license"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal, model.LicenseCC0))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(1, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), params.DisplayName, sqlmock.AnyArg(), params.Category, params.AssetType, []byte("{}"), params.FilesHash, sqlmock.AnyArg(), params.Preview, params.IsPublic, model.LicenseCC0, sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "license"}).
+				AddRow(1, "fake-asset", "fake-name", model.LicenseCC0))
+		asset, err := ctrl.UpdateAsset(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, model.LicenseCC0, asset.License)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &UpdateAssetParams{
+			DisplayName: "fake-asset",
+			Category:    "fake-category",
+			AssetType:   model.AssetTypeSprite,
+			Files:       model.FileCollection{},
+			FilesHash:   "fake-files-hash",
+			Preview:     "fake-preview",
+			IsPublic:    model.Personal,
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(1, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), params.DisplayName, sqlmock.AnyArg(), params.Category, params.AssetType, []byte("{}"), params.FilesHash, sqlmock.AnyArg(), params.Preview, params.IsPublic, sqlmock.AnyArg(), sqlmock.AnyArg(), "1").
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerForkAsset(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "preview", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", []byte(`{"index.json":"kodo://builder/files/fake-key","external.png":"https://example.com/fake.png"}`), "fake-files-hash", "kodo://builder/files/fake-preview", model.Public))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows(nil))
+		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WithArgs("2", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "forked_from", "is_public"}).
+				AddRow(2, "fake-asset", "fake-name", "1", model.Personal))
+		mock.ExpectExec(`UPDATE asset SET fork_count = fork_count \+ 1 WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		fork, err := ctrl.ForkAsset(ctx, "1", "fake-name")
+		require.NoError(t, err)
+		require.NotNil(t, fork)
+		assert.Equal(t, "2", fork.ID)
+		assert.Equal(t, "fake-name", fork.Owner)
+		assert.Equal(t, "1", fork.ForkedFrom)
+		assert.Equal(t, model.Personal, fork.IsPublic)
+
+		copies := ctrl.bucketManager.(*fakeBucketManager).copies
+		require.Len(t, copies, 2)
+		srcKeys := []string{copies[0][0], copies[1][0]}
+		assert.ElementsMatch(t, []string{"files/fake-key", "files/fake-preview"}, srcKeys)
+		for _, c := range copies {
+			assert.True(t, strings.HasPrefix(c[1], "forks/"))
+			assert.True(t, strings.HasSuffix(c[1], "-"+path.Base(c[0])))
+		}
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.ForkAsset(context.Background(), "1", "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedOwner", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.ForkAsset(ctx, "1", "another-fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("PersonalSource", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Personal))
+		_, err = ctrl.ForkAsset(ctx, "1", "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		_, err = ctrl.ForkAsset(ctx, "1", "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("CopyFailed", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		copyErr := errors.New("copy failed")
+		ctrl.bucketManager = &fakeBucketManager{err: copyErr}
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", []byte(`{"index.json":"kodo://builder/files/fake-key"}`), model.Public))
+		_, err = ctrl.ForkAsset(ctx, "1", "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, copyErr)
+	})
+
+	t.Run("DisplayNameConflict", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.displayNamePolicy = model.DisplayNameReject
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "preview", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", []byte(`{"index.json":"kodo://builder/files/fake-key","external.png":"https://example.com/fake.png"}`), "fake-files-hash", "kodo://builder/files/fake-preview", model.Public))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(3, "fake-asset"))
+		mock.ExpectRollback()
+		_, err = ctrl.ForkAsset(ctx, "1", "fake-name")
+		require.Error(t, err)
+		var conflictErr *model.DisplayNameConflictError
+		assert.ErrorAs(t, err, &conflictErr)
+		require.NoError(t, mock.ExpectationsWereMet())
+
+		// The copies are deleted, as no asset references them.
+		bucketManager := ctrl.bucketManager.(*fakeBucketManager)
+		require.Len(t, bucketManager.copies, 2)
+		assert.ElementsMatch(t, []string{bucketManager.copies[0][1], bucketManager.copies[1][1]}, bucketManager.deletes)
+	})
+}
+
+func TestUpdateAssetVisibilityParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &UpdateAssetVisibilityParams{IsPublic: model.Public}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidIsPublic", func(t *testing.T) {
+		params := &UpdateAssetVisibilityParams{IsPublic: -1}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid isPublic", msg)
+	})
+}
+
+func TestControllerUpdateAssetVisibility(t *testing.T) {
+	params := &UpdateAssetVisibilityParams{IsPublic: model.Personal}
+
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,is_public=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), model.Personal, "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Personal))
+		asset, err := ctrl.UpdateAssetVisibility(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, model.Personal, asset.IsPublic)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		_, err = ctrl.UpdateAssetVisibility(context.Background(), "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public))
+		_, err = ctrl.UpdateAssetVisibility(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		_, err = ctrl.UpdateAssetVisibility(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,is_public=\? WHERE id=\?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.UpdateAssetVisibility(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestRenameAssetParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &RenameAssetParams{DisplayName: "fake-asset"}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("MissingDisplayName", func(t *testing.T) {
+		params := &RenameAssetParams{DisplayName: "  "}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing displayName", msg)
+	})
+
+	t.Run("InvalidDisplayName", func(t *testing.T) {
+		params := &RenameAssetParams{DisplayName: strings.Repeat("a", 101)}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid displayName", msg)
+	})
+}
+
+func TestControllerRenameAsset(t *testing.T) {
+	params := &RenameAssetParams{DisplayName: " new-fake-asset "}
+
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WithArgs("fake-name", "1", model.StatusDeleted, "new-fake-asset", "new-fake-asset (%)").
+			WillReturnRows(mock.NewRows(nil))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name", "editor"}).
+				AddRow(1, 1, "fake-asset", "fake-name"))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WithArgs("1", ctrl.assetVersionLimit).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), "new-fake-asset", sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "new-fake-asset", "fake-name", model.Public))
+		asset, err := ctrl.RenameAsset(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "new-fake-asset", asset.DisplayName)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		_, err = ctrl.RenameAsset(context.Background(), "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public))
+		_, err = ctrl.RenameAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		_, err = ctrl.RenameAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WithArgs("fake-name", "1", model.StatusDeleted, "new-fake-asset", "new-fake-asset (%)").
+			WillReturnRows(mock.NewRows(nil))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name", "editor"}).
+				AddRow(1, 1, "fake-asset", "fake-name"))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WithArgs("1", ctrl.assetVersionLimit).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,moderation_status=\? WHERE id=\?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ctrl.RenameAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerListDisplayNameDuplicates(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT owner, display_name, COUNT\(\*\) AS asset_count FROM asset WHERE status != \? GROUP BY owner, display_name HAVING COUNT\(\*\) > 1`).
+			WithArgs(model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"owner", "display_name", "asset_count"}).
+				AddRow("fake-name", "fake-asset", 2))
+		duplicates, err := ctrl.ListDisplayNameDuplicates(ctx, model.Pagination{Index: 1, Size: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []model.DisplayNameDuplicate{{Owner: "fake-name", DisplayName: "fake-asset", AssetCount: 2}}, duplicates)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.ListDisplayNameDuplicates(context.Background(), model.Pagination{Index: 1, Size: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.ListDisplayNameDuplicates(ctx, model.Pagination{Index: 1, Size: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedDB", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.db.Close()
+
+		ctx := newContextWithTestAdmin(context.Background())
+		_, err = ctrl.ListDisplayNameDuplicates(ctx, model.Pagination{Index: 1, Size: 10})
+		require.Error(t, err)
+	})
+}
+
+func TestControllerIncrementAssetClickCount(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(11, 1))
+		mock.ExpectExec(`INSERT INTO asset_event \(c_time, asset_id, event_type\) VALUES \(\?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", model.AssetEventClick).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE`).
+			WithArgs("1", sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		clickCount, err := ctrl.IncrementAssetClickCount(ctx, "1", "127.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), clickCount)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Public))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", ctrl.clickViewer(ctx, "127.0.0.1"), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT click_count FROM asset WHERE id = \?`).
+			WithArgs("1").
+			WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(10))
+		mock.ExpectExec(`INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE`).
+			WithArgs("1", sqlmock.AnyArg(), 0).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		clickCount, err := ctrl.IncrementAssetClickCount(ctx, "1", "127.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), clickCount)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		_, err = ctrl.IncrementAssetClickCount(ctx, "1", "127.0.0.1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		_, err = ctrl.IncrementAssetClickCount(ctx, "1", "127.0.0.1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		_, err = ctrl.IncrementAssetClickCount(ctx, "1", "127.0.0.1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ctrl.IncrementAssetClickCount(ctx, "1", "127.0.0.1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerIncrementAssetDownloadCount(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE asset SET download_count = LAST_INSERT_ID\(download_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(4, 1))
+		mock.ExpectExec(`INSERT INTO asset_event \(c_time, asset_id, event_type\) VALUES \(\?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", model.AssetEventDownload).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		downloadCount, err := ctrl.IncrementAssetDownloadCount(context.Background(), "1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), downloadCount)
+	})
+
+	t.Run("NoAccess", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Personal))
+		_, err = ctrl.IncrementAssetDownloadCount(context.Background(), "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE asset SET download_count = LAST_INSERT_ID\(download_count \+ 1\) WHERE id = \?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ctrl.IncrementAssetDownloadCount(context.Background(), "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerDeleteAsset(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectExec(`UPDATE asset SET deleted_at = \?, status = \? WHERE id = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), model.StatusDeleted, "1", model.StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		ctrl.categoryCache.set(anyAssetType, []model.CategoryCount{{Category: "animals", AssetCount: 1}}, time.Now())
+		err = ctrl.DeleteAsset(ctx, "1")
+		require.NoError(t, err)
+
+		_, ok := ctrl.categoryCache.get(anyAssetType, time.Now())
+		assert.False(t, ok)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		err = ctrl.DeleteAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		err = ctrl.DeleteAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		err = ctrl.DeleteAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectExec(`UPDATE asset SET deleted_at = \?, status = \? WHERE id = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), model.StatusDeleted, "1", model.StatusDeleted).
+			WillReturnError(sql.ErrConnDone)
+		err = ctrl.DeleteAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
 	})
 }
 
-func TestUpdateAssetParamsValidate(t *testing.T) {
+func TestDeleteAssetsParamsValidate(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
+		params := &DeleteAssetsParams{IDs: []string{"1", "2"}}
 		ok, msg := params.Validate()
 		assert.True(t, ok)
 		assert.Empty(t, msg)
 	})
 
-	t.Run("EmptyDisplayName", func(t *testing.T) {
-		params := &UpdateAssetParams{
-			DisplayName: "",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
+	t.Run("MissingIDs", func(t *testing.T) {
+		params := &DeleteAssetsParams{}
 		ok, msg := params.Validate()
 		assert.False(t, ok)
-		assert.Equal(t, "missing displayName", msg)
+		assert.Equal(t, "missing ids", msg)
 	})
 
-	t.Run("InvalidDisplayName", func(t *testing.T) {
-		params := &UpdateAssetParams{
-			DisplayName: strings.Repeat("fake-asset", 11),
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
+	t.Run("TooManyIDs", func(t *testing.T) {
+		params := &DeleteAssetsParams{IDs: make([]string, maxDeleteAssets+1)}
 		ok, msg := params.Validate()
 		assert.False(t, ok)
-		assert.Equal(t, "invalid displayName", msg)
+		assert.Equal(t, "too many ids (max 500)", msg)
 	})
 
-	t.Run("EmptyCategory", func(t *testing.T) {
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
+	t.Run("InvalidIDs", func(t *testing.T) {
+		params := &DeleteAssetsParams{IDs: []string{"1", "abc"}}
 		ok, msg := params.Validate()
 		assert.False(t, ok)
-		assert.Equal(t, "missing category", msg)
+		assert.Equal(t, "invalid ids", msg)
 	})
+}
 
-	t.Run("InvalidAssetType", func(t *testing.T) {
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   -1,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
-		ok, msg := params.Validate()
+func TestControllerDeleteAssets(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.categoryCache.set(anyAssetType, []model.CategoryCount{{Category: "animals", AssetCount: 1}}, time.Now())
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?,\?,\?\) AND status != \? FOR UPDATE`).
+			WithArgs("1", "2", "3", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "owner"}).
+				AddRow(1, "fake-name").
+				AddRow(2, "another-fake-name"))
+		mock.ExpectExec(`UPDATE asset SET deleted_at = \?, status = \? WHERE id IN \(\?\)`).
+			WithArgs(sqlmock.AnyArg(), model.StatusDeleted, "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		results, err := ctrl.DeleteAssets(ctx, []string{"1", "2", "3", "1"}, "fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, []DeleteAssetResult{
+			{ID: "1", Status: DeleteAssetDeleted},
+			{ID: "2", Status: DeleteAssetForbidden},
+			{ID: "3", Status: DeleteAssetNotFound},
+			{ID: "1", Status: DeleteAssetDeleted},
+		}, results)
+		_, ok := ctrl.categoryCache.get(anyAssetType, time.Now())
 		assert.False(t, ok)
-		assert.Equal(t, "invalid assetType", msg)
+		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("EmptyFilesHash", func(t *testing.T) {
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
+	t.Run("Batches", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		ids := make([]string, assetDeleteBatchSize+1)
+		for i := range ids {
+			ids[i] = strconv.Itoa(i + 1)
 		}
-		ok, msg := params.Validate()
-		assert.False(t, ok)
-		assert.Equal(t, "missing filesHash", msg)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(.+\) AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner"}))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\) AND status != \? FOR UPDATE`).
+			WithArgs(ids[assetDeleteBatchSize], model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "owner"}))
+		mock.ExpectCommit()
+		results, err := ctrl.DeleteAssets(ctx, ids, "fake-name")
+		require.NoError(t, err)
+		assert.Len(t, results, len(ids))
+		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("InvalidIsPublic", func(t *testing.T) {
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    -1,
-		}
-		ok, msg := params.Validate()
-		assert.False(t, ok)
-		assert.Equal(t, "invalid isPublic", msg)
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.DeleteAssets(ctx, []string{"1"}, "another-fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.DeleteAssets(ctx, []string{"1"}, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
 	})
 }
 
-func TestControllerUpdateAsset(t *testing.T) {
+func TestControllerRestoreAsset(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
 
 		ctx := newContextWithTestUser(context.Background())
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
-			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,preview=\?,is_public=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), params.DisplayName, params.Category, params.AssetType, []byte("{}"), params.FilesHash, params.Preview, params.IsPublic, "1").
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "deleted_at", "display_name", "owner", "status"}).
+				AddRow(1, time.Now().Add(-time.Hour), "fake-asset", "fake-name", model.StatusDeleted))
+		mock.ExpectExec(`UPDATE asset SET deleted_at = NULL, status = \? WHERE id = \? AND status = \?`).
+			WithArgs(model.StatusNormal, "1", model.StatusDeleted).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
-			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Public))
-		asset, err := ctrl.UpdateAsset(ctx, "1", params)
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "status"}).
+				AddRow(1, "fake-asset", "fake-name", model.StatusNormal))
+		ctrl.categoryCache.set(anyAssetType, []model.CategoryCount{{Category: "animals", AssetCount: 1}}, time.Now())
+		asset, err := ctrl.RestoreAsset(ctx, "1")
 		require.NoError(t, err)
 		require.NotNil(t, asset)
-		assert.Equal(t, "1", asset.ID)
-		assert.Equal(t, model.Public, asset.IsPublic)
+		assert.Equal(t, model.StatusNormal, asset.Status)
+
+		_, ok := ctrl.categoryCache.get(anyAssetType, time.Now())
+		assert.False(t, ok)
 	})
 
 	t.Run("NoUser", func(t *testing.T) {
@@ -670,19 +2641,11 @@ func TestControllerUpdateAsset(t *testing.T) {
 		require.NoError(t, err)
 
 		ctx := context.Background()
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
-			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "deleted_at", "display_name", "owner", "status"}).
+				AddRow(1, time.Now().Add(-time.Hour), "fake-asset", "fake-name", model.StatusDeleted))
+		_, err = ctrl.RestoreAsset(ctx, "1")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrUnauthorized)
 	})
@@ -692,71 +2655,90 @@ func TestControllerUpdateAsset(t *testing.T) {
 		require.NoError(t, err)
 
 		ctx := newContextWithTestUser(context.Background())
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
-			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "another-fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "deleted_at", "display_name", "owner", "status"}).
+				AddRow(1, time.Now().Add(-time.Hour), "fake-asset", "another-fake-name", model.StatusDeleted))
+		_, err = ctrl.RestoreAsset(ctx, "1")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrForbidden)
 	})
 
-	t.Run("NoAsset", func(t *testing.T) {
+	t.Run("NotDeleted", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
 
 		ctx := newContextWithTestUser(context.Background())
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
 			WillReturnRows(mock.NewRows(nil))
-		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		_, err = ctrl.RestoreAsset(ctx, "1")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, model.ErrNotExist)
 	})
 
+	t.Run("OutOfRestoreWindow", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "deleted_at", "display_name", "owner", "status"}).
+				AddRow(1, time.Now().Add(-assetRestoreWindow-time.Hour), "fake-asset", "fake-name", model.StatusDeleted))
+		_, err = ctrl.RestoreAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("UpdatedAfterDeletion", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		// The restore window starts at the deletion, not at the last update.
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "u_time", "deleted_at", "display_name", "owner", "status"}).
+				AddRow(1, time.Now().Add(-time.Hour), time.Now().Add(-assetRestoreWindow-time.Hour), "fake-asset", "fake-name", model.StatusDeleted))
+		_, err = ctrl.RestoreAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("NoDeletionTime", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "deleted_at", "display_name", "owner", "status"}).
+				AddRow(1, nil, "fake-asset", "fake-name", model.StatusDeleted))
+		_, err = ctrl.RestoreAsset(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
 	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
 
 		ctx := newContextWithTestUser(context.Background())
-		params := &UpdateAssetParams{
-			DisplayName: "fake-asset",
-			Category:    "fake-category",
-			AssetType:   model.AssetTypeSprite,
-			Files:       model.FileCollection{},
-			FilesHash:   "fake-files-hash",
-			Preview:     "fake-preview",
-			IsPublic:    model.Personal,
-		}
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
-			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,preview=\?,is_public=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), params.DisplayName, params.Category, params.AssetType, []byte("{}"), params.FilesHash, params.Preview, params.IsPublic, "1").
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "deleted_at", "display_name", "owner", "status"}).
+				AddRow(1, time.Now().Add(-time.Hour), "fake-asset", "fake-name", model.StatusDeleted))
+		mock.ExpectExec(`UPDATE asset SET deleted_at = NULL, status = \? WHERE id = \? AND status = \?`).
+			WithArgs(model.StatusNormal, "1", model.StatusDeleted).
 			WillReturnError(sql.ErrConnDone)
-		_, err = ctrl.UpdateAsset(ctx, "1", params)
+		_, err = ctrl.RestoreAsset(ctx, "1")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 	})
 }
 
-func TestControllerIncreaseAssetClickCount(t *testing.T) {
+func TestControllerListAssetVersions(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
@@ -765,11 +2747,20 @@ func TestControllerIncreaseAssetClickCount(t *testing.T) {
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
 				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		mock.ExpectExec(`UPDATE asset SET u_time = \?, click_count = click_count \+ 1 WHERE id = \?`).
-			WithArgs(sqlmock.AnyArg(), "1").
-			WillReturnResult(sqlmock.NewResult(1, 1))
-		err = ctrl.IncreaseAssetClickCount(ctx, "1")
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_version WHERE asset_id = \? AND status != \?`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \? AND status != \? ORDER BY id DESC LIMIT \?, \?`).
+			WithArgs("1", model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name", "editor"}).
+				AddRow(1, 1, "old-fake-asset", "fake-name"))
+		versions, err := ctrl.ListAssetVersions(ctx, "1", model.Pagination{Index: 1, Size: 10})
 		require.NoError(t, err)
+		require.NotNil(t, versions)
+		assert.Equal(t, 1, versions.Total)
+		require.Len(t, versions.Data, 1)
+		assert.Equal(t, "old-fake-asset", versions.Data[0].DisplayName)
 	})
 
 	t.Run("NoUser", func(t *testing.T) {
@@ -780,7 +2771,7 @@ func TestControllerIncreaseAssetClickCount(t *testing.T) {
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
 				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		err = ctrl.IncreaseAssetClickCount(ctx, "1")
+		_, err = ctrl.ListAssetVersions(ctx, "1", model.Pagination{Index: 1, Size: 10})
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrUnauthorized)
 	})
@@ -792,25 +2783,13 @@ func TestControllerIncreaseAssetClickCount(t *testing.T) {
 		ctx := newContextWithTestUser(context.Background())
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "another-fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		err = ctrl.IncreaseAssetClickCount(ctx, "1")
+				AddRow(1, "fake-asset", "another-fake-name", []byte("{}"), "fake-files-hash", model.Public))
+		_, err = ctrl.ListAssetVersions(ctx, "1", model.Pagination{Index: 1, Size: 10})
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrForbidden)
 	})
 
-	t.Run("NoAsset", func(t *testing.T) {
-		ctrl, mock, err := newTestController(t)
-		require.NoError(t, err)
-
-		ctx := newContextWithTestUser(context.Background())
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
-			WillReturnRows(mock.NewRows(nil))
-		err = ctrl.IncreaseAssetClickCount(ctx, "1")
-		require.Error(t, err)
-		assert.ErrorIs(t, err, model.ErrNotExist)
-	})
-
-	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+	t.Run("ClosedConnForCountQuery", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
 
@@ -818,16 +2797,15 @@ func TestControllerIncreaseAssetClickCount(t *testing.T) {
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
 				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		mock.ExpectExec(`UPDATE asset SET u_time = \?, click_count = click_count \+ 1 WHERE id = \?`).
-			WithArgs(sqlmock.AnyArg(), "1").
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_version WHERE asset_id = \? AND status != \?`).
 			WillReturnError(sql.ErrConnDone)
-		err = ctrl.IncreaseAssetClickCount(ctx, "1")
+		_, err = ctrl.ListAssetVersions(ctx, "1", model.Pagination{Index: 1, Size: 10})
 		require.Error(t, err)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 	})
 }
 
-func TestControllerDeleteAsset(t *testing.T) {
+func TestControllerRestoreAssetVersion(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
@@ -835,12 +2813,37 @@ func TestControllerDeleteAsset(t *testing.T) {
 		ctx := newContextWithTestUser(context.Background())
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,status=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), model.StatusDeleted, "1").
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Public))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WithArgs("2", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name", "files", "files_hash", "files_meta", "license", "is_public"}).
+				AddRow(2, 1, "old-fake-asset", []byte("{}"), "old-fake-files-hash", []byte(`{"a.png":{"size":100,"contentType":"image/png","width":32,"height":32}}`), model.LicenseCC0, model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public", "moderation_status"}).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Public, model.ModerationVisible))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WithArgs("fake-name", "1", model.StatusDeleted, "old-fake-asset", "old-fake-asset (%)").
+			WillReturnRows(mock.NewRows(nil))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(3, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), "old-fake-asset", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), []byte("{}"), "old-fake-files-hash", []byte(`{"a.png":{"size":100,"contentType":"image/png","width":32,"height":32}}`), sqlmock.AnyArg(), model.Personal, model.LicenseCC0, model.ModerationPending, "1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		err = ctrl.DeleteAsset(ctx, "1")
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
+				AddRow(1, "old-fake-asset", "fake-name", []byte("{}"), "old-fake-files-hash", model.Public))
+		asset, err := ctrl.RestoreAssetVersion(ctx, "1", "2")
 		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "old-fake-asset", asset.DisplayName)
+		assert.Equal(t, "old-fake-files-hash", asset.FilesHash)
 	})
 
 	t.Run("NoUser", func(t *testing.T) {
@@ -851,37 +2854,27 @@ func TestControllerDeleteAsset(t *testing.T) {
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
 				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		err = ctrl.DeleteAsset(ctx, "1")
+		_, err = ctrl.RestoreAssetVersion(ctx, "1", "2")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrUnauthorized)
 	})
 
-	t.Run("UnexpectedUser", func(t *testing.T) {
+	t.Run("NoVersion", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
 
 		ctx := newContextWithTestUser(context.Background())
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
-				AddRow(1, "fake-asset", "another-fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		err = ctrl.DeleteAsset(ctx, "1")
-		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrForbidden)
-	})
-
-	t.Run("NoAsset", func(t *testing.T) {
-		ctrl, mock, err := newTestController(t)
-		require.NoError(t, err)
-
-		ctx := newContextWithTestUser(context.Background())
-		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows(nil))
-		err = ctrl.DeleteAsset(ctx, "1")
+		_, err = ctrl.RestoreAssetVersion(ctx, "1", "2")
 		require.Error(t, err)
 		assert.ErrorIs(t, err, model.ErrNotExist)
 	})
 
-	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+	t.Run("VersionOfAnotherAsset", func(t *testing.T) {
 		ctrl, mock, err := newTestController(t)
 		require.NoError(t, err)
 
@@ -889,11 +2882,11 @@ func TestControllerDeleteAsset(t *testing.T) {
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "files", "files_hash", "is_public"}).
 				AddRow(1, "fake-asset", "fake-name", []byte("{}"), "fake-files-hash", model.Personal))
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,status=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), model.StatusDeleted, "1").
-			WillReturnError(sql.ErrConnDone)
-		err = ctrl.DeleteAsset(ctx, "1")
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(2, 3))
+		_, err = ctrl.RestoreAssetVersion(ctx, "1", "2")
 		require.Error(t, err)
-		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.ErrorIs(t, err, ErrNotExist)
 	})
 }
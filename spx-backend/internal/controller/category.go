@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// categoryCache caches asset categories with their asset counts by asset type
+// filter. Categories change slowly, so serving them a little stale is fine.
+type categoryCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[model.AssetType]categoryCacheEntry
+}
+
+// categoryCacheEntry is an entry of [categoryCache].
+type categoryCacheEntry struct {
+	categoryCounts []model.CategoryCount
+	expiresAt      time.Time
+}
+
+// get returns the cached categories for the asset type if not expired.
+func (c *categoryCache) get(assetType model.AssetType, now time.Time) ([]model.CategoryCount, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[assetType]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.categoryCounts, true
+}
+
+// set caches the categories for the asset type.
+func (c *categoryCache) set(assetType model.AssetType, categoryCounts []model.CategoryCount, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[model.AssetType]categoryCacheEntry)
+	}
+	c.entries[assetType] = categoryCacheEntry{
+		categoryCounts: categoryCounts,
+		expiresAt:      now.Add(c.ttl),
+	}
+}
+
+// reset drops all cached categories, e.g., after assets are deleted.
+func (c *categoryCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+// anyAssetType is the key of [categoryCache] for categories of all asset types.
+const anyAssetType model.AssetType = -1
+
+// ListAssetCategoriesParams holds parameters for listing asset categories.
+type ListAssetCategoriesParams struct {
+	// AssetType is the asset type filter, applied only if non-nil.
+	AssetType *model.AssetType
+}
+
+// Validate validates the parameters.
+func (p *ListAssetCategoriesParams) Validate() (ok bool, msg string) {
+	if p.AssetType != nil {
+		switch *p.AssetType {
+		case model.AssetTypeSprite, model.AssetTypeBackdrop, model.AssetTypeSound:
+		default:
+			return false, "invalid assetType"
+		}
+	}
+	return true, ""
+}
+
+// ListAssetCategories lists categories of visible public assets with the number
+// of assets in each. Results are cached for a short while.
+func (ctrl *Controller) ListAssetCategories(ctx context.Context, params *ListAssetCategoriesParams) ([]model.CategoryCount, error) {
+	logger := log.GetReqLogger(ctx)
+
+	wheres := []model.FilterCondition{
+		{Column: "is_public", Operation: "=", Value: model.Public},
+		{Column: "moderation_status", Operation: "=", Value: model.ModerationVisible},
+	}
+	cacheKey := anyAssetType
+	if params.AssetType != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "asset_type", Operation: "=", Value: *params.AssetType})
+		cacheKey = *params.AssetType
+	}
+
+	now := time.Now()
+	if categoryCounts, ok := ctrl.categoryCache.get(cacheKey, now); ok {
+		return categoryCounts, nil
+	}
+	categoryCounts, err := model.ListAssetCategories(ctx, ctrl.db, wheres)
+	if err != nil {
+		logger.Printf("failed to list asset categories: %v", err)
+		return nil, err
+	}
+	ctrl.categoryCache.set(cacheKey, categoryCounts, now)
+	return categoryCounts, nil
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryCache(t *testing.T) {
+	c := &categoryCache{ttl: time.Minute}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	categoryCounts := []model.CategoryCount{{Category: "animals", AssetCount: 1}}
+
+	_, ok := c.get(model.AssetTypeSprite, now)
+	assert.False(t, ok)
+
+	c.set(model.AssetTypeSprite, categoryCounts, now)
+	got, ok := c.get(model.AssetTypeSprite, now.Add(30*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, categoryCounts, got)
+
+	_, ok = c.get(model.AssetTypeBackdrop, now)
+	assert.False(t, ok)
+
+	_, ok = c.get(model.AssetTypeSprite, now.Add(time.Minute))
+	assert.False(t, ok)
+
+	c.set(model.AssetTypeSprite, categoryCounts, now)
+	c.reset()
+	_, ok = c.get(model.AssetTypeSprite, now)
+	assert.False(t, ok)
+}
+
+func TestListAssetCategoriesParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		assetType := model.AssetTypeSound
+		params := &ListAssetCategoriesParams{AssetType: &assetType}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("NoAssetType", func(t *testing.T) {
+		params := &ListAssetCategoriesParams{}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidAssetType", func(t *testing.T) {
+		assetType := model.AssetType(-1)
+		params := &ListAssetCategoriesParams{AssetType: &assetType}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid assetType", msg)
+	})
+}
+
+func TestControllerListAssetCategories(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		assetType := model.AssetTypeBackdrop
+		params := &ListAssetCategoriesParams{AssetType: &assetType}
+		mock.ExpectQuery(`SELECT COALESCE\(NULLIF\(TRIM\(category\), ''\), \?\) AS category, COUNT\(\*\) AS asset_count FROM asset WHERE is_public = \? AND moderation_status = \? AND asset_type = \? AND status != \? GROUP BY 1`).
+			WithArgs(model.UncategorizedCategory, model.Public, model.ModerationVisible, model.AssetTypeBackdrop, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"category", "asset_count"}).
+				AddRow("scenery", 2))
+		categoryCounts, err := ctrl.ListAssetCategories(context.Background(), params)
+		require.NoError(t, err)
+		assert.Equal(t, []model.CategoryCount{{Category: "scenery", AssetCount: 2}}, categoryCounts)
+
+		// The second call is served from the cache.
+		categoryCounts, err = ctrl.ListAssetCategories(context.Background(), params)
+		require.NoError(t, err)
+		assert.Equal(t, []model.CategoryCount{{Category: "scenery", AssetCount: 2}}, categoryCounts)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedDB", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.db.Close()
+
+		_, err = ctrl.ListAssetCategories(context.Background(), &ListAssetCategoriesParams{})
+		require.Error(t, err)
+	})
+}
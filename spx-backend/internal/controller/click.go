@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// clickSalt is the salt for hashing IP addresses of anonymous viewers.
+//
+// The salt of a date is derived from a configured secret, so all replicas hash
+// the same IP address to the same value on the same date. The hashes can
+// neither be reversed nor linked across days without the secret.
+type clickSalt struct {
+	secret []byte
+}
+
+// hashIP returns the hash of ip salted with the salt of the date of now.
+func (s *clickSalt) hashIP(ip string, now time.Time) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(now.UTC().Format(time.DateOnly)))
+	salt := mac.Sum(nil)
+
+	mac = hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// clickViewer returns the identity of the viewer for deduplicating clicks. It
+// is the user name for signed-in users, and the salted hash of clientIP
+// otherwise.
+func (ctrl *Controller) clickViewer(ctx context.Context, clientIP string) string {
+	if user, ok := UserFromContext(ctx); ok {
+		return "user:" + user.Name
+	}
+	return "ip:" + ctrl.clickSalt.hashIP(clientIP, time.Now())
+}
+
+// TrimAssetClicks deletes asset clicks older than the retention period. Clicks
+// are only needed to deduplicate clicks on the same day, so the retention
+// period can be short.
+func (ctrl *Controller) TrimAssetClicks(ctx context.Context) error {
+	logger := log.GetReqLogger(ctx)
+
+	n, err := model.DeleteAssetClicksBefore(ctx, ctrl.db, time.Now().Add(-ctrl.assetClickRetention))
+	if err != nil {
+		logger.Printf("failed to delete asset clicks: %v", err)
+		return err
+	}
+	logger.Printf("deleted %d asset clicks", n)
+	return nil
+}
+
+// TrimAssetViews deletes daily asset views older than the retention period.
+func (ctrl *Controller) TrimAssetViews(ctx context.Context) error {
+	logger := log.GetReqLogger(ctx)
+
+	n, err := model.DeleteAssetViewsBefore(ctx, ctrl.db, time.Now().Add(-ctrl.assetViewRetention))
+	if err != nil {
+		logger.Printf("failed to delete asset views: %v", err)
+		return err
+	}
+	logger.Printf("deleted %d daily asset views", n)
+	return nil
+}
+
+// TrimAssetEvents deletes asset events older than the retention period.
+func (ctrl *Controller) TrimAssetEvents(ctx context.Context) error {
+	logger := log.GetReqLogger(ctx)
+
+	n, err := model.DeleteAssetEventsBefore(ctx, ctrl.db, time.Now().UTC().Add(-ctrl.assetEventRetention))
+	if err != nil {
+		logger.Printf("failed to delete asset events: %v", err)
+		return err
+	}
+	logger.Printf("deleted %d asset events", n)
+	return nil
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickSaltHashIP(t *testing.T) {
+	s := &clickSalt{secret: []byte("fake-secret")}
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	hash := s.hashIP("127.0.0.1", day1)
+	assert.Len(t, hash, 64)
+	assert.NotContains(t, hash, "127.0.0.1")
+	assert.Equal(t, hash, s.hashIP("127.0.0.1", day1.Add(time.Hour)))
+	assert.NotEqual(t, hash, s.hashIP("127.0.0.2", day1))
+	assert.NotEqual(t, hash, s.hashIP("127.0.0.1", day2))
+
+	// Replicas sharing the secret agree on the hash.
+	other := &clickSalt{secret: []byte("fake-secret")}
+	assert.Equal(t, hash, other.hashIP("127.0.0.1", day1))
+
+	// Hashes cannot be reproduced without the secret.
+	stranger := &clickSalt{secret: []byte("other-secret")}
+	assert.NotEqual(t, hash, stranger.hashIP("127.0.0.1", day1))
+}
+
+func TestControllerClickViewer(t *testing.T) {
+	t.Run("User", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		assert.Equal(t, "user:fake-name", ctrl.clickViewer(ctx, "127.0.0.1"))
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		viewer := ctrl.clickViewer(context.Background(), "127.0.0.1")
+		assert.True(t, strings.HasPrefix(viewer, "ip:"))
+		assert.Equal(t, viewer, ctrl.clickViewer(context.Background(), "127.0.0.1"))
+	})
+}
+
+func TestControllerTrimAssetClicks(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectExec(`DELETE FROM asset_click WHERE click_date < \?`).
+			WithArgs(time.Now().Add(-ctrl.assetClickRetention).UTC().Format(time.DateOnly)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.TrimAssetClicks(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConnForDeleteQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectExec(`DELETE FROM asset_click WHERE click_date < \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = ctrl.TrimAssetClicks(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerTrimAssetViews(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectExec(`DELETE FROM asset_view_daily WHERE view_date < \?`).
+			WithArgs(time.Now().Add(-ctrl.assetViewRetention).UTC().Format(time.DateOnly)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.TrimAssetViews(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConnForDeleteQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectExec(`DELETE FROM asset_view_daily WHERE view_date < \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = ctrl.TrimAssetViews(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerTrimAssetEvents(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectExec(`DELETE FROM asset_event WHERE c_time < \?`).
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.TrimAssetEvents(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		t.Setenv("ASSET_EVENT_RETENTION", "24h")
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		assert.Equal(t, ownerStatsDays*24*time.Hour, ctrl.assetEventRetention)
+
+		t.Setenv("TRENDING_WINDOW", "1000h")
+		ctrl, _, err = newTestController(t)
+		require.NoError(t, err)
+		assert.Equal(t, 1000*time.Hour, ctrl.assetEventRetention)
+	})
+
+	t.Run("ClosedConnForDeleteQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectExec(`DELETE FROM asset_event WHERE c_time < \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = ctrl.TrimAssetEvents(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
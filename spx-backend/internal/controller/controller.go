@@ -1,27 +1,36 @@
 package controller
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	_ "image/png"
 	"io/fs"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/goplus/builder/spx-backend/internal/aigc"
 	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
 	"github.com/joho/godotenv"
 	_ "github.com/qiniu/go-cdk-driver/kodoblob"
 	qiniuAuth "github.com/qiniu/go-sdk/v7/auth"
+	qiniuClient "github.com/qiniu/go-sdk/v7/client"
+	qiniuStorage "github.com/qiniu/go-sdk/v7/storage"
 	qiniuLog "github.com/qiniu/x/log"
 )
 
 var (
-	ErrNotExist     = errors.New("not exist")
-	ErrUnauthorized = errors.New("unauthorized")
-	ErrForbidden    = errors.New("forbidden")
+	ErrNotExist        = errors.New("not exist")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrInvalidArgs     = errors.New("invalid args")
 )
 
 // contextKey is a value for use with [context.WithValue]. It's used as a
@@ -34,8 +43,51 @@ type contextKey struct {
 type Controller struct {
 	db            *sql.DB
 	kodo          *kodoConfig
+	bucketManager bucketManager
+	uploader      uploader
 	aigcClient    *aigc.AigcClient
 	casdoorClient *casdoorsdk.Client
+	userDirectory userDirectory
+
+	// httpClient is the client for requests to the bucket.
+	httpClient *http.Client
+
+	// previewRenders limits the number of previews rendered in the
+	// background at the same time.
+	previewRenders chan struct{}
+
+	// assetVersionLimit is the maximum number of versions retained per asset.
+	assetVersionLimit int
+
+	// trending configures how trending scores of assets are computed.
+	trending *trendingConfig
+
+	// clickSalt is the salt for hashing IP addresses of anonymous viewers.
+	clickSalt *clickSalt
+
+	// assetClickRetention is how long asset clicks are kept for deduplication.
+	assetClickRetention time.Duration
+
+	// assetViewRetention is how long daily asset views are kept.
+	assetViewRetention time.Duration
+
+	// assetEventRetention is how long asset events are kept for trending
+	// scores and owner asset stats.
+	assetEventRetention time.Duration
+
+	// assetGCRetention is how long deleted assets are kept before they are
+	// permanently removed along with their storage objects.
+	assetGCRetention time.Duration
+
+	// report configures how asset reports are limited and acted on.
+	report *reportConfig
+
+	// categoryCache caches asset categories with their asset counts.
+	categoryCache *categoryCache
+
+	// displayNamePolicy is how display names already taken by other assets
+	// of the same owner are handled.
+	displayNamePolicy model.DisplayNamePolicy
 }
 
 // New creates a new controller.
@@ -77,11 +129,56 @@ func New(ctx context.Context) (*Controller, error) {
 	}
 	casdoorClient := casdoorsdk.NewClientWithConf(casdoorAuthConfig)
 
+	// Requests to the bucket must not hang, since some of them are made
+	// while serving requests.
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	trendingWindow := envDuration(logger, "TRENDING_WINDOW", 7*24*time.Hour)
+
 	return &Controller{
 		db:            db,
 		kodo:          kodoConfig,
+		bucketManager: qiniuStorage.NewBucketManagerEx(kodoConfig.cred, nil, &qiniuClient.Client{Client: httpClient}),
+		uploader: &kodoUploader{
+			kodo:         kodoConfig,
+			formUploader: qiniuStorage.NewFormUploader(nil),
+		},
 		aigcClient:    aigcClient,
 		casdoorClient: casdoorClient,
+		userDirectory: casdoorClient,
+		httpClient:    httpClient,
+
+		previewRenders: make(chan struct{}, maxConcurrentPreviewRenders),
+
+		// At least the version replaced by the latest update is kept.
+		assetVersionLimit: max(envInt(logger, "ASSET_VERSION_LIMIT", 20), 1),
+		trending: &trendingConfig{
+			window:   trendingWindow,
+			halfLife: envDuration(logger, "TRENDING_HALF_LIFE", 48*time.Hour),
+			weights: map[model.AssetEventType]float64{
+				model.AssetEventClick:    envFloat(logger, "TRENDING_CLICK_WEIGHT", 1),
+				model.AssetEventDownload: envFloat(logger, "TRENDING_DOWNLOAD_WEIGHT", 3),
+			},
+		},
+		clickSalt:           &clickSalt{secret: []byte(mustEnv(logger, "ASSET_CLICK_SALT_SECRET"))},
+		assetClickRetention: envDuration(logger, "ASSET_CLICK_RETENTION", 7*24*time.Hour),
+		assetViewRetention:  envDuration(logger, "ASSET_VIEW_RETENTION", 400*24*time.Hour),
+		// Events are never trimmed while trending scores or owner asset stats
+		// still count them.
+		assetEventRetention: max(envDuration(logger, "ASSET_EVENT_RETENTION", 90*24*time.Hour), ownerStatsDays*24*time.Hour, trendingWindow),
+		// Deleted assets are never collected while they can still be restored.
+		assetGCRetention: max(envDuration(logger, "ASSET_GC_RETENTION", assetRestoreWindow), assetRestoreWindow),
+		report: &reportConfig{
+			threshold:  envInt(logger, "ASSET_REPORT_THRESHOLD", 5),
+			rateLimit:  envInt(logger, "ASSET_REPORT_RATE_LIMIT", 10),
+			rateWindow: envDuration(logger, "ASSET_REPORT_RATE_WINDOW", time.Hour),
+		},
+		categoryCache: &categoryCache{
+			ttl: envDuration(logger, "ASSET_CATEGORY_CACHE_TTL", 5*time.Minute),
+		},
+		displayNamePolicy: model.DisplayNamePolicy(envEnum(logger, "ASSET_DISPLAY_NAME_POLICY", string(model.DisplayNameSuffix), string(model.DisplayNameReject))),
 	}, nil
 }
 
@@ -93,6 +190,56 @@ type kodoConfig struct {
 	baseUrl      string
 }
 
+// bucketManager is the subset of [qiniuStorage.BucketManager] used by the
+// controller.
+type bucketManager interface {
+	Copy(srcBucket, srcKey, destBucket, destKey string, force bool) error
+	Stat(bucket, key string) (qiniuStorage.FileInfo, error)
+	Delete(bucket, key string) error
+}
+
+// uploader uploads objects to the bucket.
+type uploader interface {
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// userDirectory is the subset of [casdoorsdk.Client] used to look up users.
+type userDirectory interface {
+	// GetUser returns the user with given name, or nil if there is none.
+	GetUser(name string) (*casdoorsdk.User, error)
+}
+
+// kodoUploader is an [uploader] backed by a [qiniuStorage.FormUploader].
+type kodoUploader struct {
+	kodo         *kodoConfig
+	formUploader *qiniuStorage.FormUploader
+}
+
+// Put implements [uploader].
+func (u *kodoUploader) Put(ctx context.Context, key string, data []byte) error {
+	putPolicy := qiniuStorage.PutPolicy{
+		Scope:   u.kodo.bucket + ":" + key,
+		Expires: 600, // 10 minutes in seconds
+	}
+	upToken := putPolicy.UploadToken(u.kodo.cred)
+	var ret qiniuStorage.PutRet
+	return u.formUploader.Put(ctx, &ret, upToken, key, bytes.NewReader(data), int64(len(data)), nil)
+}
+
+// trendingConfig is the configuration for trending assets.
+type trendingConfig struct {
+	window   time.Duration                    // only events within the window are counted
+	halfLife time.Duration                    // the weight of an event halves every halfLife
+	weights  map[model.AssetEventType]float64 // weight of each event type
+}
+
+// reportConfig is the configuration for asset reports.
+type reportConfig struct {
+	threshold  int           // open reports needed to hide an asset pending review
+	rateLimit  int           // maximum reports per user within rateWindow
+	rateWindow time.Duration // window for rateLimit
+}
+
 // mustEnv gets the environment variable value or exits the program.
 func mustEnv(logger *qiniuLog.Logger, key string) string {
 	value := os.Getenv(key)
@@ -101,3 +248,63 @@ func mustEnv(logger *qiniuLog.Logger, key string) string {
 	}
 	return value
 }
+
+// envInt gets the environment variable value as an int, or returns
+// defaultValue if it is not set. It exits the program if the value is invalid.
+func envInt(logger *qiniuLog.Logger, key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Fatalf("Invalid environment variable %s: %v", key, err)
+	}
+	return i
+}
+
+// envDuration gets the environment variable value as a [time.Duration], or
+// returns defaultValue if it is not set. It exits the program if the value is
+// invalid.
+func envDuration(logger *qiniuLog.Logger, key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Fatalf("Invalid environment variable %s: %v", key, err)
+	}
+	return d
+}
+
+// envFloat gets the environment variable value as a float64, or returns
+// defaultValue if it is not set. It exits the program if the value is invalid.
+func envFloat(logger *qiniuLog.Logger, key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		logger.Fatalf("Invalid environment variable %s: %v", key, err)
+	}
+	return f
+}
+
+// envEnum gets the environment variable value, or returns defaultValue if it
+// is not set. It exits the program if the value is neither defaultValue nor
+// one of others.
+func envEnum(logger *qiniuLog.Logger, key string, defaultValue string, others ...string) string {
+	value := os.Getenv(key)
+	if value == "" || value == defaultValue {
+		return defaultValue
+	}
+	for _, other := range others {
+		if value == other {
+			return value
+		}
+	}
+	logger.Fatalf("Invalid environment variable %s: %q", key, value)
+	return ""
+}
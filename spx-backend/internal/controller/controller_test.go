@@ -3,8 +3,13 @@ package controller
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
+	"github.com/goplus/builder/spx-backend/internal/log"
+	qiniuClient "github.com/qiniu/go-sdk/v7/client"
+	qiniuStorage "github.com/qiniu/go-sdk/v7/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -19,6 +24,8 @@ func setTestEnv(t *testing.T) {
 	t.Setenv("KODO_BUCKET_REGION", "earth")
 	t.Setenv("KODO_BASE_URL", "https://kodo.example.com")
 
+	t.Setenv("ASSET_CLICK_SALT_SECRET", "fake-click-salt-secret")
+
 	t.Setenv("GOP_CASDOOR_ENDPOINT", "https://casdoor.example.com")
 	t.Setenv("GOP_CASDOOR_CLIENTID", "fake-client-id")
 	t.Setenv("GOP_CASDOOR_CLIENTSECRET", "fake-client-secret")
@@ -61,9 +68,91 @@ func newTestController(t *testing.T) (*Controller, sqlmock.Sqlmock, error) {
 		return nil, nil, err
 	}
 	ctrl.db = db
+	ctrl.bucketManager = &fakeBucketManager{}
+	ctrl.uploader = &fakeUploader{}
+	ctrl.userDirectory = &fakeUserDirectory{}
 	return ctrl, mock, nil
 }
 
+// fakeUserDirectory is a [userDirectory] knowing every user except those in
+// missing.
+type fakeUserDirectory struct {
+	missing map[string]bool // names of users that do not exist
+	err     error
+}
+
+// GetUser implements [userDirectory].
+func (d *fakeUserDirectory) GetUser(name string) (*casdoorsdk.User, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if d.missing[name] {
+		return nil, nil
+	}
+	return &casdoorsdk.User{Name: name}, nil
+}
+
+// fakeUploader is an [uploader] recording uploads instead of making them.
+type fakeUploader struct {
+	puts map[string][]byte // uploaded data by key
+	err  error
+}
+
+// Put implements [uploader].
+func (u *fakeUploader) Put(ctx context.Context, key string, data []byte) error {
+	if u.err != nil {
+		return u.err
+	}
+	if u.puts == nil {
+		u.puts = make(map[string][]byte)
+	}
+	u.puts[key] = data
+	return nil
+}
+
+// fakeBucketManager is a [bucketManager] recording copies and deletes instead
+// of making them, and serving file info from stats.
+type fakeBucketManager struct {
+	copies     [][2]string                      // source and destination keys
+	deletes    []string                         // deleted keys
+	stats      map[string]qiniuStorage.FileInfo // file info by key
+	deleteErrs map[string]error                 // errors of deletes by key
+	err        error
+}
+
+// Copy implements [bucketManager].
+func (m *fakeBucketManager) Copy(srcBucket, srcKey, destBucket, destKey string, force bool) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.copies = append(m.copies, [2]string{srcKey, destKey})
+	return nil
+}
+
+// Delete implements [bucketManager].
+func (m *fakeBucketManager) Delete(bucket, key string) error {
+	if m.err != nil {
+		return m.err
+	}
+	if err := m.deleteErrs[key]; err != nil {
+		return err
+	}
+	m.deletes = append(m.deletes, key)
+	return nil
+}
+
+// Stat implements [bucketManager].
+func (m *fakeBucketManager) Stat(bucket, key string) (qiniuStorage.FileInfo, error) {
+	if m.err != nil {
+		return qiniuStorage.FileInfo{}, m.err
+	}
+	info, ok := m.stats[key]
+	if !ok {
+		return qiniuStorage.FileInfo{}, &qiniuClient.ErrorInfo{Code: kodoNoSuchFileCode, Err: "no such file or directory"}
+	}
+	return info, nil
+}
+
 func TestNew(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		setTestEnv(t)
@@ -80,4 +169,60 @@ func TestNew(t *testing.T) {
 		assert.EqualError(t, err, "invalid DSN: missing the slash separating the database name")
 		require.Nil(t, ctrl)
 	})
+
+	t.Run("InvalidAssetVersionLimit", func(t *testing.T) {
+		setTestEnv(t)
+		t.Setenv("ASSET_VERSION_LIMIT", "0")
+		ctrl, err := New(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, ctrl.assetVersionLimit)
+	})
+}
+
+func TestEnvInt(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		t.Setenv("FAKE_INT", "5")
+		assert.Equal(t, 5, envInt(log.GetLogger(), "FAKE_INT", 20))
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		t.Setenv("FAKE_INT", "")
+		assert.Equal(t, 20, envInt(log.GetLogger(), "FAKE_INT", 20))
+	})
+}
+
+func TestEnvDuration(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		t.Setenv("FAKE_DURATION", "36h")
+		assert.Equal(t, 36*time.Hour, envDuration(log.GetLogger(), "FAKE_DURATION", time.Hour))
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		t.Setenv("FAKE_DURATION", "")
+		assert.Equal(t, time.Hour, envDuration(log.GetLogger(), "FAKE_DURATION", time.Hour))
+	})
+}
+
+func TestEnvFloat(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		t.Setenv("FAKE_FLOAT", "2.5")
+		assert.Equal(t, 2.5, envFloat(log.GetLogger(), "FAKE_FLOAT", 1))
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		t.Setenv("FAKE_FLOAT", "")
+		assert.Equal(t, 1.0, envFloat(log.GetLogger(), "FAKE_FLOAT", 1))
+	})
+}
+
+func TestEnvEnum(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		t.Setenv("FAKE_ENUM", "b")
+		assert.Equal(t, "b", envEnum(log.GetLogger(), "FAKE_ENUM", "a", "b", "c"))
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		t.Setenv("FAKE_ENUM", "")
+		assert.Equal(t, "a", envEnum(log.GetLogger(), "FAKE_ENUM", "a", "b", "c"))
+	})
 }
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder for image.DecodeConfig
+	_ "image/jpeg" // register JPEG decoder for image.DecodeConfig
+	_ "image/png"  // register PNG decoder for image.DecodeConfig
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// maxImageHeaderSize is the maximum number of bytes read from an image to
+// find its dimensions. Headers of common formats fit well within it.
+const maxImageHeaderSize = 64 << 10 // 64 KiB
+
+// filesProbeTimeout is the maximum time spent probing files of an asset.
+const filesProbeTimeout = 10 * time.Second
+
+// filesMetaBackfillBatchSize is the number of assets probed per batch when
+// backfilling metadata of files.
+const filesMetaBackfillBatchSize = 100
+
+// parseKodoObject parses the universal URL of an object and returns its key.
+// It returns false if the object is not stored in the bucket.
+func (ctrl *Controller) parseKodoObject(object string) (string, bool) {
+	u, err := url.Parse(object)
+	if err != nil || u.Scheme != "kodo" || u.Host != ctrl.kodo.bucket {
+		return "", false
+	}
+	return strings.TrimPrefix(u.Path, "/"), true
+}
+
+// probeFile probes metadata of the object with given universal URL. Only the
+// header of an image is downloaded to find its dimensions.
+func (ctrl *Controller) probeFile(ctx context.Context, object string) (model.FileMeta, error) {
+	key, ok := ctrl.parseKodoObject(object)
+	if !ok {
+		return model.FileMeta{}, fmt.Errorf("unrecognized object: %s", object)
+	}
+	info, err := ctrl.bucketManager.Stat(ctrl.kodo.bucket, key)
+	if err != nil {
+		return model.FileMeta{}, fmt.Errorf("failed to stat object %s: %w", object, err)
+	}
+	meta := model.FileMeta{
+		Size:        info.Fsize,
+		ContentType: info.MimeType,
+		Hash:        info.Hash,
+	}
+	if !strings.HasPrefix(meta.ContentType, "image/") {
+		return meta, nil
+	}
+
+	fileURLs, err := ctrl.MakeFileURLs(ctx, &MakeFileURLsParams{Objects: []string{object}})
+	if err != nil {
+		return model.FileMeta{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURLs.ObjectURLs[object], nil)
+	if err != nil {
+		return model.FileMeta{}, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", maxImageHeaderSize-1))
+	resp, err := ctrl.httpClient.Do(req)
+	if err != nil {
+		return model.FileMeta{}, fmt.Errorf("failed to get object %s: %w", object, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return model.FileMeta{}, fmt.Errorf("failed to get object %s: unexpected status %s", object, resp.Status)
+	}
+	config, _, err := image.DecodeConfig(io.LimitReader(resp.Body, maxImageHeaderSize))
+	if err != nil {
+		// Formats without a registered decoder, e.g., SVG, keep size and
+		// content type only.
+		return meta, nil
+	}
+	meta.Width, meta.Height = config.Width, config.Height
+	return meta, nil
+}
+
+// probeFiles probes metadata of the given files. It returns nil if any of the
+// files fails to be probed, or if probing takes longer than
+// [filesProbeTimeout], so the metadata can be backfilled later.
+func (ctrl *Controller) probeFiles(ctx context.Context, files model.FileCollection) model.FileMetaCollection {
+	logger := log.GetReqLogger(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, filesProbeTimeout)
+	defer cancel()
+
+	filesMeta := make(model.FileMetaCollection, len(files))
+	for path, object := range files {
+		if err := ctx.Err(); err != nil {
+			logger.Printf("failed to probe file %q: %v", path, err)
+			return nil
+		}
+		meta, err := ctrl.probeFile(ctx, object)
+		if err != nil {
+			logger.Printf("failed to probe file %q: %v", path, err)
+			return nil
+		}
+		filesMeta[path] = meta
+	}
+	return filesMeta
+}
+
+// BackfillAssetFilesMeta probes metadata of files of assets that have not been
+// probed yet. Assets whose files fail to be probed are skipped and keep no
+// metadata, so they are simply retried by the next run.
+func (ctrl *Controller) BackfillAssetFilesMeta(ctx context.Context) error {
+	logger := log.GetReqLogger(ctx)
+
+	var (
+		cursor   string
+		n, nFail int
+	)
+	for {
+		assets, err := model.ListAssetsWithoutFilesMeta(ctx, ctrl.db, cursor, filesMetaBackfillBatchSize)
+		if err != nil {
+			logger.Printf("failed to list assets without files meta: %v", err)
+			return err
+		}
+		for _, asset := range assets {
+			cursor = asset.ID
+			filesMeta := ctrl.probeFiles(ctx, asset.Files)
+			if filesMeta == nil {
+				nFail++
+				continue
+			}
+			if err := model.UpdateAssetFilesMetaByID(ctx, ctrl.db, asset.ID, filesMeta); err != nil {
+				logger.Printf("failed to update asset files meta: %v", err)
+				return err
+			}
+			n++
+		}
+		if len(assets) < filesMetaBackfillBatchSize {
+			break
+		}
+	}
+	logger.Printf("backfilled files meta of %d assets, %d assets failed", n, nFail)
+	return nil
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	qiniuStorage "github.com/qiniu/go-sdk/v7/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFileServer starts a server serving the given files by path, and
+// points the kodo base URL of ctrl at it. Requests without a Range header fail.
+func newTestFileServer(t *testing.T, ctrl *Controller, files map[string][]byte) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") == "" {
+			http.Error(w, "missing range", http.StatusBadRequest)
+			return
+		}
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+	ctrl.kodo.baseUrl = server.URL
+}
+
+func newTestPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestControllerProbeFile(t *testing.T) {
+	t.Run("Image", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/backdrop.png": {Fsize: 1024, MimeType: "image/png"},
+		}}
+		newTestFileServer(t, ctrl, map[string][]byte{"/files/backdrop.png": newTestPNG(t, 480, 360)})
+
+		meta, err := ctrl.probeFile(context.Background(), "kodo://builder/files/backdrop.png")
+		require.NoError(t, err)
+		assert.Equal(t, model.FileMeta{Size: 1024, ContentType: "image/png", Width: 480, Height: 360}, meta)
+	})
+
+	t.Run("NonImage", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/sound.wav": {Fsize: 2048, MimeType: "audio/wav"},
+		}}
+
+		meta, err := ctrl.probeFile(context.Background(), "kodo://builder/files/sound.wav")
+		require.NoError(t, err)
+		assert.Equal(t, model.FileMeta{Size: 2048, ContentType: "audio/wav"}, meta)
+	})
+
+	t.Run("UndecodableImage", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/sprite.svg": {Fsize: 512, MimeType: "image/svg+xml"},
+		}}
+		newTestFileServer(t, ctrl, map[string][]byte{"/files/sprite.svg": []byte("<svg/>")})
+
+		meta, err := ctrl.probeFile(context.Background(), "kodo://builder/files/sprite.svg")
+		require.NoError(t, err)
+		assert.Equal(t, model.FileMeta{Size: 512, ContentType: "image/svg+xml"}, meta)
+	})
+
+	t.Run("UnrecognizedObject", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.probeFile(context.Background(), "kodo://another-bucket/files/backdrop.png")
+		require.Error(t, err)
+	})
+
+	t.Run("StatFailed", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.probeFile(context.Background(), "kodo://builder/files/missing.png")
+		require.Error(t, err)
+	})
+
+	t.Run("GetFailed", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/backdrop.png": {Fsize: 1024, MimeType: "image/png"},
+		}}
+		newTestFileServer(t, ctrl, nil)
+
+		_, err = ctrl.probeFile(context.Background(), "kodo://builder/files/backdrop.png")
+		require.Error(t, err)
+	})
+}
+
+func TestControllerProbeFiles(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/a.wav": {Fsize: 1, MimeType: "audio/wav"},
+		}}
+
+		filesMeta := ctrl.probeFiles(context.Background(), model.FileCollection{"a.wav": "kodo://builder/files/a.wav"})
+		assert.Equal(t, model.FileMetaCollection{"a.wav": {Size: 1, ContentType: "audio/wav"}}, filesMeta)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		filesMeta := ctrl.probeFiles(context.Background(), model.FileCollection{})
+		require.NotNil(t, filesMeta)
+		assert.Empty(t, filesMeta)
+	})
+
+	t.Run("ProbeFailed", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		filesMeta := ctrl.probeFiles(context.Background(), model.FileCollection{"a.wav": "kodo://builder/files/a.wav"})
+		assert.Nil(t, filesMeta)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/a.wav": {Fsize: 1, MimeType: "audio/wav"},
+		}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+		filesMeta := ctrl.probeFiles(ctx, model.FileCollection{"a.wav": "kodo://builder/files/a.wav"})
+		assert.Nil(t, filesMeta)
+	})
+}
+
+func TestControllerBackfillAssetFilesMeta(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/a.wav": {Fsize: 1, MimeType: "audio/wav"},
+		}}
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND files_meta IS NULL AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", model.StatusDeleted, filesMetaBackfillBatchSize).
+			WillReturnRows(mock.NewRows([]string{"id", "files"}).
+				AddRow("1", []byte(`{"a.wav":"kodo://builder/files/a.wav"}`)).
+				AddRow("2", []byte(`{"b.wav":"kodo://builder/files/missing.wav"}`)))
+		mock.ExpectExec(`UPDATE asset SET files_meta = \? WHERE id = \?`).
+			WithArgs([]byte(`{"a.wav":{"size":1,"contentType":"audio/wav"}}`), "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		// Asset 2 fails to be probed and keeps no metadata, so it is retried by
+		// the next run.
+		err = ctrl.BackfillAssetFilesMeta(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND files_meta IS NULL AND status != \? ORDER BY id ASC LIMIT \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = ctrl.BackfillAssetFilesMeta(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	qiniuClient "github.com/qiniu/go-sdk/v7/client"
+)
+
+// assetGCBatchSize is the number of deleted assets listed per batch when
+// collecting them.
+const assetGCBatchSize = 100
+
+// deleteObject deletes the object with given storage key. An object that is
+// already gone counts as deleted.
+func (ctrl *Controller) deleteObject(key string) error {
+	err := ctrl.bucketManager.Delete(ctrl.kodo.bucket, key)
+	var errInfo *qiniuClient.ErrorInfo
+	if errors.As(err, &errInfo) && errInfo.Code == kodoNoSuchFileCode {
+		return nil
+	}
+	return err
+}
+
+// collectAsset deletes the storage objects of deleted asset that are no longer
+// referenced elsewhere, including its preview and those of its versions, and
+// only then removes the asset itself. The asset is left in place for the next
+// run if any of its objects fails to be deleted. It returns the number of
+// objects deleted.
+func (ctrl *Controller) collectAsset(ctx context.Context, asset *model.Asset) (int, error) {
+	logger := log.GetReqLogger(ctx)
+
+	versionObjects, err := model.ListAssetVersionObjects(ctx, ctrl.db, asset.ID)
+	if err != nil {
+		logger.Printf("failed to list asset version objects: %v", err)
+		return 0, err
+	}
+	objects := make(map[string]struct{})
+	for _, object := range versionObjects {
+		objects[object] = struct{}{}
+	}
+	for _, object := range asset.Files {
+		objects[object] = struct{}{}
+	}
+	if asset.Preview != "" {
+		objects[asset.Preview] = struct{}{}
+	}
+
+	var (
+		n      int
+		errObj error
+	)
+	for object := range objects {
+		key, ok := ctrl.parseKodoObject(object)
+		if !ok {
+			continue
+		}
+		deleted, err := model.DeleteObjectIfUnreferenced(ctx, ctrl.db, object, asset.ID, func() error {
+			return ctrl.deleteObject(key)
+		})
+		if err != nil {
+			logger.Printf("failed to delete object %s: %v", object, err)
+			errObj = err
+			continue
+		}
+		if deleted {
+			n++
+		}
+	}
+	if errObj != nil {
+		return n, errObj
+	}
+
+	if err := model.PurgeAssetByID(ctx, ctrl.db, asset.ID); err != nil {
+		logger.Printf("failed to purge asset: %v", err)
+		return n, err
+	}
+	return n, nil
+}
+
+// CollectDeletedAssets permanently removes assets deleted longer than the
+// retention period ago, together with their storage objects. Objects still
+// referenced by other assets or projects are kept.
+//
+// Failures are logged and the affected assets are skipped, so they are simply
+// retried by the next run.
+func (ctrl *Controller) CollectDeletedAssets(ctx context.Context) error {
+	logger := log.GetReqLogger(ctx)
+
+	before := time.Now().UTC().Add(-ctrl.assetGCRetention)
+	var (
+		cursor                 string
+		nAssets, nObjs, nFails int
+	)
+	for {
+		assets, err := model.ListDeletedAssetsBefore(ctx, ctrl.db, before, cursor, assetGCBatchSize)
+		if err != nil {
+			logger.Printf("failed to list deleted assets: %v", err)
+			return err
+		}
+		for _, asset := range assets {
+			n, err := ctrl.collectAsset(ctx, &asset)
+			nObjs += n
+			if err != nil {
+				logger.Printf("failed to collect asset %s: %v", asset.ID, err)
+				nFails++
+			} else {
+				nAssets++
+			}
+			cursor = asset.ID
+		}
+		if len(assets) < assetGCBatchSize {
+			break
+		}
+	}
+	logger.Printf("collected %d deleted assets and %d objects, %d assets failed", nAssets, nObjs, nFails)
+	return nil
+}
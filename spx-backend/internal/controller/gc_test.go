@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	qiniuClient "github.com/qiniu/go-sdk/v7/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const referencedQuery = `SELECT EXISTS \(SELECT 1 FROM asset WHERE id != \? AND \? MEMBER OF`
+
+// expectObjectReference sets up expectations for checking references to
+// object before it is deleted. Since the check and the deletion run in a
+// transaction, it ends with a commit, or a rollback if deleteFails.
+func expectObjectReference(mock sqlmock.Sqlmock, object string, referenced, deleteFails bool) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(referencedQuery).
+		WithArgs("1", object, "1", object, "1", object, "1", object, object).
+		WillReturnRows(mock.NewRows([]string{"referenced"}).AddRow(referenced))
+	if deleteFails {
+		mock.ExpectRollback()
+	} else {
+		mock.ExpectCommit()
+	}
+}
+
+// expectPurgeAsset sets up expectations for purging asset with given id.
+func expectPurgeAsset(mock sqlmock.Sqlmock, id string) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM asset WHERE id = \? AND status = \?`).
+		WithArgs(id, model.StatusDeleted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < 9; i++ {
+		mock.ExpectExec(`DELETE FROM asset_.+ WHERE asset_id = \?`).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectCommit()
+}
+
+func TestControllerCollectAsset(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		bm := &fakeBucketManager{}
+		ctrl.bucketManager = bm
+
+		asset := &model.Asset{
+			ID: "1",
+			Files: model.FileCollection{
+				"a.wav":   "kodo://builder/files/a.wav",
+				"b.png":   "kodo://builder/files/b.png",
+				"ext.png": "https://example.com/ext.png",
+			},
+			Preview: "kodo://builder/previews/a.png",
+		}
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \?`).
+			WithArgs("1").
+			WillReturnRows(mock.NewRows([]string{"id", "files", "preview"}).
+				AddRow(1, []byte(`{"a.wav":"kodo://builder/files/a-old.wav"}`), "kodo://builder/previews/a-old.png"))
+		mock.MatchExpectationsInOrder(false)
+		expectObjectReference(mock, "kodo://builder/files/a.wav", false, false)
+		expectObjectReference(mock, "kodo://builder/files/a-old.wav", false, false)
+		expectObjectReference(mock, "kodo://builder/files/b.png", true, false)
+		expectObjectReference(mock, "kodo://builder/previews/a.png", false, false)
+		expectObjectReference(mock, "kodo://builder/previews/a-old.png", false, false)
+		expectPurgeAsset(mock, "1")
+
+		n, err := ctrl.collectAsset(context.Background(), asset)
+		require.NoError(t, err)
+		assert.Equal(t, 4, n)
+		assert.ElementsMatch(t, []string{"files/a.wav", "files/a-old.wav", "previews/a.png", "previews/a-old.png"}, bm.deletes)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyGone", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{deleteErrs: map[string]error{
+			"files/a.wav": &qiniuClient.ErrorInfo{Code: kodoNoSuchFileCode},
+		}}
+
+		asset := &model.Asset{ID: "1", Files: model.FileCollection{"a.wav": "kodo://builder/files/a.wav"}}
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		expectObjectReference(mock, "kodo://builder/files/a.wav", false, false)
+		expectPurgeAsset(mock, "1")
+
+		n, err := ctrl.collectAsset(context.Background(), asset)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteFailed", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		bm := &fakeBucketManager{deleteErrs: map[string]error{
+			"files/a.wav": errors.New("fake error"),
+		}}
+		ctrl.bucketManager = bm
+
+		asset := &model.Asset{ID: "1", Files: model.FileCollection{
+			"a.wav": "kodo://builder/files/a.wav",
+			"b.png": "kodo://builder/files/b.png",
+		}}
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.MatchExpectationsInOrder(false)
+		expectObjectReference(mock, "kodo://builder/files/a.wav", false, true)
+		expectObjectReference(mock, "kodo://builder/files/b.png", false, false)
+
+		n, err := ctrl.collectAsset(context.Background(), asset)
+		require.Error(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"files/b.png"}, bm.deletes)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestControllerCollectDeletedAssets(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		bm := &fakeBucketManager{deleteErrs: map[string]error{
+			"files/a.wav": errors.New("fake error"),
+		}}
+		ctrl.bucketManager = bm
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status = \? AND deleted_at < \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", model.StatusDeleted, sqlmock.AnyArg(), assetGCBatchSize).
+			WillReturnRows(mock.NewRows([]string{"id", "files"}).
+				AddRow(1, []byte(`{"a.wav":"kodo://builder/files/a.wav"}`)).
+				AddRow(2, []byte(`{"b.wav":"kodo://builder/files/b.wav"}`)))
+
+		// Asset 1 is kept since its object fails to be deleted.
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \?`).
+			WithArgs("1").
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectQuery(referencedQuery).
+			WillReturnRows(mock.NewRows([]string{"referenced"}).AddRow(false))
+		mock.ExpectRollback()
+
+		// Asset 2 is collected regardless.
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \?`).
+			WithArgs("2").
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectQuery(referencedQuery).
+			WillReturnRows(mock.NewRows([]string{"referenced"}).AddRow(false))
+		mock.ExpectCommit()
+		expectPurgeAsset(mock, "2")
+
+		err = ctrl.CollectDeletedAssets(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"files/b.wav"}, bm.deletes)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		t.Setenv("ASSET_GC_RETENTION", "24h")
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		assert.Equal(t, assetRestoreWindow, ctrl.assetGCRetention)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status = \? AND deleted_at < \?`).
+			WithArgs("0", model.StatusDeleted, sqlmock.AnyArg(), assetGCBatchSize).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		err = ctrl.CollectDeletedAssets(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status = \? AND deleted_at < \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = ctrl.CollectDeletedAssets(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// moderationNoteRE is the regular expression for moderation note.
+var moderationNoteRE = regexp.MustCompile(`^(?s).{0,1000}$`)
+
+// ListModerationQueueParams holds parameters for listing the moderation
+// queue.
+type ListModerationQueueParams struct {
+	// ModerationStatus is the moderation status filter, applied only if
+	// non-nil.
+	ModerationStatus *model.ModerationStatus
+
+	// Pagination is the pagination information.
+	Pagination model.Pagination
+}
+
+// Validate validates the parameters.
+func (p *ListModerationQueueParams) Validate() (ok bool, msg string) {
+	if p.ModerationStatus != nil {
+		switch *p.ModerationStatus {
+		case model.ModerationVisible, model.ModerationPending, model.ModerationRejected, model.ModerationBroken, model.ModerationDraft:
+		default:
+			return false, "invalid moderationStatus"
+		}
+	}
+	return true, ""
+}
+
+// ModerationQueueItem is an asset in the moderation queue.
+type ModerationQueueItem struct {
+	// Asset is the asset to review.
+	Asset model.Asset `json:"asset"`
+
+	// OpenReports is the number of open reports of the asset by reason.
+	OpenReports map[model.AssetReportReason]int `json:"openReports"`
+}
+
+// ListModerationQueue lists assets with open reports, oldest first, together
+// with a summary of the reports. Only admins are allowed.
+func (ctrl *Controller) ListModerationQueue(ctx context.Context, params *ListModerationQueueParams) (*model.ByPage[ModerationQueueItem], error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	wheres := []model.FilterCondition{model.OpenAssetReportsFilter()}
+	if params.ModerationStatus != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "moderation_status", Operation: "=", Value: *params.ModerationStatus})
+	}
+	assets, err := model.ListAssets(ctx, ctrl.db, params.Pagination, wheres, nil)
+	if err != nil {
+		logger.Printf("failed to list assets: %v", err)
+		return nil, err
+	}
+
+	assetIDs := make([]string, 0, len(assets.Data))
+	for _, asset := range assets.Data {
+		assetIDs = append(assetIDs, asset.ID)
+	}
+	reportCounts, err := model.ListOpenAssetReportCounts(ctx, ctrl.db, assetIDs)
+	if err != nil {
+		logger.Printf("failed to list open asset report counts: %v", err)
+		return nil, err
+	}
+	openReports := make(map[string]map[model.AssetReportReason]int, len(assets.Data))
+	for _, reportCount := range reportCounts {
+		if openReports[reportCount.AssetID] == nil {
+			openReports[reportCount.AssetID] = make(map[model.AssetReportReason]int)
+		}
+		openReports[reportCount.AssetID][reportCount.Reason] = reportCount.Count
+	}
+
+	items := make([]ModerationQueueItem, 0, len(assets.Data))
+	for _, asset := range assets.Data {
+		item := ModerationQueueItem{Asset: asset, OpenReports: openReports[asset.ID]}
+		if item.OpenReports == nil {
+			item.OpenReports = map[model.AssetReportReason]int{}
+		}
+		items = append(items, item)
+	}
+	return &model.ByPage[ModerationQueueItem]{
+		Total: assets.Total,
+		Data:  items,
+	}, nil
+}
+
+// ModerateAssetParams holds parameters for moderating an asset.
+type ModerateAssetParams struct {
+	Decision model.ModerationDecision `json:"decision"`
+	Note     string                   `json:"note"`
+}
+
+// Validate validates the parameters.
+func (p *ModerateAssetParams) Validate() (ok bool, msg string) {
+	switch p.Decision {
+	case model.ModerationApprove, model.ModerationReject:
+	default:
+		return false, "invalid decision"
+	}
+	if !moderationNoteRE.MatchString(p.Note) {
+		return false, "invalid note"
+	}
+	return true, ""
+}
+
+// ModerateAsset applies a moderation decision to asset with given id as the
+// admin in the context, and returns the audit record of the decision.
+//
+// Approved assets are visible in public listings again. Rejected assets are
+// removed from public listings and can no longer be resolved by anyone but
+// their owners. Drafts cannot be moderated until their owners submit them.
+func (ctrl *Controller) ModerateAsset(ctx context.Context, id string, params *ModerateAssetParams) (*model.AssetModeration, error) {
+	logger := log.GetReqLogger(ctx)
+
+	admin, err := EnsureAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	moderation, err := model.ModerateAsset(ctx, ctrl.db, &model.AssetModeration{
+		AssetID:   id,
+		Moderator: admin.Name,
+		Decision:  params.Decision,
+		Note:      params.Note,
+	})
+	if err != nil {
+		logger.Printf("failed to moderate asset: %v", err)
+		return nil, err
+	}
+	return moderation, nil
+}
+
+// SubmitAssetForReview submits asset with given id for review, so it can
+// appear in public listings once approved. Only the owner is allowed. Drafts
+// and rejected assets can be submitted.
+func (ctrl *Controller) SubmitAssetForReview(ctx context.Context, id string, owner string) (*model.AssetModeration, error) {
+	logger := log.GetReqLogger(ctx)
+
+	user, err := EnsureUser(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := ctrl.ensureAsset(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	moderation, err := model.SubmitAssetForReview(ctx, ctrl.db, &model.AssetModeration{
+		AssetID:   asset.ID,
+		Moderator: user.Name,
+		Decision:  model.ModerationSubmit,
+	})
+	if err != nil {
+		logger.Printf("failed to submit asset for review: %v", err)
+		return nil, err
+	}
+	return moderation, nil
+}
+
+// ReviewAsset approves or rejects asset with given id pending review as the
+// admin in the context, and returns the audit record of the decision. Unlike
+// [Controller.ModerateAsset], assets not pending review, e.g., drafts that
+// were never submitted, cannot be reviewed.
+func (ctrl *Controller) ReviewAsset(ctx context.Context, id string, decision model.ModerationDecision, note string) (*model.AssetModeration, error) {
+	logger := log.GetReqLogger(ctx)
+
+	admin, err := EnsureAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	moderation, err := model.ReviewAsset(ctx, ctrl.db, &model.AssetModeration{
+		AssetID:   id,
+		Moderator: admin.Name,
+		Decision:  decision,
+		Note:      note,
+	})
+	if err != nil {
+		logger.Printf("failed to review asset: %v", err)
+		return nil, err
+	}
+	return moderation, nil
+}
+
+// ListReviewQueue lists assets pending review, longest waiting first. Only
+// admins are allowed.
+func (ctrl *Controller) ListReviewQueue(ctx context.Context, pagination model.Pagination) (*model.ByPage[model.Asset], error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	wheres := []model.FilterCondition{
+		{Column: "moderation_status", Operation: "=", Value: model.ModerationPending},
+	}
+	orders := []model.OrderByCondition{
+		{Column: "u_time", Direction: "ASC"},
+		{Column: "id", Direction: "ASC"},
+	}
+	assets, err := model.ListAssets(ctx, ctrl.db, pagination, wheres, orders)
+	if err != nil {
+		logger.Printf("failed to list assets: %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
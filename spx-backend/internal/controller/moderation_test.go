@@ -0,0 +1,416 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListModerationQueueParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		moderationStatus := model.ModerationPending
+		params := &ListModerationQueueParams{ModerationStatus: &moderationStatus}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidModerationStatus", func(t *testing.T) {
+		moderationStatus := model.ModerationStatus(-1)
+		params := &ListModerationQueueParams{ModerationStatus: &moderationStatus}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid moderationStatus", msg)
+	})
+}
+
+func TestControllerListModerationQueue(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		moderationStatus := model.ModerationPending
+		params := &ListModerationQueueParams{
+			ModerationStatus: &moderationStatus,
+			Pagination:       model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE id IN \(SELECT asset_id FROM asset_report WHERE state = \? AND status != \?\) AND moderation_status = \? AND status != \?`).
+			WithArgs(model.AssetReportOpen, model.StatusDeleted, model.ModerationPending, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(2))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(SELECT asset_id FROM asset_report WHERE state = \? AND status != \?\) AND moderation_status = \? AND status != \? ORDER BY id ASC LIMIT \?, \?`).
+			WithArgs(model.AssetReportOpen, model.StatusDeleted, model.ModerationPending, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name").
+				AddRow(2, "another-fake-asset", "fake-name"))
+		mock.ExpectQuery(`SELECT asset_id, reason, COUNT\(\*\) AS count FROM asset_report WHERE asset_id IN \(\?,\?\)`).
+			WithArgs("1", "2", model.AssetReportOpen, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"asset_id", "reason", "count"}).
+				AddRow(1, model.AssetReportSpam, 3).
+				AddRow(1, model.AssetReportOther, 2))
+		queue, err := ctrl.ListModerationQueue(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, queue)
+		assert.Equal(t, 2, queue.Total)
+		require.Len(t, queue.Data, 2)
+		assert.Equal(t, "1", queue.Data[0].Asset.ID)
+		assert.Equal(t, map[model.AssetReportReason]int{model.AssetReportSpam: 3, model.AssetReportOther: 2}, queue.Data[0].OpenReports)
+		assert.Empty(t, queue.Data[1].OpenReports)
+		assert.NotNil(t, queue.Data[1].OpenReports)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.ListModerationQueue(context.Background(), &ListModerationQueueParams{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.ListModerationQueue(ctx, &ListModerationQueueParams{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedConnForReportCountQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset`).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`SELECT asset_id, reason, COUNT\(\*\) AS count FROM asset_report`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.ListModerationQueue(ctx, &ListModerationQueueParams{Pagination: model.Pagination{Index: 1, Size: 10}})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestModerateAssetParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &ModerateAssetParams{Decision: model.ModerationReject, Note: "fake-note"}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidDecision", func(t *testing.T) {
+		params := &ModerateAssetParams{Decision: "invalid"}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid decision", msg)
+	})
+
+	t.Run("InvalidNote", func(t *testing.T) {
+		params := &ModerateAssetParams{Decision: model.ModerationApprove, Note: strings.Repeat("a", 1001)}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid note", msg)
+	})
+}
+
+func TestControllerModerateAsset(t *testing.T) {
+	params := &ModerateAssetParams{Decision: model.ModerationReject, Note: "fake-note"}
+
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationVisible))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(model.ModerationRejected, "1", model.StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), model.AssetReportResolved, "1", model.AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision", "note"}).
+				AddRow(1, 1, "fake-name", model.ModerationReject, "fake-note"))
+		mock.ExpectCommit()
+		moderation, err := ctrl.ModerateAsset(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, "fake-name", moderation.Moderator)
+		assert.Equal(t, model.ModerationReject, moderation.Decision)
+	})
+
+	t.Run("ApproveVisible", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationVisible))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(model.ModerationVisible, "1", model.StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), model.AssetReportDismissed, "1", model.AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_moderation \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision", "note"}).
+				AddRow(1, 1, "fake-name", model.ModerationApprove, "fake-note"))
+		mock.ExpectCommit()
+		moderation, err := ctrl.ModerateAsset(ctx, "1", &ModerateAssetParams{Decision: model.ModerationApprove, Note: "fake-note"})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, model.ModerationApprove, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RejectRejected", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationRejected))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(model.ModerationRejected, "1", model.StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), model.AssetReportResolved, "1", model.AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_moderation \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision", "note"}).
+				AddRow(1, 1, "fake-name", model.ModerationReject, "fake-note"))
+		mock.ExpectCommit()
+		moderation, err := ctrl.ModerateAsset(ctx, "1", &ModerateAssetParams{Decision: model.ModerationReject, Note: "fake-note"})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, model.ModerationReject, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.ModerateAsset(context.Background(), "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.ModerateAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}))
+		mock.ExpectRollback()
+		_, err = ctrl.ModerateAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("Draft", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationDraft))
+		mock.ExpectRollback()
+		_, err = ctrl.ModerateAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrInvalidTransition)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestControllerSubmitAssetForReview(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "moderation_status"}).
+				AddRow(1, "fake-name", model.ModerationDraft))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationDraft))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \?`).
+			WithArgs(model.ModerationPending, "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \?`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision"}).
+				AddRow(1, 1, "fake-name", model.ModerationSubmit))
+		mock.ExpectCommit()
+		moderation, err := ctrl.SubmitAssetForReview(ctx, "1", "fake-name")
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, "fake-name", moderation.Moderator)
+		assert.Equal(t, model.ModerationSubmit, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.SubmitAssetForReview(ctx, "1", "another-fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "is_public"}).
+				AddRow(1, "another-fake-name", model.Public))
+		_, err = ctrl.SubmitAssetForReview(ctx, "1", "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("AlreadySubmitted", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "moderation_status"}).
+				AddRow(1, "fake-name", model.ModerationPending))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationPending))
+		mock.ExpectRollback()
+		_, err = ctrl.SubmitAssetForReview(ctx, "1", "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrInvalidTransition)
+	})
+}
+
+func TestControllerReviewAsset(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationPending))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(model.ModerationRejected, "1", model.StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \?`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision", "note"}).
+				AddRow(1, 1, "fake-name", model.ModerationReject, "fake-note"))
+		mock.ExpectCommit()
+		moderation, err := ctrl.ReviewAsset(ctx, "1", model.ModerationReject, "fake-note")
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, model.ModerationReject, moderation.Decision)
+		assert.Equal(t, "fake-note", moderation.Note)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.ReviewAsset(ctx, "1", model.ModerationApprove, "")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NeverSubmitted", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(model.ModerationDraft))
+		mock.ExpectRollback()
+		_, err = ctrl.ReviewAsset(ctx, "1", model.ModerationApprove, "")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrInvalidTransition)
+	})
+}
+
+func TestControllerListReviewQueue(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE moderation_status = \? AND status != \?`).
+			WithArgs(model.ModerationPending, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE moderation_status = \? AND status != \? ORDER BY u_time ASC, id ASC LIMIT \?, \?`).
+			WithArgs(model.ModerationPending, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "moderation_status"}).
+				AddRow(1, model.ModerationPending))
+		assets, err := ctrl.ListReviewQueue(ctx, model.Pagination{Index: 1, Size: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, assets.Total)
+		require.Len(t, assets.Data, 1)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.ListReviewQueue(ctx, model.Pagination{Index: 1, Size: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+}
@@ -0,0 +1,472 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// Dimensions of rendered previews in pixels.
+const (
+	previewCardSize    = 256 // width and height of sprite cards
+	previewCardPadding = 16  // space kept around the costume on sprite cards
+	waveformWidth      = 512
+	waveformHeight     = 128
+)
+
+// maxPreviewSourceSize is the maximum size of a file a preview is rendered
+// from.
+const maxPreviewSourceSize = 10 << 20 // 10 MiB
+
+// previewRenderBatchSize is the number of assets listed per batch when
+// rendering missing previews.
+const previewRenderBatchSize = 100
+
+// maxConcurrentPreviewRenders is the maximum number of previews rendered in
+// the background at the same time.
+const maxConcurrentPreviewRenders = 4
+
+// previewRenderTimeout is the maximum time spent rendering a single preview.
+const previewRenderTimeout = 2 * time.Minute
+
+// maxPreviewAttempts is the maximum number of failed attempts to render the
+// preview of an asset before it is given up.
+const maxPreviewAttempts = 8
+
+// assetConfigFileName is the name of the config file of a sprite, backdrop or
+// sound among files of an asset.
+const assetConfigFileName = "index.json"
+
+// errUnrenderablePreview is returned for assets whose previews can never be
+// rendered from their files, e.g., of formats without a decoder. Such assets
+// are not retried.
+var errUnrenderablePreview = errors.New("preview cannot be rendered")
+
+var (
+	previewBackground = color.RGBA{0xf6, 0xf8, 0xfa, 0xff}
+	waveformColor     = color.RGBA{0x0b, 0xc0, 0xcf, 0xff}
+)
+
+// assetConfig is the part of the config file of a sprite, backdrop or sound
+// needed to render its preview. Paths are relative to the directory of the
+// config file.
+type assetConfig struct {
+	// Costumes is the costumes of a sprite.
+	Costumes []struct {
+		Path string `json:"path"`
+	} `json:"costumes"`
+
+	// Path is the path of the audio file of a sound, or of the image of a
+	// backdrop.
+	Path string `json:"path"`
+}
+
+// readObject downloads the object with given universal URL. Objects larger
+// than [maxPreviewSourceSize] are rejected.
+func (ctrl *Controller) readObject(ctx context.Context, object string) ([]byte, error) {
+	fileURLs, err := ctrl.MakeFileURLs(ctx, &MakeFileURLsParams{Objects: []string{object}})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURLs.ObjectURLs[object], nil)
+	if err != nil {
+		return nil, err
+	}
+	// One extra byte is asked for to tell objects that are too large.
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", maxPreviewSourceSize))
+	resp, err := ctrl.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", object, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("failed to get object %s: unexpected status %s", object, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPreviewSourceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", object, err)
+	}
+	if len(data) > maxPreviewSourceSize {
+		return nil, fmt.Errorf("%w: object %s is too large", errUnrenderablePreview, object)
+	}
+	return data, nil
+}
+
+// readAssetConfig reads the config file among files of a sprite, backdrop or
+// sound asset, and returns it with the directory its paths are relative to.
+func (ctrl *Controller) readAssetConfig(ctx context.Context, files model.FileCollection) (*assetConfig, string, error) {
+	// Pick the first config file in path order, so the choice is stable.
+	var configPaths []string
+	for p := range files {
+		if path.Base(p) == assetConfigFileName {
+			configPaths = append(configPaths, p)
+		}
+	}
+	if len(configPaths) == 0 {
+		return nil, "", fmt.Errorf("%w: missing config file", errUnrenderablePreview)
+	}
+	sort.Strings(configPaths)
+
+	data, err := ctrl.readObject(ctx, files[configPaths[0]])
+	if err != nil {
+		return nil, "", err
+	}
+	var config assetConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, "", fmt.Errorf("%w: failed to parse config file: %v", errUnrenderablePreview, err)
+	}
+	return &config, path.Dir(configPaths[0]), nil
+}
+
+// readAssetFile downloads the file at the given path relative to dir among
+// files of an asset.
+func (ctrl *Controller) readAssetFile(ctx context.Context, files model.FileCollection, dir, p string) ([]byte, error) {
+	object, ok := files[path.Join(dir, p)]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing file %q", errUnrenderablePreview, p)
+	}
+	return ctrl.readObject(ctx, object)
+}
+
+// previewImageObject returns the object of the image a preview of sprite or
+// backdrop asset is rendered from, i.e., the first costume of a sprite or the
+// image of a backdrop.
+func (ctrl *Controller) previewImageObject(ctx context.Context, asset *model.Asset) (string, error) {
+	config, dir, err := ctrl.readAssetConfig(ctx, asset.Files)
+	if err != nil {
+		return "", err
+	}
+	var p string
+	if asset.AssetType == model.AssetTypeSprite {
+		if len(config.Costumes) == 0 {
+			return "", fmt.Errorf("%w: no costumes", errUnrenderablePreview)
+		}
+		p = config.Costumes[0].Path
+	} else {
+		if config.Path == "" {
+			return "", fmt.Errorf("%w: missing image path", errUnrenderablePreview)
+		}
+		p = config.Path
+	}
+	object, ok := asset.Files[path.Join(dir, p)]
+	if !ok {
+		return "", fmt.Errorf("%w: missing file %q", errUnrenderablePreview, p)
+	}
+	return object, nil
+}
+
+// readPreviewImage downloads and decodes the image with given universal URL.
+func (ctrl *Controller) readPreviewImage(ctx context.Context, object string) (image.Image, error) {
+	data, err := ctrl.readObject(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		// Formats without a registered decoder, e.g., SVG, cannot be
+		// rendered.
+		return nil, fmt.Errorf("%w: failed to decode image: %v", errUnrenderablePreview, err)
+	}
+	if size := img.Bounds().Size(); size.X == 0 || size.Y == 0 {
+		return nil, fmt.Errorf("%w: empty image", errUnrenderablePreview)
+	}
+	return img, nil
+}
+
+// renderSpritePreview renders the first costume of a sprite onto a card, and
+// returns the card as PNG.
+func renderSpritePreview(costume image.Image) ([]byte, error) {
+	card := image.NewRGBA(image.Rect(0, 0, previewCardSize, previewCardSize))
+	draw.Draw(card, card.Bounds(), image.NewUniform(previewBackground), image.Point{}, draw.Src)
+
+	// The costume is scaled to fit within the padding, keeping its aspect
+	// ratio, and centered on the card.
+	size := costume.Bounds().Size()
+	inner := previewCardSize - 2*previewCardPadding
+	scale := min(float64(inner)/float64(size.X), float64(inner)/float64(size.Y))
+	w, h := max(int(float64(size.X)*scale), 1), max(int(float64(size.Y)*scale), 1)
+	origin := image.Pt((previewCardSize-w)/2, (previewCardSize-h)/2)
+	draw.Draw(card, image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}, scaleImage(costume, w, h), image.Point{}, draw.Over)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, card); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderBackdropPreview renders a thumbnail of the image of a backdrop, and
+// returns it as PNG. The thumbnail fits within [previewCardSize] keeping the
+// aspect ratio of the image. Smaller images are not scaled up.
+func renderBackdropPreview(backdrop image.Image) ([]byte, error) {
+	size := backdrop.Bounds().Size()
+	scale := min(float64(previewCardSize)/float64(size.X), float64(previewCardSize)/float64(size.Y), 1)
+	w, h := max(int(float64(size.X)*scale), 1), max(int(float64(size.Y)*scale), 1)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleImage(backdrop, w, h)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleImage scales src to w by h pixels with nearest-neighbor sampling.
+func scaleImage(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy := b.Min.Y + y*b.Dy()/h
+		for x := 0; x < w; x++ {
+			sx := b.Min.X + x*b.Dx()/w
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+	return dst
+}
+
+// renderSoundPreview renders the waveform of a sound, and returns it as PNG.
+func (ctrl *Controller) renderSoundPreview(ctx context.Context, files model.FileCollection) ([]byte, error) {
+	config, dir, err := ctrl.readAssetConfig(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	if config.Path == "" {
+		return nil, fmt.Errorf("%w: missing audio path", errUnrenderablePreview)
+	}
+	data, err := ctrl.readAssetFile(ctx, files, dir, config.Path)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := decodeWAV(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode audio: %v", errUnrenderablePreview, err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, waveformWidth, waveformHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(previewBackground), image.Point{}, draw.Src)
+
+	// Each column shows the peak amplitude of its share of the samples as a
+	// bar centered vertically. Silent columns still get a line of one pixel.
+	for x := 0; x < waveformWidth; x++ {
+		var peak float64
+		from, to := x*len(samples)/waveformWidth, (x+1)*len(samples)/waveformWidth
+		for _, s := range samples[from:to] {
+			if s < 0 {
+				s = -s
+			}
+			peak = max(peak, s)
+		}
+		h := max(int(peak*waveformHeight), 1)
+		top := (waveformHeight - h) / 2
+		draw.Draw(img, image.Rect(x, top, x+1, top+h), image.NewUniform(waveformColor), image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// errUnsupportedAudio is returned by [decodeWAV] for audio it cannot decode.
+var errUnsupportedAudio = errors.New("unsupported audio format")
+
+// decodeWAV decodes 8-bit or 16-bit PCM WAV data, and returns the samples of
+// its first channel scaled to [-1, 1].
+func decodeWAV(data []byte) ([]float64, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, errUnsupportedAudio
+	}
+	var (
+		channels, bitsPerSample int
+		pcm                     []byte
+	)
+	for chunks := data[12:]; len(chunks) >= 8; {
+		id, size := string(chunks[0:4]), int(binary.LittleEndian.Uint32(chunks[4:8]))
+		body := chunks[8:]
+		if size > len(body) {
+			size = len(body)
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 || binary.LittleEndian.Uint16(body[0:2]) != 1 { // 1 is PCM
+				return nil, errUnsupportedAudio
+			}
+			channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+		case "data":
+			pcm = body[:size]
+		}
+		// Chunks are padded to an even size.
+		next := size + size%2
+		if next > len(body) {
+			break
+		}
+		chunks = body[next:]
+	}
+	if channels == 0 || pcm == nil || (bitsPerSample != 8 && bitsPerSample != 16) {
+		return nil, errUnsupportedAudio
+	}
+
+	frameSize := channels * bitsPerSample / 8
+	samples := make([]float64, 0, len(pcm)/frameSize)
+	for i := 0; i+frameSize <= len(pcm); i += frameSize {
+		if bitsPerSample == 8 {
+			samples = append(samples, (float64(pcm[i])-128)/128)
+		} else {
+			samples = append(samples, float64(int16(binary.LittleEndian.Uint16(pcm[i:i+2])))/32768)
+		}
+	}
+	return samples, nil
+}
+
+// renderAssetPreview renders a preview of asset from its files, uploads it,
+// and sets it as the preview of the asset unless one has been set meanwhile.
+//
+// Sprites and backdrops whose image cannot be rendered get the original image
+// as their preview instead, e.g., SVG, which clients can show as it is.
+func (ctrl *Controller) renderAssetPreview(ctx context.Context, asset *model.Asset) error {
+	logger := log.GetReqLogger(ctx)
+
+	var (
+		data []byte
+		err  error
+	)
+	switch asset.AssetType {
+	case model.AssetTypeSprite, model.AssetTypeBackdrop:
+		var (
+			object string
+			img    image.Image
+		)
+		if object, err = ctrl.previewImageObject(ctx, asset); err != nil {
+			return err
+		}
+		if img, err = ctrl.readPreviewImage(ctx, object); errors.Is(err, errUnrenderablePreview) {
+			if err := model.UpdateAssetPreviewByID(ctx, ctrl.db, asset.ID, object); err != nil {
+				logger.Printf("failed to update asset preview: %v", err)
+				return err
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if asset.AssetType == model.AssetTypeSprite {
+			data, err = renderSpritePreview(img)
+		} else {
+			data, err = renderBackdropPreview(img)
+		}
+	case model.AssetTypeSound:
+		data, err = ctrl.renderSoundPreview(ctx, asset.Files)
+	default:
+		return fmt.Errorf("%w: no preview rendering for asset type %d", errUnrenderablePreview, asset.AssetType)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Previews are keyed by the hash of their content, like uploaded files.
+	key := fmt.Sprintf("previews/%x.png", sha256.Sum256(data))
+	if err := ctrl.uploader.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to upload preview: %w", err)
+	}
+	preview := (&url.URL{Scheme: "kodo", Host: ctrl.kodo.bucket, Path: "/" + key}).String()
+	if err := model.UpdateAssetPreviewByID(ctx, ctrl.db, asset.ID, preview); err != nil {
+		logger.Printf("failed to update asset preview: %v", err)
+		return err
+	}
+	return nil
+}
+
+// tryRenderAssetPreview is like [Controller.renderAssetPreview], but also
+// records a failure, so the asset is retried with a backoff, or never again if
+// its preview cannot be rendered at all.
+func (ctrl *Controller) tryRenderAssetPreview(ctx context.Context, asset *model.Asset) error {
+	logger := log.GetReqLogger(ctx)
+
+	renderCtx, cancel := context.WithTimeout(ctx, previewRenderTimeout)
+	defer cancel()
+	err := ctrl.renderAssetPreview(renderCtx, asset)
+	if err == nil {
+		return nil
+	}
+	permanent := errors.Is(err, errUnrenderablePreview)
+	if err := model.AddAssetPreviewFailure(ctx, ctrl.db, asset.ID, time.Now().UTC(), permanent); err != nil {
+		logger.Printf("failed to add asset preview failure: %v", err)
+	}
+	return err
+}
+
+// renderAssetPreviewAsync renders a preview of asset in the background. When
+// [maxConcurrentPreviewRenders] previews are already being rendered, it is
+// skipped. Skips and failures are only logged, since assets without a preview
+// are picked up by [Controller.RenderMissingPreviews] later.
+func (ctrl *Controller) renderAssetPreviewAsync(ctx context.Context, asset *model.Asset) {
+	logger := log.GetReqLogger(ctx)
+
+	select {
+	case ctrl.previewRenders <- struct{}{}:
+	default:
+		logger.Printf("too many previews being rendered, skipped asset %s", asset.ID)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() { <-ctrl.previewRenders }()
+		if err := ctrl.tryRenderAssetPreview(ctx, asset); err != nil {
+			logger.Printf("failed to render preview of asset %s: %v", asset.ID, err)
+		}
+	}()
+}
+
+// RenderMissingPreviews renders previews of sprite, backdrop and sound assets
+// that have none, e.g., assets added before previews were rendered, or whose
+// previews failed to be rendered when they were added or updated.
+//
+// Failures are logged and the affected assets are skipped. They are retried by
+// later runs with a backoff, unless their previews cannot be rendered at all.
+func (ctrl *Controller) RenderMissingPreviews(ctx context.Context) error {
+	logger := log.GetReqLogger(ctx)
+
+	var (
+		cursor   string
+		n, nFail int
+	)
+	for {
+		assets, err := model.ListAssetsWithoutPreview(ctx, ctrl.db, cursor, previewRenderBatchSize, maxPreviewAttempts, time.Now().UTC())
+		if err != nil {
+			logger.Printf("failed to list assets without preview: %v", err)
+			return err
+		}
+		for _, asset := range assets {
+			if err := ctrl.tryRenderAssetPreview(ctx, &asset); err != nil {
+				logger.Printf("failed to render preview of asset %s: %v", asset.ID, err)
+				nFail++
+			} else {
+				n++
+			}
+			cursor = asset.ID
+		}
+		if len(assets) < previewRenderBatchSize {
+			break
+		}
+	}
+	logger.Printf("rendered previews of %d assets, %d assets failed", n, nFail)
+	return nil
+}
@@ -0,0 +1,375 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestWAV encodes the given 16-bit samples as PCM WAV data with given
+// number of channels.
+func newTestWAV(t *testing.T, channels int, samples []int16) []byte {
+	var pcm bytes.Buffer
+	require.NoError(t, binary.Write(&pcm, binary.LittleEndian, samples))
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+pcm.Len()))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	for _, v := range []any{
+		uint32(16),                   // chunk size
+		uint16(1),                    // PCM
+		uint16(channels),             // channels
+		uint32(44100),                // sample rate
+		uint32(44100 * 2 * channels), // byte rate
+		uint16(2 * channels),         // block align
+		uint16(16),                   // bits per sample
+	} {
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, v))
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(pcm.Len()))
+	buf.Write(pcm.Bytes())
+	return buf.Bytes()
+}
+
+func decodeTestPNG(t *testing.T, data []byte) image.Image {
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img
+}
+
+func TestDecodeWAV(t *testing.T) {
+	t.Run("Mono", func(t *testing.T) {
+		samples, err := decodeWAV(newTestWAV(t, 1, []int16{0, 16384, -32768}))
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0, 0.5, -1}, samples)
+	})
+
+	t.Run("Stereo", func(t *testing.T) {
+		samples, err := decodeWAV(newTestWAV(t, 2, []int16{16384, 0, -16384, 0}))
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0.5, -0.5}, samples)
+	})
+
+	t.Run("NotWAV", func(t *testing.T) {
+		_, err := decodeWAV([]byte("ID3 fake mp3"))
+		assert.ErrorIs(t, err, errUnsupportedAudio)
+	})
+
+	t.Run("NotPCM", func(t *testing.T) {
+		data := newTestWAV(t, 1, []int16{0})
+		binary.LittleEndian.PutUint16(data[20:22], 3) // IEEE float
+		_, err := decodeWAV(data)
+		assert.ErrorIs(t, err, errUnsupportedAudio)
+	})
+}
+
+func TestControllerReadObject(t *testing.T) {
+	t.Run("Timeout", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.httpClient.Timeout = 10 * time.Millisecond
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		t.Cleanup(server.Close)
+		t.Cleanup(func() { close(release) })
+		ctrl.kodo.baseUrl = server.URL
+
+		_, err = ctrl.readObject(context.Background(), "kodo://builder/files/a.png")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errUnrenderablePreview)
+	})
+}
+
+func TestControllerRenderAssetPreviewAsync(t *testing.T) {
+	t.Run("TooManyRenders", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		for i := 0; i < maxConcurrentPreviewRenders; i++ {
+			ctrl.previewRenders <- struct{}{}
+		}
+
+		ctrl.renderAssetPreviewAsync(context.Background(), &model.Asset{ID: "1", AssetType: model.AssetTypeSprite})
+		assert.Len(t, ctrl.previewRenders, maxConcurrentPreviewRenders)
+		require.NoError(t, mock.ExpectationsWereMet())
+		assert.Empty(t, ctrl.uploader.(*fakeUploader).puts)
+	})
+}
+
+func TestControllerRenderAssetPreview(t *testing.T) {
+	t.Run("Sprite", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"costumes":[{"name":"a","path":"a.png"},{"name":"b","path":"b.png"}]}`),
+			"/files/a.png":      newTestPNG(t, 64, 32),
+		})
+
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WithArgs(sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeSprite,
+			Files: model.FileCollection{
+				"assets/sprites/fake/index.json": "kodo://builder/files/index.json",
+				"assets/sprites/fake/a.png":      "kodo://builder/files/a.png",
+				"assets/sprites/fake/b.png":      "kodo://builder/files/b.png",
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+
+		puts := ctrl.uploader.(*fakeUploader).puts
+		require.Len(t, puts, 1)
+		for key, data := range puts {
+			assert.True(t, strings.HasPrefix(key, "previews/"))
+			assert.Equal(t, image.Rect(0, 0, previewCardSize, previewCardSize), decodeTestPNG(t, data).Bounds())
+		}
+	})
+
+	t.Run("Sound", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"path":"fake.wav"}`),
+			"/files/fake.wav":   newTestWAV(t, 1, []int16{0, 8192, -16384, 32767}),
+		})
+
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WithArgs(sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeSound,
+			Files: model.FileCollection{
+				"assets/sounds/fake/index.json": "kodo://builder/files/index.json",
+				"assets/sounds/fake/fake.wav":   "kodo://builder/files/fake.wav",
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+
+		puts := ctrl.uploader.(*fakeUploader).puts
+		require.Len(t, puts, 1)
+		for _, data := range puts {
+			assert.Equal(t, image.Rect(0, 0, waveformWidth, waveformHeight), decodeTestPNG(t, data).Bounds())
+		}
+	})
+
+	t.Run("MissingConfig", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeSprite,
+			Files:     model.FileCollection{"assets/sprites/fake/a.png": "kodo://builder/files/a.png"},
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errUnrenderablePreview)
+		assert.ErrorContains(t, err, "missing config file")
+		assert.Empty(t, ctrl.uploader.(*fakeUploader).puts)
+	})
+
+	t.Run("Backdrop", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"name":"fake","path":"fake.png"}`),
+			"/files/fake.png":   newTestPNG(t, 1024, 512),
+		})
+
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WithArgs(sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeBackdrop,
+			Files: model.FileCollection{
+				"assets/backdrops/fake/index.json": "kodo://builder/files/index.json",
+				"assets/backdrops/fake/fake.png":   "kodo://builder/files/fake.png",
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+
+		puts := ctrl.uploader.(*fakeUploader).puts
+		require.Len(t, puts, 1)
+		for _, data := range puts {
+			assert.Equal(t, image.Rect(0, 0, previewCardSize, previewCardSize/2), decodeTestPNG(t, data).Bounds())
+		}
+	})
+
+	t.Run("SmallBackdrop", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"name":"fake","path":"fake.png"}`),
+			"/files/fake.png":   newTestPNG(t, 64, 32),
+		})
+
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WithArgs(sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeBackdrop,
+			Files: model.FileCollection{
+				"assets/backdrops/fake/index.json": "kodo://builder/files/index.json",
+				"assets/backdrops/fake/fake.png":   "kodo://builder/files/fake.png",
+			},
+		})
+		require.NoError(t, err)
+
+		puts := ctrl.uploader.(*fakeUploader).puts
+		require.Len(t, puts, 1)
+		for _, data := range puts {
+			assert.Equal(t, image.Rect(0, 0, 64, 32), decodeTestPNG(t, data).Bounds())
+		}
+	})
+
+	t.Run("UndecodableCostume", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"costumes":[{"name":"a","path":"a.svg"}]}`),
+			"/files/a.svg":      []byte("<svg/>"),
+		})
+
+		// The original costume is used as the preview instead.
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WithArgs("kodo://builder/files/a.svg", "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeSprite,
+			Files: model.FileCollection{
+				"assets/sprites/fake/index.json": "kodo://builder/files/index.json",
+				"assets/sprites/fake/a.svg":      "kodo://builder/files/a.svg",
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+		assert.Empty(t, ctrl.uploader.(*fakeUploader).puts)
+	})
+
+	t.Run("UndecodableSound", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"path":"fake.mp3"}`),
+			"/files/fake.mp3":   []byte("ID3 fake mp3"),
+		})
+
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeSound,
+			Files: model.FileCollection{
+				"assets/sounds/fake/index.json": "kodo://builder/files/index.json",
+				"assets/sounds/fake/fake.mp3":   "kodo://builder/files/fake.mp3",
+			},
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errUnrenderablePreview)
+		assert.Empty(t, ctrl.uploader.(*fakeUploader).puts)
+	})
+
+	t.Run("UploadError", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.uploader = &fakeUploader{err: errors.New("upload failed")}
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"costumes":[{"name":"a","path":"a.png"}]}`),
+			"/files/a.png":      newTestPNG(t, 32, 32),
+		})
+
+		err = ctrl.renderAssetPreview(context.Background(), &model.Asset{
+			ID:        "1",
+			AssetType: model.AssetTypeSprite,
+			Files: model.FileCollection{
+				"assets/sprites/fake/index.json": "kodo://builder/files/index.json",
+				"assets/sprites/fake/a.png":      "kodo://builder/files/a.png",
+			},
+		})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "upload failed")
+		assert.NotErrorIs(t, err, errUnrenderablePreview)
+	})
+}
+
+func TestControllerRenderMissingPreviews(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"costumes":[{"name":"a","path":"a.png"}]}`),
+			"/files/a.png":      newTestPNG(t, 32, 32),
+		})
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND \(preview IS NULL OR preview = ''\) AND asset_type IN \(\?, \?, \?\) AND id NOT IN \(SELECT asset_id FROM asset_preview_failure WHERE permanent = 1 OR attempts >= \? OR failed_at > DATE_SUB\(\?, INTERVAL \(1 << \(attempts - 1\)\) HOUR\)\) AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", model.AssetTypeSprite, model.AssetTypeBackdrop, model.AssetTypeSound, maxPreviewAttempts, sqlmock.AnyArg(), model.StatusDeleted, previewRenderBatchSize).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_type", "files"}).
+				AddRow("1", model.AssetTypeSprite, []byte(`{"assets/sprites/fake/index.json":"kodo://builder/files/index.json","assets/sprites/fake/a.png":"kodo://builder/files/a.png"}`)).
+				AddRow("2", model.AssetTypeSprite, []byte(`{"assets/sprites/fake/a.png":"kodo://builder/files/a.png"}`)))
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WithArgs(sqlmock.AnyArg(), "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_preview_failure \(asset_id, attempts, failed_at, permanent\) VALUES \(\?, 1, \?, \?\) ON DUPLICATE KEY UPDATE`).
+			WithArgs("2", sqlmock.AnyArg(), true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.RenderMissingPreviews(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+		assert.Len(t, ctrl.uploader.(*fakeUploader).puts, 1)
+	})
+
+	t.Run("TransientFailure", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.uploader = &fakeUploader{err: errors.New("upload failed")}
+		newTestFileServer(t, ctrl, map[string][]byte{
+			"/files/index.json": []byte(`{"costumes":[{"name":"a","path":"a.png"}]}`),
+			"/files/a.png":      newTestPNG(t, 32, 32),
+		})
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND \(preview IS NULL OR preview = ''\)`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_type", "files"}).
+				AddRow("1", model.AssetTypeSprite, []byte(`{"assets/sprites/fake/index.json":"kodo://builder/files/index.json","assets/sprites/fake/a.png":"kodo://builder/files/a.png"}`)))
+		mock.ExpectExec(`INSERT INTO asset_preview_failure`).
+			WithArgs("1", sqlmock.AnyArg(), false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = ctrl.RenderMissingPreviews(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND \(preview IS NULL OR preview = ''\) AND asset_type IN \(\?, \?, \?\) AND id NOT IN \(SELECT asset_id FROM asset_preview_failure WHERE permanent = 1 OR attempts >= \? OR failed_at > DATE_SUB\(\?, INTERVAL \(1 << \(attempts - 1\)\) HOUR\)\) AND status != \? ORDER BY id ASC LIMIT \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = ctrl.RenderMissingPreviews(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
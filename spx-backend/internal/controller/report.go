@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// reportDetailsRE is the regular expression for asset report details.
+var reportDetailsRE = regexp.MustCompile(`^(?s).{0,1000}$`)
+
+// ReportAssetParams holds parameters for reporting an asset.
+type ReportAssetParams struct {
+	Reason  model.AssetReportReason `json:"reason"`
+	Details string                  `json:"details"`
+}
+
+// Validate validates the parameters.
+func (p *ReportAssetParams) Validate() (ok bool, msg string) {
+	switch p.Reason {
+	case model.AssetReportSpam, model.AssetReportInappropriate, model.AssetReportCopyright, model.AssetReportOther:
+	default:
+		return false, "invalid reason"
+	}
+	if !reportDetailsRE.MatchString(p.Details) {
+		return false, "invalid details"
+	}
+	return true, ""
+}
+
+// ReportAsset reports asset with given id as the user in the context. Repeated
+// reports of the same user on the same asset are ignored. Returns
+// [ErrTooManyRequests] if the user has reported too many assets recently.
+func (ctrl *Controller) ReportAsset(ctx context.Context, id string, params *ReportAssetParams) error {
+	logger := log.GetReqLogger(ctx)
+
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return ErrUnauthorized
+	}
+
+	asset, err := ctrl.ensureAsset(ctx, id, false)
+	if err != nil {
+		return err
+	}
+
+	recentReports, err := model.CountAssetReportsByReporter(ctx, ctrl.db, user.Name, time.Now().UTC().Add(-ctrl.report.rateWindow))
+	if err != nil {
+		logger.Printf("failed to count asset reports: %v", err)
+		return err
+	}
+	if recentReports >= ctrl.report.rateLimit {
+		return ErrTooManyRequests
+	}
+
+	if _, err := model.AddAssetReport(ctx, ctrl.db, &model.AssetReport{
+		AssetID:  asset.ID,
+		Reporter: user.Name,
+		Reason:   params.Reason,
+		Details:  params.Details,
+	}, ctrl.report.threshold); err != nil {
+		logger.Printf("failed to add asset report: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ListAssetReportsParams holds parameters for listing asset reports.
+type ListAssetReportsParams struct {
+	// AssetID is the asset filter, applied only if non-nil.
+	AssetID *string
+
+	// State is the handling state filter, applied only if non-nil.
+	State *model.AssetReportState
+
+	// Pagination is the pagination information.
+	Pagination model.Pagination
+}
+
+// Validate validates the parameters.
+func (p *ListAssetReportsParams) Validate() (ok bool, msg string) {
+	if p.State != nil {
+		switch *p.State {
+		case model.AssetReportOpen, model.AssetReportResolved, model.AssetReportDismissed:
+		default:
+			return false, "invalid state"
+		}
+	}
+	return true, ""
+}
+
+// ListAssetReports lists asset reports, newest first. Only admins are allowed.
+func (ctrl *Controller) ListAssetReports(ctx context.Context, params *ListAssetReportsParams) (*model.ByPage[model.AssetReport], error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var wheres []model.FilterCondition
+	if params.AssetID != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "asset_id", Operation: "=", Value: *params.AssetID})
+	}
+	if params.State != nil {
+		wheres = append(wheres, model.FilterCondition{Column: "state", Operation: "=", Value: *params.State})
+	}
+	orders := []model.OrderByCondition{
+		{Column: "c_time", Direction: "DESC"},
+		{Column: "id", Direction: "DESC"},
+	}
+	reports, err := model.ListAssetReports(ctx, ctrl.db, params.Pagination, wheres, orders)
+	if err != nil {
+		logger.Printf("failed to list asset reports: %v", err)
+		return nil, err
+	}
+	return reports, nil
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportAssetParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &ReportAssetParams{Reason: model.AssetReportSpam, Details: "fake-details"}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidReason", func(t *testing.T) {
+		params := &ReportAssetParams{Reason: "invalid"}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid reason", msg)
+	})
+
+	t.Run("InvalidDetails", func(t *testing.T) {
+		params := &ReportAssetParams{Reason: model.AssetReportOther, Details: strings.Repeat("a", 1001)}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid details", msg)
+	})
+}
+
+func TestControllerReportAsset(t *testing.T) {
+	params := &ReportAssetParams{Reason: model.AssetReportSpam, Details: "fake-details"}
+
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE reporter = \? AND c_time >= \?`).
+			WithArgs("fake-name", sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "fake-name", model.AssetReportSpam, "fake-details", model.AssetReportOpen, model.StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE asset_id = \? AND state = \? AND status != \?`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectCommit()
+		err = ctrl.ReportAsset(ctx, "1", params)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CustomThreshold", func(t *testing.T) {
+		t.Setenv("ASSET_REPORT_THRESHOLD", "1")
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE reporter = \? AND c_time >= \?`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE asset_id = \? AND state = \? AND status != \?`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND moderation_status = \?`).
+			WithArgs(model.ModerationPending, "1", model.ModerationVisible).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		err = ctrl.ReportAsset(ctx, "1", params)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		err = ctrl.ReportAsset(context.Background(), "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Personal))
+		err = ctrl.ReportAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		err = ctrl.ReportAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		t.Setenv("ASSET_REPORT_RATE_LIMIT", "3")
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE reporter = \? AND c_time >= \?`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+		err = ctrl.ReportAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrTooManyRequests)
+	})
+
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE reporter = \? AND c_time >= \?`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		err = ctrl.ReportAsset(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListAssetReportsParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		state := model.AssetReportResolved
+		params := &ListAssetReportsParams{State: &state}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidState", func(t *testing.T) {
+		state := model.AssetReportState(-1)
+		params := &ListAssetReportsParams{State: &state}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid state", msg)
+	})
+}
+
+func TestControllerListAssetReports(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		assetID := "1"
+		state := model.AssetReportOpen
+		params := &ListAssetReportsParams{
+			AssetID:    &assetID,
+			State:      &state,
+			Pagination: model.Pagination{Index: 1, Size: 10},
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE asset_id = \? AND state = \? AND status != \?`).
+			WithArgs("1", model.AssetReportOpen, model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset_report WHERE asset_id = \? AND state = \? AND status != \? ORDER BY c_time DESC, id DESC LIMIT \?, \?`).
+			WithArgs("1", model.AssetReportOpen, model.StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "reporter"}).
+				AddRow(1, 1, "fake-name"))
+		reports, err := ctrl.ListAssetReports(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, reports)
+		assert.Len(t, reports.Data, 1)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.ListAssetReports(context.Background(), &ListAssetReportsParams{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.ListAssetReports(ctx, &ListAssetReportsParams{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedDB", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.ListAssetReports(ctx, &ListAssetReportsParams{Pagination: model.Pagination{Index: 1, Size: 10}})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// ownerStatsDays is the number of days covered by the daily clicks of owner
+// asset stats, including today.
+const ownerStatsDays = 30
+
+// OwnerAssetStats is the figures of assets of an owner.
+type OwnerAssetStats struct {
+	// Total is the figures of all assets of the owner added up.
+	Total model.AssetStatsTotal `json:"total"`
+
+	// Assets is the figures of each asset of the owner, most clicked first.
+	Assets []model.AssetStats `json:"assets"`
+
+	// DailyClicks is the number of clicks on assets of the owner per day, for
+	// each of the last days, oldest first.
+	DailyClicks []model.DailyCount `json:"dailyClicks"`
+}
+
+// GetOwnerAssetStats gets figures of assets of owner. Only the owner is
+// allowed.
+func (ctrl *Controller) GetOwnerAssetStats(ctx context.Context, owner string) (*OwnerAssetStats, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureUser(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	total, err := model.OwnerAssetStatsTotal(ctx, ctrl.db, owner)
+	if err != nil {
+		logger.Printf("failed to get owner asset stats total: %v", err)
+		return nil, err
+	}
+	assets, err := model.ListOwnerAssetStats(ctx, ctrl.db, owner)
+	if err != nil {
+		logger.Printf("failed to list owner asset stats: %v", err)
+		return nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(ownerStatsDays - 1))
+	clicks, err := model.ListOwnerDailyClicks(ctx, ctrl.db, owner, since)
+	if err != nil {
+		logger.Printf("failed to list owner daily clicks: %v", err)
+		return nil, err
+	}
+	clicksByDate := make(map[string]int64, len(clicks))
+	for _, c := range clicks {
+		clicksByDate[c.Date] = c.Count
+	}
+
+	// Days without clicks are filled in, so the series always covers every
+	// day.
+	dailyClicks := make([]model.DailyCount, 0, ownerStatsDays)
+	for d := since; !d.After(today); d = d.AddDate(0, 0, 1) {
+		date := d.Format(time.DateOnly)
+		dailyClicks = append(dailyClicks, model.DailyCount{Date: date, Count: clicksByDate[date]})
+	}
+
+	return &OwnerAssetStats{
+		Total:       *total,
+		Assets:      assets,
+		DailyClicks: dailyClicks,
+	}, nil
+}
+
+// maxAssetViewSeriesDays is the maximum number of days covered by a view
+// series of an asset.
+const maxAssetViewSeriesDays = 365
+
+// GetAssetViewSeries gets the daily views of asset with given id for each of
+// the last days, including today, oldest first. Days without views are filled
+// in, and days is clamped to [1, maxAssetViewSeriesDays]. Only the owner of
+// the asset is allowed.
+func (ctrl *Controller) GetAssetViewSeries(ctx context.Context, assetID string, days int, owner string) ([]model.AssetViewDay, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureUser(ctx, owner); err != nil {
+		return nil, err
+	}
+	asset, err := ctrl.ensureAsset(ctx, assetID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	days = min(max(days, 1), maxAssetViewSeriesDays)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+	views, err := model.ListAssetDailyViews(ctx, ctrl.db, asset.ID, since)
+	if err != nil {
+		logger.Printf("failed to list asset daily views: %v", err)
+		return nil, err
+	}
+	viewsByDate := make(map[string]model.AssetViewDay, len(views))
+	for _, v := range views {
+		viewsByDate[v.Date] = v
+	}
+
+	series := make([]model.AssetViewDay, 0, days)
+	for d := since; !d.After(today); d = d.AddDate(0, 0, 1) {
+		date := d.Format(time.DateOnly)
+		v := viewsByDate[date]
+		v.Date = date
+		series = append(series, v)
+	}
+	return series, nil
+}
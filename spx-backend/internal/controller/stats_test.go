@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerGetOwnerAssetStats(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		today := time.Now().UTC().Format(time.DateOnly)
+		mock.ExpectQuery(`SELECT COUNT\(\*\) AS asset_count`).
+			WithArgs("fake-name", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"asset_count", "click_count", "download_count", "fork_count"}).
+				AddRow(1, 5, 2, 0))
+		mock.ExpectQuery(`SELECT id, display_name, click_count, download_count, fork_count FROM asset`).
+			WithArgs("fake-name", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "click_count", "download_count", "fork_count"}).
+				AddRow("1", "fake-asset", 5, 2, 0))
+		mock.ExpectQuery(`SELECT DATE_FORMAT`).
+			WithArgs("fake-name", model.StatusDeleted, model.AssetEventClick, sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows([]string{"date", "count"}).
+				AddRow(today, 5))
+		stats, err := ctrl.GetOwnerAssetStats(ctx, "fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, model.AssetStatsTotal{AssetCount: 1, ClickCount: 5, DownloadCount: 2}, stats.Total)
+		require.Len(t, stats.Assets, 1)
+		assert.Equal(t, "1", stats.Assets[0].AssetID)
+		require.Len(t, stats.DailyClicks, ownerStatsDays)
+		for _, c := range stats.DailyClicks[:ownerStatsDays-1] {
+			assert.Zero(t, c.Count)
+		}
+		assert.Equal(t, model.DailyCount{Date: today, Count: 5}, stats.DailyClicks[ownerStatsDays-1])
+		assert.Equal(t, time.Now().UTC().AddDate(0, 0, -(ownerStatsDays-1)).Format(time.DateOnly), stats.DailyClicks[0].Date)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.GetOwnerAssetStats(context.Background(), "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.GetOwnerAssetStats(ctx, "another-fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT COUNT\(\*\) AS asset_count`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.GetOwnerAssetStats(ctx, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerGetAssetViewSeries(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		today := time.Now().UTC().Format(time.DateOnly)
+		since := time.Now().UTC().AddDate(0, 0, -6).Format(time.DateOnly)
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "is_public"}).
+				AddRow(1, "fake-name", model.Personal))
+		mock.ExpectQuery(`SELECT DATE_FORMAT\(view_date`).
+			WithArgs("1", since).
+			WillReturnRows(mock.NewRows([]string{"date", "views", "unique_viewers"}).
+				AddRow(today, 5, 3))
+		series, err := ctrl.GetAssetViewSeries(ctx, "1", 7, "fake-name")
+		require.NoError(t, err)
+		require.Len(t, series, 7)
+		assert.Equal(t, model.AssetViewDay{Date: since}, series[0])
+		for _, v := range series[:6] {
+			assert.Zero(t, v.Views)
+			assert.Zero(t, v.UniqueViewers)
+		}
+		assert.Equal(t, model.AssetViewDay{Date: today, Views: 5, UniqueViewers: 3}, series[6])
+	})
+
+	t.Run("ClampedDays", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "is_public"}).
+				AddRow(1, "fake-name", model.Personal))
+		mock.ExpectQuery(`SELECT DATE_FORMAT\(view_date`).
+			WillReturnRows(mock.NewRows([]string{"date", "views", "unique_viewers"}))
+		series, err := ctrl.GetAssetViewSeries(ctx, "1", 10000, "fake-name")
+		require.NoError(t, err)
+		assert.Len(t, series, maxAssetViewSeriesDays)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.GetAssetViewSeries(context.Background(), "1", 7, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.GetAssetViewSeries(ctx, "1", 7, "another-fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NotOwner", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "is_public"}).
+				AddRow(1, "another-fake-name", model.Public))
+		_, err = ctrl.GetAssetViewSeries(ctx, "1", 7, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "is_public"}).
+				AddRow(1, "fake-name", model.Personal))
+		mock.ExpectQuery(`SELECT DATE_FORMAT\(view_date`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.GetAssetViewSeries(ctx, "1", 7, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// tagNameRE is the regular expression for normalized tag name. Commas are not
+// allowed since tags are passed as a comma-separated list when listing assets.
+var tagNameRE = regexp.MustCompile(`^[^,]{1,32}$`)
+
+// maxAssetTags is the maximum number of tags an asset can have.
+const maxAssetTags = 10
+
+// normalizeTags trims and lowercases the given tags, dropping empty and
+// duplicate ones while keeping the original order.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// validateTags validates the given tags after normalization.
+func validateTags(tags []string) (ok bool, msg string) {
+	normalized := normalizeTags(tags)
+	if len(normalized) > maxAssetTags {
+		return false, "too many tags"
+	}
+	for _, tag := range normalized {
+		if !tagNameRE.MatchString(tag) {
+			return false, "invalid tag"
+		}
+	}
+	return true, ""
+}
+
+// GetAssetTags gets tags of asset with given id.
+func (ctrl *Controller) GetAssetTags(ctx context.Context, id string) ([]model.Tag, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := ctrl.ensureAsset(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := model.ListAssetTags(ctx, ctrl.db, asset.ID)
+	if err != nil {
+		logger.Printf("failed to list asset tags: %v", err)
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SetAssetTagsParams holds parameters for setting tags of an asset.
+type SetAssetTagsParams struct {
+	Tags []string `json:"tags"`
+}
+
+// Validate validates the parameters.
+func (p *SetAssetTagsParams) Validate() (ok bool, msg string) {
+	return validateTags(p.Tags)
+}
+
+// SetAssetTags replaces tags of asset with given id.
+func (ctrl *Controller) SetAssetTags(ctx context.Context, id string, params *SetAssetTagsParams) ([]model.Tag, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := ctrl.ensureAsset(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := model.SetAssetTags(ctx, ctrl.db, asset.ID, normalizeTags(params.Tags))
+	if err != nil {
+		logger.Printf("failed to set asset tags: %v", err)
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ListPopularTags lists at most limit tags carried by the most public assets.
+func (ctrl *Controller) ListPopularTags(ctx context.Context, limit int) ([]model.TagCount, error) {
+	logger := log.GetReqLogger(ctx)
+
+	tagCounts, err := model.ListPopularTags(ctx, ctrl.db, limit)
+	if err != nil {
+		logger.Printf("failed to list popular tags: %v", err)
+		return nil, err
+	}
+	return tagCounts, nil
+}
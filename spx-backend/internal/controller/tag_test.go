@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		tags := normalizeTags([]string{" Winter ", "BOSS", "winter", "", "  ", "8-bit"})
+		assert.Equal(t, []string{"winter", "boss", "8-bit"}, tags)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		tags := normalizeTags(nil)
+		assert.Empty(t, tags)
+	})
+}
+
+func TestSetAssetTagsParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &SetAssetTagsParams{Tags: []string{"winter", "boss"}}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		params := &SetAssetTagsParams{}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("DuplicateTagsWithinLimit", func(t *testing.T) {
+		tags := make([]string, maxAssetTags+1)
+		for i := range tags {
+			tags[i] = "winter"
+		}
+		params := &SetAssetTagsParams{Tags: tags}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("TooManyTags", func(t *testing.T) {
+		tags := make([]string, maxAssetTags+1)
+		for i := range tags {
+			tags[i] = strings.Repeat("x", i+1)
+		}
+		params := &SetAssetTagsParams{Tags: tags}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "too many tags", msg)
+	})
+
+	t.Run("TooLongTag", func(t *testing.T) {
+		params := &SetAssetTagsParams{Tags: []string{strings.Repeat("x", 33)}}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid tag", msg)
+	})
+
+	t.Run("TagWithComma", func(t *testing.T) {
+		params := &SetAssetTagsParams{Tags: []string{"winter,boss"}}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid tag", msg)
+	})
+}
+
+func TestControllerGetAssetTags(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		mock.ExpectQuery(`SELECT tag.\* FROM tag JOIN asset_tag ON asset_tag.tag_id = tag.id WHERE asset_tag.asset_id = \? AND tag.status != \? ORDER BY tag.name ASC`).
+			WithArgs("1", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "name"}).
+				AddRow(1, "winter"))
+		tags, err := ctrl.GetAssetTags(ctx, "1")
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+		assert.Equal(t, "winter", tags[0].Name)
+	})
+
+	t.Run("PersonalAssetOfAnotherUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Personal))
+		_, err = ctrl.GetAssetTags(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		_, err = ctrl.GetAssetTags(ctx, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+}
+
+func TestControllerSetAssetTags(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &SetAssetTagsParams{Tags: []string{" Winter", "winter"}}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset_tag WHERE asset_id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT IGNORE INTO tag \(c_time, u_time, name, status\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "winter", model.StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM tag WHERE name IN \(\?\) AND status != \? ORDER BY name ASC`).
+			WithArgs("winter", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "name"}).
+				AddRow(1, "winter"))
+		mock.ExpectExec(`INSERT INTO asset_tag \(c_time, u_time, asset_id, tag_id, status\) VALUES \(\?, \?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "1", model.StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		tags, err := ctrl.SetAssetTags(ctx, "1", params)
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+		assert.Equal(t, "winter", tags[0].Name)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		params := &SetAssetTagsParams{Tags: []string{"winter"}}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Public))
+		_, err = ctrl.SetAssetTags(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &SetAssetTagsParams{Tags: []string{"winter"}}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "another-fake-name", model.Public))
+		_, err = ctrl.SetAssetTags(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedConnForDeleteQuery", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		params := &SetAssetTagsParams{Tags: []string{"winter"}}
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner", "is_public"}).
+				AddRow(1, "fake-asset", "fake-name", model.Personal))
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset_tag WHERE asset_id = \?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ctrl.SetAssetTags(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestControllerListPopularTags(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT tag.name AS name, COUNT\(\*\) AS asset_count FROM tag .+ LIMIT \?`).
+			WithArgs(model.StatusDeleted, model.StatusDeleted, model.Public, model.ModerationVisible, 5).
+			WillReturnRows(mock.NewRows([]string{"name", "asset_count"}).
+				AddRow("winter", 3))
+		tagCounts, err := ctrl.ListPopularTags(context.Background(), 5)
+		require.NoError(t, err)
+		require.Len(t, tagCounts, 1)
+		assert.Equal(t, "winter", tagCounts[0].Name)
+		assert.Equal(t, 3, tagCounts[0].AssetCount)
+	})
+
+	t.Run("ClosedDB", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.db.Close()
+
+		_, err = ctrl.ListPopularTags(context.Background(), 5)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+)
+
+// assetTransferBatchSize is the number of assets moved per transaction when
+// transferring all assets of an owner.
+const assetTransferBatchSize = 100
+
+// ensureOwnerOrAdmin ensures there is a user in the context and it is either
+// owner or an admin.
+func ensureOwnerOrAdmin(ctx context.Context, owner string) (*User, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	if user.Name != owner && !user.IsAdmin {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// ensureUserExists ensures the user with given name exists, so assets are
+// never transferred to an owner nobody can sign in as.
+func (ctrl *Controller) ensureUserExists(ctx context.Context, name string) error {
+	logger := log.GetReqLogger(ctx)
+
+	user, err := ctrl.userDirectory.GetUser(name)
+	if err != nil {
+		logger.Printf("failed to get user: %v", err)
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("%w: user %q does not exist", ErrInvalidArgs, name)
+	}
+	return nil
+}
+
+// TransferAssetOwnershipParams holds parameters for transferring ownership of
+// an asset.
+type TransferAssetOwnershipParams struct {
+	ToOwner string `json:"toOwner"`
+}
+
+// Validate validates the parameters.
+func (p *TransferAssetOwnershipParams) Validate() (ok bool, msg string) {
+	if p.ToOwner == "" {
+		return false, "missing toOwner"
+	}
+	return true, ""
+}
+
+// TransferAssetOwnership transfers asset with given id to another owner. Only
+// the current owner or an admin is allowed. Tags, versions and counters stay
+// with the asset. A display name already taken by an asset of the new owner is
+// handled according to the display name policy.
+func (ctrl *Controller) TransferAssetOwnership(ctx context.Context, id string, params *TransferAssetOwnershipParams) (*model.Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	asset, err := model.AssetByID(ctx, ctrl.db, id)
+	if err != nil {
+		logger.Printf("failed to get asset: %v", err)
+		return nil, err
+	}
+	actor, err := ensureOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if params.ToOwner == asset.Owner {
+		return asset, nil
+	}
+	if err := ctrl.ensureUserExists(ctx, params.ToOwner); err != nil {
+		return nil, err
+	}
+
+	if err := model.TransferAsset(ctx, ctrl.db, asset.ID, asset.Owner, params.ToOwner, actor.Name, ctrl.displayNamePolicy); err != nil {
+		logger.Printf("failed to transfer asset: %v", err)
+		return nil, err
+	}
+	transferredAsset, err := model.AssetByID(ctx, ctrl.db, asset.ID)
+	if err != nil {
+		logger.Printf("failed to get asset: %v", err)
+		return nil, err
+	}
+	return transferredAsset, nil
+}
+
+// TransferAssetsParams holds parameters for transferring all assets of an
+// owner.
+type TransferAssetsParams struct {
+	FromOwner string `json:"fromOwner"`
+	ToOwner   string `json:"toOwner"`
+}
+
+// Validate validates the parameters.
+func (p *TransferAssetsParams) Validate() (ok bool, msg string) {
+	if p.FromOwner == "" {
+		return false, "missing fromOwner"
+	}
+	if p.ToOwner == "" {
+		return false, "missing toOwner"
+	}
+	if p.FromOwner == p.ToOwner {
+		return false, "toOwner must differ from fromOwner"
+	}
+	return true, ""
+}
+
+// TransferAssetsResult holds the result of transferring all assets of an owner.
+type TransferAssetsResult struct {
+	// Transferred is the number of assets transferred.
+	Transferred int `json:"transferred"`
+}
+
+// TransferAssets transfers all assets of an owner to another owner in batches.
+// Only the current owner or an admin is allowed. Each batch is transferred
+// atomically, so a failed call can simply be retried to move the rest.
+func (ctrl *Controller) TransferAssets(ctx context.Context, params *TransferAssetsParams) (*TransferAssetsResult, error) {
+	logger := log.GetReqLogger(ctx)
+
+	actor, err := ensureOwnerOrAdmin(ctx, params.FromOwner)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctrl.ensureUserExists(ctx, params.ToOwner); err != nil {
+		return nil, err
+	}
+
+	result := &TransferAssetsResult{}
+	for {
+		n, err := model.TransferAssets(ctx, ctrl.db, params.FromOwner, params.ToOwner, actor.Name, assetTransferBatchSize, ctrl.displayNamePolicy)
+		if err != nil {
+			logger.Printf("failed to transfer assets: %v", err)
+			return nil, err
+		}
+		result.Transferred += n
+		if n < assetTransferBatchSize {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferAssetOwnershipParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &TransferAssetOwnershipParams{ToOwner: "another-fake-name"}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("MissingToOwner", func(t *testing.T) {
+		params := &TransferAssetOwnershipParams{}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing toOwner", msg)
+	})
+}
+
+func TestControllerTransferAssetOwnership(t *testing.T) {
+	params := &TransferAssetOwnershipParams{ToOwner: "another-fake-name"}
+
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND owner = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", "fake-name", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WithArgs("another-fake-name", "1", model.StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`UPDATE asset SET u_time = \?, owner = \?, display_name = \? WHERE id = \? AND owner = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), "another-fake-name", "fake-asset", "1", "fake-name", model.StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "fake-name", "another-fake-name", "fake-name", model.StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "another-fake-name"))
+		asset, err := ctrl.TransferAssetOwnership(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "another-fake-name", asset.Owner)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "third-fake-name"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND owner = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", "third-fake-name", model.StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "third-fake-name"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WithArgs("another-fake-name", "1", model.StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`UPDATE asset SET u_time = \?, owner = \?, display_name = \? WHERE id = \? AND owner = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), "another-fake-name", "fake-asset", "1", "third-fake-name", model.StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "third-fake-name", "another-fake-name", "fake-name", model.StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "another-fake-name"))
+		asset, err := ctrl.TransferAssetOwnership(ctx, "1", params)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "another-fake-name", asset.Owner)
+	})
+
+	t.Run("UnknownToOwner", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.userDirectory = &fakeUserDirectory{missing: map[string]bool{"another-fake-name": true}}
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		_, err = ctrl.TransferAssetOwnership(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidArgs)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UserLookupError", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.userDirectory = &fakeUserDirectory{err: errors.New("casdoor unavailable")}
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		_, err = ctrl.TransferAssetOwnership(ctx, "1", params)
+		require.Error(t, err)
+		assert.EqualError(t, err, "casdoor unavailable")
+	})
+
+	t.Run("DisplayNameConflict", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.displayNamePolicy = model.DisplayNameReject
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND owner = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \?`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "fake-asset"))
+		mock.ExpectRollback()
+		_, err = ctrl.TransferAssetOwnership(ctx, "1", params)
+		require.Error(t, err)
+		var conflictErr *model.DisplayNameConflictError
+		assert.ErrorAs(t, err, &conflictErr)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SameOwner", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		asset, err := ctrl.TransferAssetOwnership(ctx, "1", &TransferAssetOwnershipParams{ToOwner: "fake-name"})
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "fake-name", asset.Owner)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "fake-name"))
+		_, err = ctrl.TransferAssetOwnership(context.Background(), "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "fake-asset", "third-fake-name"))
+		_, err = ctrl.TransferAssetOwnership(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("NoAsset", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		_, err = ctrl.TransferAssetOwnership(ctx, "1", params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrNotExist)
+	})
+}
+
+func TestTransferAssetsParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &TransferAssetsParams{FromOwner: "fake-name", ToOwner: "another-fake-name"}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("MissingFromOwner", func(t *testing.T) {
+		params := &TransferAssetsParams{ToOwner: "another-fake-name"}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing fromOwner", msg)
+	})
+
+	t.Run("MissingToOwner", func(t *testing.T) {
+		params := &TransferAssetsParams{FromOwner: "fake-name"}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "missing toOwner", msg)
+	})
+
+	t.Run("SameOwner", func(t *testing.T) {
+		params := &TransferAssetsParams{FromOwner: "fake-name", ToOwner: "fake-name"}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "toOwner must differ from fromOwner", msg)
+	})
+}
+
+func TestControllerTransferAssets(t *testing.T) {
+	params := &TransferAssetsParams{FromOwner: "fake-name", ToOwner: "another-fake-name"}
+
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		rows := mock.NewRows([]string{"id", "owner"})
+		for i := 0; i < assetTransferBatchSize; i++ {
+			rows.AddRow(i+1, "fake-name")
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND status != \? ORDER BY id ASC LIMIT \? FOR UPDATE`).
+			WithArgs("fake-name", model.StatusDeleted, assetTransferBatchSize).
+			WillReturnRows(rows)
+		for i := 0; i < assetTransferBatchSize; i++ {
+			mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \?`).
+				WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+			mock.ExpectExec(`UPDATE asset SET u_time = \?, owner = \?`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO asset_transfer`).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND status != \? ORDER BY id ASC LIMIT \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner"}).
+				AddRow(assetTransferBatchSize+1, "fake-name"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \?`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`UPDATE asset SET u_time = \?, owner = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		result, err := ctrl.TransferAssets(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, assetTransferBatchSize+1, result.Transferred)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UnexpectedUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.TransferAssets(ctx, &TransferAssetsParams{FromOwner: "third-fake-name", ToOwner: "another-fake-name"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("UnknownToOwner", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.userDirectory = &fakeUserDirectory{missing: map[string]bool{"another-fake-name": true}}
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.TransferAssets(ctx, params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidArgs)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ctrl.TransferAssets(ctx, params)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -36,6 +36,18 @@ func EnsureUser(ctx context.Context, expectedUser string) (*User, error) {
 	return user, nil
 }
 
+// EnsureAdmin ensures there is a user in the context and it is an admin.
+func EnsureAdmin(ctx context.Context) (*User, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	if !user.IsAdmin {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
 // UserFromToken gets user from the provided JWT token.
 func (ctrl *Controller) UserFromToken(token string) (*User, error) {
 	claims, err := ctrl.casdoorClient.ParseJwtToken(token)
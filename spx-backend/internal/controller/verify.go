@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	qiniuClient "github.com/qiniu/go-sdk/v7/client"
+)
+
+// kodoNoSuchFileCode is the status code kodo replies with for missing objects.
+const kodoNoSuchFileCode = 612
+
+// assetVerifyBatchSize is the number of assets listed per batch when verifying
+// files of assets.
+const assetVerifyBatchSize = 100
+
+// maxAssetVerifyLimit is the maximum number of assets verified per call.
+const maxAssetVerifyLimit = 1000
+
+// assetIDRE is the regular expression for asset id.
+var assetIDRE = regexp.MustCompile(`^\d+$`)
+
+// Problems found with files of assets.
+const (
+	fileProblemMissing      = "missing"
+	fileProblemSizeMismatch = "size mismatch"
+	fileProblemHashMismatch = "hash mismatch"
+)
+
+// VerifyAssetFilesParams holds parameters for verifying files of assets.
+type VerifyAssetFilesParams struct {
+	// Cursor is the id of the last asset verified by a previous call. The walk
+	// resumes after it, or starts from the first asset if empty.
+	Cursor string `json:"cursor"`
+
+	// Limit is the maximum number of assets to verify.
+	Limit int `json:"limit"`
+}
+
+// Validate validates the parameters.
+func (p *VerifyAssetFilesParams) Validate() (ok bool, msg string) {
+	if p.Cursor != "" && !assetIDRE.MatchString(p.Cursor) {
+		return false, "invalid cursor"
+	}
+	if p.Limit < 1 || p.Limit > maxAssetVerifyLimit {
+		return false, fmt.Sprintf("limit must be between 1 and %d", maxAssetVerifyLimit)
+	}
+	return true, ""
+}
+
+// BrokenAsset is an asset with missing or corrupt files.
+type BrokenAsset struct {
+	// ID is the id of the asset.
+	ID string `json:"id"`
+
+	// Owner is the name of the owner of the asset.
+	Owner string `json:"owner"`
+
+	// Files maps relative paths of the broken files to the problems found.
+	Files map[string]string `json:"files"`
+}
+
+// UnverifiableAsset is an asset whose files could not be checked.
+type UnverifiableAsset struct {
+	// ID is the id of the asset.
+	ID string `json:"id"`
+
+	// Owner is the name of the owner of the asset.
+	Owner string `json:"owner"`
+
+	// Reason is why the files could not be checked.
+	Reason string `json:"reason"`
+}
+
+// VerifyAssetFilesResult holds the result of verifying files of assets.
+type VerifyAssetFilesResult struct {
+	// Verified is the number of assets verified.
+	Verified int `json:"verified"`
+
+	// Broken is the verified assets with missing or corrupt files.
+	Broken []BrokenAsset `json:"broken"`
+
+	// Unverifiable is the assets whose files could not be checked. They are
+	// skipped and left as they are, and counted as verified.
+	Unverifiable []UnverifiableAsset `json:"unverifiable"`
+
+	// NextCursor is the cursor to resume the walk with. It is empty once all
+	// assets are verified.
+	NextCursor string `json:"nextCursor"`
+}
+
+// errUnverifiableObject is returned for objects outside the bucket. They are
+// never fetched, since their URLs are supplied by users.
+var errUnverifiableObject = errors.New("object outside the bucket cannot be verified")
+
+// verifyFile checks the file at object against its recorded metadata, if any.
+// It returns the problem found, or an empty string if the file is intact.
+// Errors are returned only if the file cannot be checked, e.g., it is not
+// stored in the bucket.
+func (ctrl *Controller) verifyFile(ctx context.Context, object string, meta *model.FileMeta) (string, error) {
+	key, ok := ctrl.parseKodoObject(object)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errUnverifiableObject, object)
+	}
+
+	info, err := ctrl.bucketManager.Stat(ctrl.kodo.bucket, key)
+	if err != nil {
+		var errInfo *qiniuClient.ErrorInfo
+		if errors.As(err, &errInfo) && errInfo.Code == kodoNoSuchFileCode {
+			return fileProblemMissing, nil
+		}
+		return "", fmt.Errorf("failed to stat object %s: %w", object, err)
+	}
+	if meta == nil {
+		return "", nil
+	}
+	if meta.Hash != "" && info.Hash != meta.Hash {
+		return fileProblemHashMismatch, nil
+	}
+	if info.Fsize != meta.Size {
+		return fileProblemSizeMismatch, nil
+	}
+	return "", nil
+}
+
+// verifyAssetFiles checks all files of asset and returns the problems found by
+// relative path.
+func (ctrl *Controller) verifyAssetFiles(ctx context.Context, asset *model.Asset) (map[string]string, error) {
+	problems := make(map[string]string)
+	for path, object := range asset.Files {
+		var meta *model.FileMeta
+		if m, ok := asset.FilesMeta[path]; ok {
+			meta = &m
+		}
+		problem, err := ctrl.verifyFile(ctx, object, meta)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			problems[path] = problem
+		}
+	}
+	return problems, nil
+}
+
+// VerifyAssetFiles walks assets in id order and checks that their files still
+// exist and match their recorded metadata. Assets with missing or corrupt
+// files are hidden from public listings as broken, and broken assets whose
+// files are intact again are made visible. Only admins are allowed.
+//
+// At most params.Limit assets are verified per call. The walk can be resumed
+// with the returned cursor, and a failed call can simply be retried since
+// verifying an asset again is harmless.
+func (ctrl *Controller) VerifyAssetFiles(ctx context.Context, params *VerifyAssetFilesParams) (*VerifyAssetFilesResult, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := EnsureAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &VerifyAssetFilesResult{
+		Broken:       []BrokenAsset{},
+		Unverifiable: []UnverifiableAsset{},
+	}
+	cursor := params.Cursor
+	for result.Verified < params.Limit {
+		limit := min(assetVerifyBatchSize, params.Limit-result.Verified)
+		assets, err := model.ListAssetsAfter(ctx, ctrl.db, cursor, limit)
+		if err != nil {
+			logger.Printf("failed to list assets: %v", err)
+			return nil, err
+		}
+		for _, asset := range assets {
+			problems, err := ctrl.verifyAssetFiles(ctx, &asset)
+			if err != nil {
+				// A single asset that cannot be checked must not stop the
+				// walk, or every retry would get stuck on it.
+				logger.Printf("failed to verify files of asset %s: %v", asset.ID, err)
+				result.Unverifiable = append(result.Unverifiable, UnverifiableAsset{ID: asset.ID, Owner: asset.Owner, Reason: err.Error()})
+				result.Verified++
+				cursor = asset.ID
+				continue
+			}
+			broken := len(problems) > 0
+			if err := model.SetAssetBrokenByID(ctx, ctrl.db, asset.ID, broken); err != nil {
+				logger.Printf("failed to set asset broken: %v", err)
+				return nil, err
+			}
+			if broken {
+				result.Broken = append(result.Broken, BrokenAsset{ID: asset.ID, Owner: asset.Owner, Files: problems})
+			}
+			result.Verified++
+			cursor = asset.ID
+		}
+		if len(assets) < limit {
+			return result, nil
+		}
+	}
+	result.NextCursor = cursor
+	return result, nil
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/goplus/builder/spx-backend/internal/model"
+	qiniuStorage "github.com/qiniu/go-sdk/v7/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAssetFilesParamsValidate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		params := &VerifyAssetFilesParams{Cursor: "42", Limit: 100}
+		ok, msg := params.Validate()
+		assert.True(t, ok)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		params := &VerifyAssetFilesParams{Cursor: "abc", Limit: 100}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "invalid cursor", msg)
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		params := &VerifyAssetFilesParams{Limit: maxAssetVerifyLimit + 1}
+		ok, msg := params.Validate()
+		assert.False(t, ok)
+		assert.Equal(t, "limit must be between 1 and 1000", msg)
+	})
+}
+
+func TestControllerVerifyFile(t *testing.T) {
+	t.Run("Intact", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/a.wav": {Fsize: 1, Hash: "fake-hash"},
+		}}
+
+		problem, err := ctrl.verifyFile(context.Background(), "kodo://builder/files/a.wav", &model.FileMeta{Size: 1, Hash: "fake-hash"})
+		require.NoError(t, err)
+		assert.Empty(t, problem)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		problem, err := ctrl.verifyFile(context.Background(), "kodo://builder/files/a.wav", nil)
+		require.NoError(t, err)
+		assert.Equal(t, fileProblemMissing, problem)
+	})
+
+	t.Run("HashMismatch", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/a.wav": {Fsize: 1, Hash: "another-fake-hash"},
+		}}
+
+		problem, err := ctrl.verifyFile(context.Background(), "kodo://builder/files/a.wav", &model.FileMeta{Size: 1, Hash: "fake-hash"})
+		require.NoError(t, err)
+		assert.Equal(t, fileProblemHashMismatch, problem)
+	})
+
+	t.Run("SizeMismatch", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/a.wav": {Fsize: 2},
+		}}
+
+		problem, err := ctrl.verifyFile(context.Background(), "kodo://builder/files/a.wav", &model.FileMeta{Size: 1})
+		require.NoError(t, err)
+		assert.Equal(t, fileProblemSizeMismatch, problem)
+	})
+
+	t.Run("StatFailed", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{err: errors.New("fake error")}
+
+		_, err = ctrl.verifyFile(context.Background(), "kodo://builder/files/a.wav", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("External", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request to %s", r.URL)
+		}))
+		defer server.Close()
+
+		_, err = ctrl.verifyFile(context.Background(), server.URL+"/a.png", nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errUnverifiableObject)
+	})
+}
+
+func TestControllerVerifyAssetFiles(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{stats: map[string]qiniuStorage.FileInfo{
+			"files/a.wav": {Fsize: 1},
+		}}
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", model.StatusDeleted, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "files", "files_meta"}).
+				AddRow("1", "fake-name", []byte(`{"a.wav":"kodo://builder/files/a.wav"}`), []byte(`{"a.wav":{"size":1}}`)).
+				AddRow("2", "fake-name", []byte(`{"b.wav":"kodo://builder/files/b.wav"}`), nil))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND moderation_status = \?`).
+			WithArgs(model.ModerationVisible, "1", model.ModerationBroken).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND moderation_status = \?`).
+			WithArgs(model.ModerationBroken, "2", model.ModerationVisible).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		result, err := ctrl.VerifyAssetFiles(ctx, &VerifyAssetFilesParams{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Verified)
+		assert.Equal(t, []BrokenAsset{{ID: "2", Owner: "fake-name", Files: map[string]string{"b.wav": fileProblemMissing}}}, result.Broken)
+		assert.Empty(t, result.NextCursor)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unverifiable", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+		ctrl.bucketManager = &fakeBucketManager{err: errors.New("fake error")}
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", model.StatusDeleted, 2).
+			WillReturnRows(mock.NewRows([]string{"id", "owner", "files"}).
+				AddRow("1", "fake-name", []byte(`{"a.wav":"kodo://builder/files/a.wav"}`)).
+				AddRow("2", "fake-name", []byte(`{}`)))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND moderation_status = \?`).
+			WithArgs(model.ModerationVisible, "2", model.ModerationBroken).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		result, err := ctrl.VerifyAssetFiles(ctx, &VerifyAssetFilesParams{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Verified)
+		assert.Empty(t, result.Broken)
+		require.Len(t, result.Unverifiable, 1)
+		assert.Equal(t, "1", result.Unverifiable[0].ID)
+		assert.Contains(t, result.Unverifiable[0].Reason, "fake error")
+		assert.Equal(t, "2", result.NextCursor)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Resumable", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("5", model.StatusDeleted, 1).
+			WillReturnRows(mock.NewRows([]string{"id", "files"}).
+				AddRow("6", []byte(`{}`)))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		result, err := ctrl.VerifyAssetFiles(ctx, &VerifyAssetFilesParams{Cursor: "5", Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Verified)
+		assert.Empty(t, result.Broken)
+		assert.Equal(t, "6", result.NextCursor)
+	})
+
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		_, err = ctrl.VerifyAssetFiles(context.Background(), &VerifyAssetFilesParams{Limit: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		ctrl, _, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestUser(context.Background())
+		_, err = ctrl.VerifyAssetFiles(ctx, &VerifyAssetFilesParams{Limit: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		ctrl, mock, err := newTestController(t)
+		require.NoError(t, err)
+
+		ctx := newContextWithTestAdmin(context.Background())
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ctrl.VerifyAssetFiles(ctx, &VerifyAssetFilesParams{Limit: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
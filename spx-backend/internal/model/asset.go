@@ -3,7 +3,10 @@ package model
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/goplus/builder/spx-backend/internal/log"
@@ -17,12 +20,16 @@ type Asset struct {
 	// CTime is the creation time.
 	CTime time.Time `db:"c_time" json:"cTime"`
 
-	// UTime is the last update time.
+	// UTime is the last update time. Counter and moderation status changes
+	// leave it untouched, so it tracks edits made by the owner.
 	UTime time.Time `db:"u_time" json:"uTime"`
 
 	// DisplayName is the name to display.
 	DisplayName string `db:"display_name" json:"displayName"`
 
+	// Description is the free-form description of the asset.
+	Description string `db:"description" json:"description"`
+
 	// Owner is the name of the asset owner.
 	Owner string `db:"owner" json:"owner"`
 
@@ -38,15 +45,46 @@ type Asset struct {
 	// FilesHash is the hash of the asset's files.
 	FilesHash string `db:"files_hash" json:"filesHash"`
 
+	// FilesMeta contains metadata of the asset's files, e.g., image
+	// dimensions. It is nil if the files have not been probed yet.
+	FilesMeta FileMetaCollection `db:"files_meta" json:"filesMeta"`
+
 	// Preview is the URL for the asset preview, e.g., a gif for a sprite.
 	Preview string `db:"preview" json:"preview"`
 
+	// IsAiGenerated indicates if the asset is generated by AI.
+	IsAiGenerated bool `db:"is_ai_generated" json:"isAiGenerated"`
+
+	// AiProvider is the AI provider or model that generated the asset. It is
+	// empty for assets that are not AI generated.
+	AiProvider string `db:"ai_provider" json:"aiProvider"`
+
+	// License is the license under which others may use the asset.
+	License AssetLicense `db:"license" json:"license"`
+
 	// ClickCount is the number of clicks on the asset.
 	ClickCount int64 `db:"click_count" json:"clickCount"`
 
+	// DownloadCount is the number of downloads of the asset, e.g., imports
+	// into projects.
+	DownloadCount int64 `db:"download_count" json:"downloadCount"`
+
+	// ForkedFrom is the id of the asset this asset is forked from. It is empty
+	// for assets that are not forks.
+	ForkedFrom string `db:"forked_from" json:"forkedFrom"`
+
+	// ForkCount is the number of forks of the asset.
+	ForkCount int64 `db:"fork_count" json:"forkCount"`
+
 	// IsPublic indicates if the asset is public.
 	IsPublic IsPublic `db:"is_public" json:"isPublic"`
 
+	// ModerationStatus indicates if the asset may appear in public listings.
+	ModerationStatus ModerationStatus `db:"moderation_status" json:"moderationStatus"`
+
+	// DeletedAt is the deletion time. It is nil unless the asset is deleted.
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt"`
+
 	// Status indicates if the asset is deleted.
 	Status Status `db:"status" json:"status"`
 }
@@ -54,6 +92,14 @@ type Asset struct {
 // TableAsset is the table name of [Asset] in database.
 const TableAsset = "asset"
 
+// AssetFullTextColumns are the columns of [Asset] covered by the full-text
+// index, for use with the "MATCH" filter operation.
+const AssetFullTextColumns = "display_name, description"
+
+// AssetNameFullTextColumns are the columns of [Asset] covered by the full-text
+// index on the display name alone, for weighting name matches above others.
+const AssetNameFullTextColumns = "display_name"
+
 // AssetType is the type of asset.
 type AssetType int
 
@@ -63,6 +109,59 @@ const (
 	AssetTypeSound
 )
 
+// ModerationStatus is the moderation status of an asset.
+type ModerationStatus int
+
+const (
+	ModerationVisible  ModerationStatus = iota
+	ModerationPending                   // hidden from public listings pending review
+	ModerationRejected                  // hidden from everyone but the owner
+	ModerationBroken                    // hidden from public listings since its files are missing or corrupt
+	ModerationDraft                     // hidden from everyone but the owner until submitted for review
+)
+
+// AssetLicense is the license of an asset.
+type AssetLicense string
+
+const (
+	LicenseCC0               AssetLicense = "CC0"
+	LicenseCCBY              AssetLicense = "CC-BY"
+	LicenseCCBYSA            AssetLicense = "CC-BY-SA"
+	LicenseCCBYNC            AssetLicense = "CC-BY-NC"
+	LicenseCCBYND            AssetLicense = "CC-BY-ND"
+	LicenseAllRightsReserved AssetLicense = "all-rights-reserved"
+)
+
+// AssetLicenses are all the known licenses.
+var AssetLicenses = []AssetLicense{
+	LicenseCC0,
+	LicenseCCBY,
+	LicenseCCBYSA,
+	LicenseCCBYNC,
+	LicenseCCBYND,
+	LicenseAllRightsReserved,
+}
+
+// IsValid reports whether the license is a known one.
+func (l AssetLicense) IsValid() bool {
+	for _, license := range AssetLicenses {
+		if l == license {
+			return true
+		}
+	}
+	return false
+}
+
+// Remixable reports whether the license allows others to build upon the asset
+// freely, including commercially.
+func (l AssetLicense) Remixable() bool {
+	switch l {
+	case LicenseCC0, LicenseCCBY, LicenseCCBYSA:
+		return true
+	}
+	return false
+}
+
 // AssetByID gets asset with given id. Returns `ErrNotExist` if it does not exist.
 func AssetByID(ctx context.Context, db *sql.DB, id string) (*Asset, error) {
 	return QueryByID[Asset](ctx, db, TableAsset, id)
@@ -73,32 +172,490 @@ func ListAssets(ctx context.Context, db *sql.DB, paginaton Pagination, filters [
 	return QueryByPage[Asset](ctx, db, TableAsset, paginaton, filters, orderBy)
 }
 
-// AddAsset adds an asset.
-func AddAsset(ctx context.Context, db *sql.DB, a *Asset) (*Asset, error) {
-	return Create(ctx, db, TableAsset, a)
+// ListAssetsWithDeleted lists assets with given pagination, where conditions
+// and order by conditions, including deleted ones.
+func ListAssetsWithDeleted(ctx context.Context, db *sql.DB, paginaton Pagination, filters []FilterCondition, orderBy []OrderByCondition) (*ByPage[Asset], error) {
+	whereClause, whereArgs := buildWhereClauseWithDeleted(filters)
+	return queryByPage[Asset](ctx, db, TableAsset, paginaton, whereClause, whereArgs, orderBy)
+}
+
+// AddAsset adds an asset. A display name already taken by another asset of the
+// same owner is handled according to policy.
+func AddAsset(ctx context.Context, db *sql.DB, a *Asset, policy DisplayNamePolicy) (*Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var created *Asset
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		a.DisplayName, err = resolveDisplayName(ctx, tx, a.Owner, a.DisplayName, "", policy)
+		if err != nil {
+			return err
+		}
+		created, err = Create(ctx, tx, TableAsset, a)
+		if err != nil {
+			logger.Printf("Create failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListAssetsByIDs lists assets with given ids, in no particular order. Ids of
+// assets that do not exist are ignored.
+func ListAssetsByIDs(ctx context.Context, db *sql.DB, ids []string) ([]Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	wheres := []FilterCondition{{Column: "id", Operation: "IN", Value: ids}}
+	assets, err := Query[Asset](ctx, db, TableAsset, wheres, nil)
+	if err != nil {
+		logger.Printf("Query failed: %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
+
+// ListAssetsByIDsWithDeleted lists assets with given ids, including deleted
+// ones.
+func ListAssetsByIDsWithDeleted(ctx context.Context, db *sql.DB, ids []string) ([]Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id IN (%s)", TableAsset, placeholders(len(ids)))
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	assets, err := queryRows[Asset](ctx, db, query, args...)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
+
+// UncategorizedCategory is the category that assets with an empty or blank
+// category are counted under by [ListAssetCategories].
+const UncategorizedCategory = "uncategorized"
+
+// CategoryCount is an asset category with the number of assets in it.
+type CategoryCount struct {
+	// Category is the name of the category.
+	Category string `db:"category" json:"category"`
+
+	// AssetCount is the number of assets in the category.
+	AssetCount int `db:"asset_count" json:"assetCount"`
+}
+
+// ListAssetCategories lists distinct categories of assets matching filters with
+// the number of assets in each, ordered by the number in descending order.
+// Empty and blank categories are collapsed into [UncategorizedCategory].
+func ListAssetCategories(ctx context.Context, db *sql.DB, filters []FilterCondition) ([]CategoryCount, error) {
+	logger := log.GetReqLogger(ctx)
+
+	whereClause, whereArgs := buildWhereClause(filters)
+	query := fmt.Sprintf(
+		"SELECT COALESCE(NULLIF(TRIM(category), ''), ?) AS category, COUNT(*) AS asset_count FROM %s %s GROUP BY 1 ORDER BY asset_count DESC, category ASC",
+		TableAsset, whereClause,
+	)
+	categoryCounts, err := queryRows[CategoryCount](ctx, db, query, append([]any{UncategorizedCategory}, whereArgs...)...)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	if categoryCounts == nil {
+		categoryCounts = []CategoryCount{}
+	}
+	return categoryCounts, nil
+}
+
+// randomAssetAttemptsFactor bounds the number of samples drawn by
+// [ListRandomAssets] to this many times the requested number, so duplicate
+// samples cannot make it loop for long.
+const randomAssetAttemptsFactor = 4
+
+// ListRandomAssets lists at most n random assets matching filters.
+//
+// Instead of sorting the whole table randomly, each asset is sampled by picking
+// a random id between the smallest and largest matching ids and taking the
+// first matching asset from there, which is a single index lookup. Assets
+// right after gaps in ids are slightly more likely to be picked.
+func ListRandomAssets(ctx context.Context, db *sql.DB, filters []FilterCondition, n int) ([]Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	whereClause, whereArgs := buildWhereClause(filters)
+	var minID, maxID sql.NullInt64
+	rangeQuery := fmt.Sprintf("SELECT MIN(id), MAX(id) FROM %s %s", TableAsset, whereClause)
+	if err := db.QueryRowContext(ctx, rangeQuery, whereArgs...).Scan(&minID, &maxID); err != nil {
+		logger.Printf("db.QueryRowContext failed: %v", err)
+		return nil, err
+	}
+	assets := []Asset{}
+	if !minID.Valid {
+		return assets, nil
+	}
+
+	seen := make(map[string]bool, n)
+	for i := 0; i < n*randomAssetAttemptsFactor && len(assets) < n; i++ {
+		pivot := minID.Int64 + rand.Int63n(maxID.Int64-minID.Int64+1)
+		whereClause, whereArgs := buildWhereClause(append([]FilterCondition{{Column: "id", Operation: ">=", Value: pivot}}, filters...))
+		query := fmt.Sprintf("SELECT * FROM %s %s ORDER BY id ASC LIMIT 1", TableAsset, whereClause)
+		sampled, err := queryRows[Asset](ctx, db, query, whereArgs...)
+		if err != nil {
+			logger.Printf("queryRows failed: %v", err)
+			return nil, err
+		}
+		// The asset may have been deleted since the range was queried.
+		if len(sampled) == 0 || seen[sampled[0].ID] {
+			continue
+		}
+		seen[sampled[0].ID] = true
+		assets = append(assets, sampled[0])
+	}
+	return assets, nil
+}
+
+// ListAssetsWithoutFilesMeta lists at most limit assets whose files have not
+// been probed yet with ids greater than afterID, ordered by id. An empty afterID
+// lists from the first asset.
+func ListAssetsWithoutFilesMeta(ctx context.Context, db *sql.DB, afterID string, limit int) ([]Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if afterID == "" {
+		afterID = "0"
+	}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id > ? AND files_meta IS NULL AND status != ? ORDER BY id ASC LIMIT ?", TableAsset)
+	assets, err := queryRows[Asset](ctx, db, query, afterID, StatusDeleted, limit)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
+
+// UpdateAssetFilesMetaByID updates metadata of files of asset with given id.
+// Unlike other updates, it leaves the update time untouched since the asset
+// itself does not change.
+func UpdateAssetFilesMetaByID(ctx context.Context, db *sql.DB, id string, filesMeta FileMetaCollection) error {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("UPDATE %s SET files_meta = ? WHERE id = ?", TableAsset)
+	if _, err := db.ExecContext(ctx, query, filesMeta, id); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ListAssetsWithoutPreview lists at most limit sprite, backdrop and sound
+// assets without a preview with ids greater than afterID, ordered by id. An empty afterID
+// lists from the first asset.
+//
+// Assets whose previews failed to be rendered are left out for a backoff of
+// an hour doubling with each failed attempt, and for good once they failed
+// permanently or maxAttempts times.
+func ListAssetsWithoutPreview(ctx context.Context, db *sql.DB, afterID string, limit int, maxAttempts int, now time.Time) ([]Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if afterID == "" {
+		afterID = "0"
+	}
+	query := fmt.Sprintf(
+		"SELECT * FROM %s WHERE id > ? AND (preview IS NULL OR preview = '') AND asset_type IN (?, ?, ?) AND id NOT IN (SELECT asset_id FROM %s WHERE permanent = 1 OR attempts >= ? OR failed_at > DATE_SUB(?, INTERVAL (1 << (attempts - 1)) HOUR)) AND status != ? ORDER BY id ASC LIMIT ?",
+		TableAsset,
+		TableAssetPreviewFailure,
+	)
+	assets, err := queryRows[Asset](ctx, db, query, afterID, AssetTypeSprite, AssetTypeBackdrop, AssetTypeSound, maxAttempts, now, StatusDeleted, limit)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
+
+// UpdateAssetPreviewByID sets the preview of asset with given id if it has none
+// yet, so a preview set by the owner in the meantime is kept. Like metadata of
+// files, it leaves the update time untouched.
+func UpdateAssetPreviewByID(ctx context.Context, db *sql.DB, id string, preview string) error {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("UPDATE %s SET preview = ? WHERE id = ? AND (preview IS NULL OR preview = '')", TableAsset)
+	if _, err := db.ExecContext(ctx, query, preview, id); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ListAssetsAfter lists at most limit assets with ids greater than afterID,
+// ordered by id. An empty afterID lists from the first asset.
+func ListAssetsAfter(ctx context.Context, db *sql.DB, afterID string, limit int) ([]Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if afterID == "" {
+		afterID = "0"
+	}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id > ? AND status != ? ORDER BY id ASC LIMIT ?", TableAsset)
+	assets, err := queryRows[Asset](ctx, db, query, afterID, StatusDeleted, limit)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
+
+// SetAssetBrokenByID marks asset with given id as broken if broken is true,
+// hiding it from public listings, or makes a broken asset visible again
+// otherwise. Assets hidden by moderation are left untouched either way.
+func SetAssetBrokenByID(ctx context.Context, db *sql.DB, id string, broken bool) error {
+	logger := log.GetReqLogger(ctx)
+
+	from, to := ModerationBroken, ModerationVisible
+	if broken {
+		from, to = to, from
+	}
+	query := fmt.Sprintf("UPDATE %s SET moderation_status = ? WHERE id = ? AND moderation_status = ?", TableAsset)
+	if _, err := db.ExecContext(ctx, query, to, id, from); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ForkAsset adds fork as a fork of the asset it is forked from, and increases
+// the fork count of that asset in the same transaction. A display name already
+// taken by another asset of the same owner is handled according to policy.
+func ForkAsset(ctx context.Context, db *sql.DB, fork *Asset, policy DisplayNamePolicy) (*Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var created *Asset
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		fork.DisplayName, err = resolveDisplayName(ctx, tx, fork.Owner, fork.DisplayName, "", policy)
+		if err != nil {
+			return err
+		}
+		created, err = Create(ctx, tx, TableAsset, fork)
+		if err != nil {
+			logger.Printf("Create failed: %v", err)
+			return err
+		}
+
+		query := fmt.Sprintf("UPDATE %s SET fork_count = fork_count + 1 WHERE id = ?", TableAsset)
+		if _, err := tx.ExecContext(ctx, query, fork.ForkedFrom); err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
 // UpdateAssetByID updates asset with given id.
-func UpdateAssetByID(ctx context.Context, db *sql.DB, id string, a *Asset) (*Asset, error) {
+//
+// The previous state of the asset is kept as an [AssetVersion] edited by
+// editor, in the same transaction as the update. At most maxVersions versions
+// are retained for the asset, the oldest ones are pruned first.
+//
+// A changed display name already taken by another asset of the same owner is
+// handled according to policy. An approved asset whose content changes goes
+// back to pending review.
+func UpdateAssetByID(ctx context.Context, db *sql.DB, id string, a *Asset, editor string, maxVersions int, policy DisplayNamePolicy) (*Asset, error) {
 	logger := log.GetReqLogger(ctx)
-	if err := UpdateByID(ctx, db, TableAsset, id, a, "display_name", "category", "asset_type", "files", "files_hash", "preview", "is_public"); err != nil {
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		prev, err := QueryByID[Asset](ctx, tx, TableAsset, id)
+		if err != nil {
+			logger.Printf("QueryByID failed: %v", err)
+			return err
+		}
+		if !strings.EqualFold(a.DisplayName, prev.DisplayName) {
+			a.DisplayName, err = resolveDisplayName(ctx, tx, prev.Owner, a.DisplayName, id, policy)
+			if err != nil {
+				return err
+			}
+		}
+		if err := addAssetVersion(ctx, tx, prev, editor, maxVersions); err != nil {
+			logger.Printf("addAssetVersion failed: %v", err)
+			return err
+		}
+		a.ModerationStatus = editedModerationStatus(prev, a)
+		if err := UpdateByID(ctx, tx, TableAsset, id, a, "display_name", "description", "category", "asset_type", "files", "files_hash", "files_meta", "preview", "is_public", "license", "moderation_status"); err != nil {
+			logger.Printf("UpdateByID failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return AssetByID(ctx, db, id)
+}
+
+// UpdateAssetVisibilityByID updates only the visibility of asset with given
+// id.
+func UpdateAssetVisibilityByID(ctx context.Context, db *sql.DB, id string, isPublic IsPublic) (*Asset, error) {
+	logger := log.GetReqLogger(ctx)
+	if err := UpdateByID(ctx, db, TableAsset, id, &Asset{IsPublic: isPublic}, "is_public"); err != nil {
 		logger.Printf("UpdateByID failed: %v", err)
 		return nil, err
 	}
 	return AssetByID(ctx, db, id)
 }
 
-// IncreaseAssetClickCount increases asset's click count by 1.
-func IncreaseAssetClickCount(ctx context.Context, db *sql.DB, id string) error {
+// UpdateAssetDisplayNameByID updates only the display name of asset with given
+// id. A display name already taken by another asset of the same owner is
+// handled according to policy.
+//
+// Like [UpdateAssetByID], an approved asset whose display name changes goes
+// back to pending review, the previous state of the asset is kept as an
+// [AssetVersion] edited by editor, and at most maxVersions versions are
+// retained for the asset.
+func UpdateAssetDisplayNameByID(ctx context.Context, db *sql.DB, id string, displayName string, editor string, maxVersions int, policy DisplayNamePolicy) (*Asset, error) {
 	logger := log.GetReqLogger(ctx)
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		prev, err := QueryByID[Asset](ctx, tx, TableAsset, id)
+		if err != nil {
+			logger.Printf("QueryByID failed: %v", err)
+			return err
+		}
+		displayName, err = resolveDisplayName(ctx, tx, prev.Owner, displayName, id, policy)
+		if err != nil {
+			return err
+		}
+		if err := addAssetVersion(ctx, tx, prev, editor, maxVersions); err != nil {
+			logger.Printf("addAssetVersion failed: %v", err)
+			return err
+		}
+		edited := *prev
+		edited.DisplayName = displayName
+		if err := UpdateByID(ctx, tx, TableAsset, id, &Asset{
+			DisplayName:      displayName,
+			ModerationStatus: editedModerationStatus(prev, &edited),
+		}, "display_name", "moderation_status"); err != nil {
+			logger.Printf("UpdateByID failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return AssetByID(ctx, db, id)
+}
 
-	query := fmt.Sprintf("UPDATE %s SET u_time = ?, click_count = click_count + 1 WHERE id = ?", TableAsset)
-	result, err := db.ExecContext(ctx, query, time.Now().UTC(), id)
+// incrementAssetCount atomically increases the counter column of asset with
+// given id by 1 and returns the new count.
+func incrementAssetCount(ctx context.Context, db Queryer, id string, column string) (int64, error) {
+	logger := log.GetReqLogger(ctx)
+
+	// LAST_INSERT_ID(expr) hands the updated value back through the result of
+	// the same statement, so no read-modify-write is involved.
+	query := fmt.Sprintf("UPDATE %[1]s SET %[2]s = LAST_INSERT_ID(%[2]s + 1) WHERE id = ?", TableAsset, column)
+	result, err := db.ExecContext(ctx, query, id)
 	if err != nil {
 		logger.Printf("db.ExecContext failed: %v", err)
-		return err
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Printf("result.RowsAffected failed: %v", err)
+		return 0, err
+	} else if rowsAffected == 0 {
+		return 0, ErrNotExist
+	}
+	count, err := result.LastInsertId()
+	if err != nil {
+		logger.Printf("result.LastInsertId failed: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// IncrementAssetClickCount increases asset's click count by 1 and records the
+// click as an asset event, unless viewer has already clicked the asset on the
+// same day. Either way, the click is added to the daily views of the asset. It
+// returns the click count after the click.
+func IncrementAssetClickCount(ctx context.Context, db *sql.DB, id string, viewer string) (int64, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var clickCount int64
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		isNew, err := addAssetClick(ctx, tx, id, viewer, now)
+		if err != nil {
+			logger.Printf("addAssetClick failed: %v", err)
+			return err
+		}
+		if isNew {
+			clickCount, err = incrementAssetCount(ctx, tx, id, "click_count")
+			if err != nil {
+				logger.Printf("incrementAssetCount failed: %v", err)
+				return err
+			}
+			if err := addAssetEvent(ctx, tx, id, AssetEventClick); err != nil {
+				logger.Printf("addAssetEvent failed: %v", err)
+				return err
+			}
+		} else {
+			query := fmt.Sprintf("SELECT click_count FROM %s WHERE id = ?", TableAsset)
+			if err := tx.QueryRowContext(ctx, query, id).Scan(&clickCount); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrNotExist
+				}
+				logger.Printf("tx.QueryRowContext failed: %v", err)
+				return err
+			}
+		}
+
+		if err := addAssetView(ctx, tx, id, now, isNew); err != nil {
+			logger.Printf("addAssetView failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return 0, err
 	}
+	return clickCount, nil
+}
+
+// IncrementAssetDownloadCount increases asset's download count by 1 and
+// records the download as an asset event. It returns the download count after
+// the download.
+func IncrementAssetDownloadCount(ctx context.Context, db *sql.DB, id string) (int64, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var downloadCount int64
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		downloadCount, err = incrementAssetCount(ctx, tx, id, "download_count")
+		if err != nil {
+			logger.Printf("incrementAssetCount failed: %v", err)
+			return err
+		}
+		if err := addAssetEvent(ctx, tx, id, AssetEventDownload); err != nil {
+			logger.Printf("addAssetEvent failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return downloadCount, nil
+}
+
+// DeleteAssetByID deletes asset with given id. Its deletion time is recorded
+// and its update time is left untouched.
+func DeleteAssetByID(ctx context.Context, db *sql.DB, id string) error {
+	logger := log.GetReqLogger(ctx)
 
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = ?, status = ? WHERE id = ? AND status != ?", TableAsset)
+	result, err := db.ExecContext(ctx, query, time.Now().UTC(), StatusDeleted, id, StatusDeleted)
+	if err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		logger.Printf("result.RowsAffected failed: %v", err)
@@ -109,7 +666,91 @@ func IncreaseAssetClickCount(ctx context.Context, db *sql.DB, id string) error {
 	return nil
 }
 
-// DeleteAssetByID deletes asset with given id.
-func DeleteAssetByID(ctx context.Context, db *sql.DB, id string) error {
-	return UpdateByID(ctx, db, TableAsset, id, &Asset{Status: StatusDeleted}, "status")
+// DeleteOwnedAssets deletes assets with given ids owned by owner in a single
+// transaction. It returns the owners of all assets found by id, deleted or not,
+// so callers can tell missing assets from assets of other owners.
+func DeleteOwnedAssets(ctx context.Context, db *sql.DB, ids []string, owner string) (map[string]string, error) {
+	logger := log.GetReqLogger(ctx)
+
+	owners := make(map[string]string, len(ids))
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT * FROM %s WHERE id IN (%s) AND status != ? FOR UPDATE", TableAsset, placeholders(len(ids)))
+		args := make([]any, 0, len(ids)+1)
+		for _, id := range ids {
+			args = append(args, id)
+		}
+		args = append(args, StatusDeleted)
+		assets, err := queryRows[Asset](ctx, tx, query, args...)
+		if err != nil {
+			logger.Printf("queryRows failed: %v", err)
+			return err
+		}
+
+		var owned []any
+		for _, asset := range assets {
+			owners[asset.ID] = asset.Owner
+			if asset.Owner == owner {
+				owned = append(owned, asset.ID)
+			}
+		}
+		if len(owned) == 0 {
+			return nil
+		}
+		query = fmt.Sprintf("UPDATE %s SET deleted_at = ?, status = ? WHERE id IN (%s)", TableAsset, placeholders(len(owned)))
+		if _, err := tx.ExecContext(ctx, query, append([]any{time.Now().UTC(), StatusDeleted}, owned...)...); err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
+
+// DeletedAssetByID gets deleted asset with given id. Returns `ErrNotExist` if
+// it does not exist or is not deleted.
+func DeletedAssetByID(ctx context.Context, db *sql.DB, id string) (*Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ? AND status = ? LIMIT 1", TableAsset)
+	rows, err := db.QueryContext(ctx, query, id, StatusDeleted)
+	if err != nil {
+		logger.Printf("db.QueryContext failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, ErrNotExist
+	}
+	asset, err := rowsScan[Asset](rows)
+	if err != nil {
+		logger.Printf("rowsScan failed: %v", err)
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// RestoreAssetByID restores deleted asset with given id. Its deletion time is
+// cleared and its update time is left untouched, so the restored asset keeps
+// its place in listings ordered by update time. Returns `ErrNotExist` if it
+// does not exist or is not deleted.
+func RestoreAssetByID(ctx context.Context, db *sql.DB, id string) (*Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = NULL, status = ? WHERE id = ? AND status = ?", TableAsset)
+	result, err := db.ExecContext(ctx, query, StatusNormal, id, StatusDeleted)
+	if err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Printf("result.RowsAffected failed: %v", err)
+		return nil, err
+	} else if rowsAffected == 0 {
+		return nil, ErrNotExist
+	}
+	return AssetByID(ctx, db, id)
 }
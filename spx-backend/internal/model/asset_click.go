@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// TableAssetClick is the table name of asset clicks in database. An asset
+// click records that a viewer clicked an asset on a date, and is used to count
+// at most one click per viewer per asset per day.
+const TableAssetClick = "asset_click"
+
+// clickDate returns the date of t in UTC, formatted for the click_date column.
+func clickDate(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// addAssetClick records a click of viewer on asset with given id at given
+// time. It reports whether the click is new, i.e., viewer has not clicked the
+// asset on the same date before.
+func addAssetClick(ctx context.Context, db Queryer, assetID string, viewer string, t time.Time) (bool, error) {
+	logger := log.GetReqLogger(ctx)
+
+	// The unique key on (asset_id, viewer, click_date) makes concurrent clicks
+	// of the same viewer insert at most one row.
+	query := fmt.Sprintf("INSERT IGNORE INTO %s (c_time, asset_id, viewer, click_date) VALUES (?, ?, ?, ?)", TableAssetClick)
+	result, err := db.ExecContext(ctx, query, t, assetID, viewer, clickDate(t))
+	if err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Printf("result.RowsAffected failed: %v", err)
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteAssetClicksBefore deletes asset clicks on dates before the date of t.
+// It returns the number of deleted clicks.
+func DeleteAssetClicksBefore(ctx context.Context, db *sql.DB, t time.Time) (int64, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE click_date < ?", TableAssetClick)
+	result, err := db.ExecContext(ctx, query, clickDate(t))
+	if err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Printf("result.RowsAffected failed: %v", err)
+		return 0, err
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickDate(t *testing.T) {
+	assert.Equal(t, "2024-01-01", clickDate(time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2024-01-01", clickDate(time.Date(2024, 1, 2, 7, 0, 0, 0, time.FixedZone("UTC+8", 8*60*60))))
+}
+
+func TestAddAssetClick(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(now, "1", "user:fake-name", "2024-01-01").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		isNew, err := addAssetClick(context.Background(), db, "1", "user:fake-name", now)
+		require.NoError(t, err)
+		assert.True(t, isNew)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		isNew, err := addAssetClick(context.Background(), db, "1", "user:fake-name", time.Now())
+		require.NoError(t, err)
+		assert.False(t, isNew)
+	})
+
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = addAssetClick(context.Background(), db, "1", "user:fake-name", time.Now())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForRowsAffected", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WillReturnResult(sqlmock.NewErrorResult(sql.ErrConnDone))
+		_, err = addAssetClick(context.Background(), db, "1", "user:fake-name", time.Now())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestDeleteAssetClicksBefore(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`DELETE FROM asset_click WHERE click_date < \?`).
+			WithArgs("2024-01-01").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		n, err := DeleteAssetClicksBefore(context.Background(), db, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+	})
+
+	t.Run("ClosedConnForDeleteQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`DELETE FROM asset_click WHERE click_date < \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = DeleteAssetClicksBefore(context.Background(), db, time.Now())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// TableAssetEvent is the table name of asset events in database. An asset
+// event records a single interaction with an asset, e.g., a click, and is
+// used to compute trending scores.
+const TableAssetEvent = "asset_event"
+
+// AssetEventType is the type of an asset event.
+type AssetEventType int
+
+const (
+	AssetEventClick AssetEventType = iota
+	AssetEventDownload
+)
+
+// addAssetEvent records an event of given type on asset with given id.
+func addAssetEvent(ctx context.Context, db Queryer, assetID string, eventType AssetEventType) error {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("INSERT INTO %s (c_time, asset_id, event_type) VALUES (?, ?, ?)", TableAssetEvent)
+	if _, err := db.ExecContext(ctx, query, time.Now().UTC(), assetID, eventType); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteAssetEventsBefore deletes asset events that happened before t. It
+// returns the number of deleted events.
+func DeleteAssetEventsBefore(ctx context.Context, db *sql.DB, t time.Time) (int64, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE c_time < ?", TableAssetEvent)
+	result, err := db.ExecContext(ctx, query, t)
+	if err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Printf("result.RowsAffected failed: %v", err)
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
+// TrendingScoring configures how trending scores of assets are computed.
+//
+// The score of an asset is the sum of the weights of its events since Since,
+// each decayed by half for every HalfLife elapsed between the event and Now.
+type TrendingScoring struct {
+	Now      time.Time
+	Since    time.Time
+	HalfLife time.Duration
+	Weights  map[AssetEventType]float64 // events of types not listed are ignored
+}
+
+// subquery returns a query selecting asset_id and score of assets with events
+// counted by the scoring.
+func (s TrendingScoring) subquery() (string, []any) {
+	eventTypes := make([]AssetEventType, 0, len(s.Weights))
+	for eventType := range s.Weights {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Slice(eventTypes, func(i, j int) bool { return eventTypes[i] < eventTypes[j] })
+
+	var (
+		weightExpr strings.Builder
+		weightArgs = make([]any, 0, 2*len(eventTypes))
+	)
+	weightExpr.WriteString("CASE event_type")
+	for _, eventType := range eventTypes {
+		weightExpr.WriteString(" WHEN ? THEN ?")
+		weightArgs = append(weightArgs, eventType, s.Weights[eventType])
+	}
+	weightExpr.WriteString(" ELSE 0 END")
+
+	query := fmt.Sprintf(
+		"SELECT asset_id, SUM((%s) * POW(0.5, TIMESTAMPDIFF(SECOND, c_time, ?) / ?)) AS score FROM %s WHERE c_time >= ? GROUP BY asset_id",
+		weightExpr.String(), TableAssetEvent,
+	)
+	args := append(weightArgs, s.Now, s.HalfLife.Seconds(), s.Since)
+	return query, args
+}
+
+// ListTrendingAssets lists assets with events counted by the scoring, ordered
+// by trending score in descending order. Columns in filters refer to the asset
+// table.
+func ListTrendingAssets(ctx context.Context, db *sql.DB, paginaton Pagination, filters []FilterCondition, scoring TrendingScoring) (*ByPage[Asset], error) {
+	logger := log.GetReqLogger(ctx)
+
+	trendQuery, trendArgs := scoring.subquery()
+	whereClause, whereArgs := buildWhereClause(filters)
+	from := fmt.Sprintf("%[1]s JOIN (%[2]s) AS trend ON trend.asset_id = %[1]s.id", TableAsset, trendQuery)
+	args := append(trendArgs, whereArgs...)
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", from, whereClause)
+	if err := db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		logger.Printf("db.QueryRowContext failed: %v", err)
+		return nil, err
+	}
+
+	offset := (paginaton.Index - 1) * paginaton.Size
+	query := fmt.Sprintf("SELECT %s.* FROM %s %s ORDER BY trend.score DESC, %s.id DESC LIMIT ?, ?", TableAsset, from, whereClause, TableAsset)
+	data, err := queryRows[Asset](ctx, db, query, append(args, offset, paginaton.Size)...)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	if data == nil {
+		data = []Asset{}
+	}
+	return &ByPage[Asset]{
+		Total: total,
+		Data:  data,
+	}, nil
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAssetEvent(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_event \(c_time, asset_id, event_type\) VALUES \(\?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", AssetEventClick).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		err = addAssetEvent(context.Background(), db, "1", AssetEventClick)
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_event`).
+			WillReturnError(sql.ErrConnDone)
+		err = addAssetEvent(context.Background(), db, "1", AssetEventClick)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestDeleteAssetEventsBefore(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectExec(`DELETE FROM asset_event WHERE c_time < \?`).
+			WithArgs(before).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		n, err := DeleteAssetEventsBefore(context.Background(), db, before)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`DELETE FROM asset_event WHERE c_time < \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = DeleteAssetEventsBefore(context.Background(), db, time.Now())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestTrendingScoringSubquery(t *testing.T) {
+	now := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+	scoring := TrendingScoring{
+		Now:      now,
+		Since:    now.Add(-7 * 24 * time.Hour),
+		HalfLife: 48 * time.Hour,
+		Weights:  map[AssetEventType]float64{AssetEventClick: 1},
+	}
+	query, args := scoring.subquery()
+	assert.Equal(t, "SELECT asset_id, SUM((CASE event_type WHEN ? THEN ? ELSE 0 END) * POW(0.5, TIMESTAMPDIFF(SECOND, c_time, ?) / ?)) AS score FROM asset_event WHERE c_time >= ? GROUP BY asset_id", query)
+	assert.Equal(t, []any{AssetEventClick, 1.0, now, 172800.0, now.Add(-7 * 24 * time.Hour)}, args)
+}
+
+func TestListTrendingAssets(t *testing.T) {
+	now := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+	scoring := TrendingScoring{
+		Now:      now,
+		Since:    now.Add(-7 * 24 * time.Hour),
+		HalfLife: 48 * time.Hour,
+		Weights:  map[AssetEventType]float64{AssetEventClick: 1},
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset JOIN \(SELECT asset_id, SUM\(.+\) AS score FROM asset_event WHERE c_time >= \? GROUP BY asset_id\) AS trend ON trend.asset_id = asset.id WHERE is_public = \? AND status != \?`).
+			WithArgs(AssetEventClick, 1.0, now, 172800.0, scoring.Since, Public, StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(2))
+		mock.ExpectQuery(`SELECT asset.\* FROM asset JOIN \(.+\) AS trend ON trend.asset_id = asset.id WHERE is_public = \? AND status != \? ORDER BY trend.score DESC, asset.id DESC LIMIT \?, \?`).
+			WithArgs(AssetEventClick, 1.0, now, 172800.0, scoring.Since, Public, StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "bar").
+				AddRow(1, "foo"))
+		assets, err := ListTrendingAssets(context.Background(), db, Pagination{Index: 1, Size: 10}, []FilterCondition{{Column: "is_public", Operation: "=", Value: Public}}, scoring)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Equal(t, 2, assets.Total)
+		require.Len(t, assets.Data, 2)
+		assert.Equal(t, "2", assets.Data[0].ID)
+		assert.Equal(t, "1", assets.Data[1].ID)
+	})
+
+	t.Run("NoEvents", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset JOIN`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(0))
+		mock.ExpectQuery(`SELECT asset.\* FROM asset JOIN`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		assets, err := ListTrendingAssets(context.Background(), db, Pagination{Index: 1, Size: 10}, nil, scoring)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Equal(t, 0, assets.Total)
+		assert.NotNil(t, assets.Data)
+		assert.Empty(t, assets.Data)
+	})
+
+	t.Run("ClosedConnForCountQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset JOIN`).
+			WillReturnError(sql.ErrConnDone)
+		assets, err := ListTrendingAssets(context.Background(), db, Pagination{Index: 1, Size: 10}, nil, scoring)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, assets)
+	})
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// ListDeletedAssetsBefore lists at most limit assets deleted before t with ids
+// greater than afterID, ordered by id. An empty afterID lists from the first
+// asset.
+func ListDeletedAssetsBefore(ctx context.Context, db *sql.DB, t time.Time, afterID string, limit int) ([]Asset, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if afterID == "" {
+		afterID = "0"
+	}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id > ? AND status = ? AND deleted_at < ? ORDER BY id ASC LIMIT ?", TableAsset)
+	assets, err := queryRows[Asset](ctx, db, query, afterID, StatusDeleted, t, limit)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return assets, nil
+}
+
+// ListAssetVersionObjects lists objects referenced by files and previews of
+// all versions of asset with given id, including deleted ones.
+func ListAssetVersionObjects(ctx context.Context, db *sql.DB, assetID string) ([]string, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE asset_id = ? ORDER BY id ASC", TableAssetVersion)
+	versions, err := queryRows[AssetVersion](ctx, db, query, assetID)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	var objects []string
+	for _, v := range versions {
+		for _, object := range v.Files {
+			objects = append(objects, object)
+		}
+		if v.Preview != "" {
+			objects = append(objects, v.Preview)
+		}
+	}
+	return objects, nil
+}
+
+// DeleteObjectIfUnreferenced calls deleteObject to delete the object with
+// given universal URL, unless it is referenced by files or the preview of any
+// asset other than the one with given id, of any version of such an asset, or
+// by files of any project. Deleted rows count as well, since their objects are
+// still needed until they are collected. It reports whether the object was
+// deleted.
+//
+// Objects are keyed by the hash of their content, so this is in effect a
+// reference count on the content. The references are looked up through the
+// multi-valued indexes on files and the indexes on preview, with locking reads
+// in the same transaction deleteObject runs in, so existing rows cannot stop
+// or start referencing the object in between.
+//
+// Locking reads do not cover rows that do not exist yet, so a race remains: a
+// row inserted concurrently, e.g., for an upload of the same content, whose
+// object is stored before its row is added, may reference the object just as
+// it is deleted. Deleted assets are only collected after their retention,
+// which makes this unlikely but not impossible.
+func DeleteObjectIfUnreferenced(ctx context.Context, db *sql.DB, object string, exceptAssetID string, deleteObject func() error) (bool, error) {
+	logger := log.GetReqLogger(ctx)
+
+	const memberOf = "? MEMBER OF (JSON_EXTRACT(files, '$.*'))"
+	query := fmt.Sprintf(
+		"SELECT EXISTS (SELECT 1 FROM %[1]s WHERE id != ? AND %[4]s FOR SHARE) "+
+			"OR EXISTS (SELECT 1 FROM %[1]s WHERE id != ? AND preview = ? FOR SHARE) "+
+			"OR EXISTS (SELECT 1 FROM %[2]s WHERE asset_id != ? AND %[4]s FOR SHARE) "+
+			"OR EXISTS (SELECT 1 FROM %[2]s WHERE asset_id != ? AND preview = ? FOR SHARE) "+
+			"OR EXISTS (SELECT 1 FROM %[3]s WHERE %[4]s FOR SHARE)",
+		TableAsset, TableAssetVersion, TableProject, memberOf,
+	)
+	var deleted bool
+	err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		var referenced bool
+		if err := tx.QueryRowContext(
+			ctx, query,
+			exceptAssetID, object,
+			exceptAssetID, object,
+			exceptAssetID, object,
+			exceptAssetID, object,
+			object,
+		).Scan(&referenced); err != nil {
+			logger.Printf("tx.QueryRowContext failed: %v", err)
+			return err
+		}
+		if referenced {
+			return nil
+		}
+		if err := deleteObject(); err != nil {
+			return err
+		}
+		deleted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted, nil
+}
+
+// assetOwnedTables are the tables whose rows belong to a single asset through
+// their asset_id column.
+var assetOwnedTables = []string{
+	TableAssetVersion,
+	TableAssetTag,
+	TableAssetEvent,
+	TableAssetClick,
+	TableAssetViewDaily,
+	TableAssetReport,
+	TableAssetModeration,
+	TableAssetTransfer,
+	TableAssetPreviewFailure,
+}
+
+// PurgeAssetByID permanently removes deleted asset with given id along with
+// all rows belonging to it. Returns [ErrNotExist] if it does not exist or is
+// not deleted.
+func PurgeAssetByID(ctx context.Context, db *sql.DB, id string) error {
+	logger := log.GetReqLogger(ctx)
+
+	return runInTx(ctx, db, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("DELETE FROM %s WHERE id = ? AND status = ?", TableAsset)
+		result, err := tx.ExecContext(ctx, query, id, StatusDeleted)
+		if err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			logger.Printf("result.RowsAffected failed: %v", err)
+			return err
+		} else if rowsAffected == 0 {
+			return ErrNotExist
+		}
+
+		for _, table := range assetOwnedTables {
+			query := fmt.Sprintf("DELETE FROM %s WHERE asset_id = ?", table)
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				logger.Printf("tx.ExecContext failed: %v", err)
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDeletedAssetsBefore(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status = \? AND deleted_at < \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", StatusDeleted, before, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "files"}).
+				AddRow(1, []byte(`{"a.wav":"kodo://builder/files/a.wav"}`)))
+		assets, err := ListDeletedAssetsBefore(context.Background(), db, before, "", 10)
+		require.NoError(t, err)
+		require.Len(t, assets, 1)
+		assert.Equal(t, "kodo://builder/files/a.wav", assets[0].Files["a.wav"])
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListDeletedAssetsBefore(context.Background(), db, time.Now(), "5", 10)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListAssetVersionObjects(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \? ORDER BY id ASC`).
+			WithArgs("1").
+			WillReturnRows(mock.NewRows([]string{"id", "files", "preview"}).
+				AddRow(1, []byte(`{"a.wav":"kodo://builder/files/a.wav"}`), "kodo://builder/previews/a.png").
+				AddRow(2, []byte(`{"b.wav":"kodo://builder/files/b.wav"}`), ""))
+		objects, err := ListAssetVersionObjects(context.Background(), db, "1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"kodo://builder/files/a.wav",
+			"kodo://builder/previews/a.png",
+			"kodo://builder/files/b.wav",
+		}, objects)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListAssetVersionObjects(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestDeleteObjectIfUnreferenced(t *testing.T) {
+	const query = `SELECT EXISTS \(SELECT 1 FROM asset WHERE id != \? AND \? MEMBER OF \(JSON_EXTRACT\(files, '\$\.\*'\)\) FOR SHARE\) ` +
+		`OR EXISTS \(SELECT 1 FROM asset WHERE id != \? AND preview = \? FOR SHARE\) ` +
+		`OR EXISTS \(SELECT 1 FROM asset_version WHERE asset_id != \? AND \? MEMBER OF \(JSON_EXTRACT\(files, '\$\.\*'\)\) FOR SHARE\) ` +
+		`OR EXISTS \(SELECT 1 FROM asset_version WHERE asset_id != \? AND preview = \? FOR SHARE\) ` +
+		`OR EXISTS \(SELECT 1 FROM project WHERE \? MEMBER OF \(JSON_EXTRACT\(files, '\$\.\*'\)\) FOR SHARE\)`
+	const object = "kodo://builder/files/a.wav"
+
+	t.Run("Referenced", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).
+			WithArgs("1", object, "1", object, "1", object, "1", object, object).
+			WillReturnRows(mock.NewRows([]string{"referenced"}).AddRow(true))
+		mock.ExpectCommit()
+		deleted, err := DeleteObjectIfUnreferenced(context.Background(), db, object, "1", func() error {
+			t.Fatal("unexpected delete")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.False(t, deleted)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unreferenced", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		var called bool
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).
+			WithArgs("1", object, "1", object, "1", object, "1", object, object).
+			WillReturnRows(mock.NewRows([]string{"referenced"}).AddRow(false))
+		mock.ExpectCommit()
+		deleted, err := DeleteObjectIfUnreferenced(context.Background(), db, object, "1", func() error {
+			called = true
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, deleted)
+		assert.True(t, called)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteFailed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).
+			WillReturnRows(mock.NewRows([]string{"referenced"}).AddRow(false))
+		mock.ExpectRollback()
+		deleteErr := errors.New("fake error")
+		deleted, err := DeleteObjectIfUnreferenced(context.Background(), db, object, "1", func() error {
+			return deleteErr
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, deleteErr)
+		assert.False(t, deleted)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = DeleteObjectIfUnreferenced(context.Background(), db, object, "1", func() error {
+			t.Fatal("unexpected delete")
+			return nil
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestPurgeAssetByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset WHERE id = \? AND status = \?`).
+			WithArgs("1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		for _, table := range []string{"asset_version", "asset_tag", "asset_event", "asset_click", "asset_view_daily", "asset_report", "asset_moderation", "asset_transfer", "asset_preview_failure"} {
+			mock.ExpectExec(`DELETE FROM ` + table + ` WHERE asset_id = \?`).
+				WithArgs("1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+		err = PurgeAssetByID(context.Background(), db, "1")
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset WHERE id = \? AND status = \?`).
+			WithArgs("1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		err = PurgeAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset WHERE id = \? AND status = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM asset_version WHERE asset_id = \?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		err = PurgeAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
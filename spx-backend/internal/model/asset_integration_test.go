@@ -0,0 +1,99 @@
+//go:build integration
+
+// Integration tests run against a real MySQL database initialized with
+// init.sql, whose DSN is given by GOP_SPX_TEST_DSN, e.g.:
+//
+//	GOP_SPX_TEST_DSN='root:root@tcp(127.0.0.1:3306)/builder_test?charset=utf8&parseTime=True' go test -tags integration ./internal/model
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIntegrationDB opens the database given by GOP_SPX_TEST_DSN, or skips the
+// test if it is not set.
+func newIntegrationDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("GOP_SPX_TEST_DSN")
+	if dsn == "" {
+		t.Skip("GOP_SPX_TEST_DSN is not set")
+	}
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Ping())
+	return db
+}
+
+// addIntegrationAsset adds an asset for the test and returns its id. The asset
+// and the rows recorded for it are deleted when the test finishes.
+func addIntegrationAsset(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	now := time.Now().UTC()
+	query := fmt.Sprintf("INSERT INTO %s (c_time, u_time, display_name, owner, is_public, status) VALUES (?, ?, ?, ?, ?, ?)", TableAsset)
+	result, err := db.Exec(query, now, now, t.Name(), "fake-name", Public, StatusNormal)
+	require.NoError(t, err)
+	lastInsertID, err := result.LastInsertId()
+	require.NoError(t, err)
+	id := strconv.FormatInt(lastInsertID, 10)
+
+	t.Cleanup(func() {
+		for _, table := range []string{TableAssetClick, TableAssetEvent, TableAssetViewDaily} {
+			_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE asset_id = ?", table), id)
+			assert.NoError(t, err)
+		}
+		_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", TableAsset), id)
+		assert.NoError(t, err)
+	})
+	return id
+}
+
+func TestIncrementAssetClickCountConcurrently(t *testing.T) {
+	db := newIntegrationDB(t)
+	id := addIntegrationAsset(t, db)
+
+	const n = 100
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		clickCounts = make(map[int64]bool, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(viewer string) {
+			defer wg.Done()
+			clickCount, err := IncrementAssetClickCount(context.Background(), db, id, viewer)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			clickCounts[clickCount] = true
+			mu.Unlock()
+		}(fmt.Sprintf("user:viewer-%d", i))
+	}
+	wg.Wait()
+
+	// Every increment lands, and each sees a distinct count.
+	var clickCount int64
+	err := db.QueryRow(fmt.Sprintf("SELECT click_count FROM %s WHERE id = ?", TableAsset), id).Scan(&clickCount)
+	require.NoError(t, err)
+	assert.Equal(t, int64(n), clickCount)
+	assert.Len(t, clickCounts, n)
+	for i := int64(1); i <= n; i++ {
+		assert.True(t, clickCounts[i], "missing click count %d", i)
+	}
+}
@@ -0,0 +1,194 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// AssetModeration is the model for an audit record of a moderation decision
+// on an asset.
+type AssetModeration struct {
+	// ID is the globally unique identifier.
+	ID string `db:"id" json:"id"`
+
+	// CTime is the creation time.
+	CTime time.Time `db:"c_time" json:"cTime"`
+
+	// UTime is the last update time.
+	UTime time.Time `db:"u_time" json:"uTime"`
+
+	// AssetID is the id of the moderated asset.
+	AssetID string `db:"asset_id" json:"assetId"`
+
+	// Moderator is the name of the user who made the decision, i.e., the
+	// owner for submissions and an admin otherwise.
+	Moderator string `db:"moderator" json:"moderator"`
+
+	// Decision is the moderation decision.
+	Decision ModerationDecision `db:"decision" json:"decision"`
+
+	// Note is the free-form note left by the moderator.
+	Note string `db:"note" json:"note"`
+
+	// Status indicates if the record is deleted.
+	Status Status `db:"status" json:"status"`
+}
+
+// TableAssetModeration is the table name of [AssetModeration] in database.
+const TableAssetModeration = "asset_moderation"
+
+// ModerationDecision is a moderation decision on an asset.
+type ModerationDecision string
+
+const (
+	ModerationApprove ModerationDecision = "approve"
+	ModerationReject  ModerationDecision = "reject"
+	ModerationSubmit  ModerationDecision = "submit" // submitted for review by the owner
+)
+
+// lockAssetModerationStatus locks asset with given id for update and checks
+// that its moderation status is one of from. Returns [ErrNotExist] if it does
+// not exist, or [ErrInvalidTransition] if it is in any other status.
+func lockAssetModerationStatus(ctx context.Context, tx *sql.Tx, id string, from []ModerationStatus) error {
+	logger := log.GetReqLogger(ctx)
+
+	var status ModerationStatus
+	query := fmt.Sprintf("SELECT moderation_status FROM %s WHERE id = ? AND status != ? FOR UPDATE", TableAsset)
+	if err := tx.QueryRowContext(ctx, query, id, StatusDeleted).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotExist
+		}
+		logger.Printf("tx.QueryRowContext failed: %v", err)
+		return err
+	}
+	if !slices.Contains(from, status) {
+		return ErrInvalidTransition
+	}
+	return nil
+}
+
+// editedModerationStatus returns the moderation status of asset prev once it
+// is edited into a. Approved assets whose display name, description, files or
+// preview change go back to pending review, so approved content cannot be
+// swapped without another review. Assets in any other status are hidden from
+// public listings already and keep their status.
+func editedModerationStatus(prev, a *Asset) ModerationStatus {
+	if prev.ModerationStatus != ModerationVisible {
+		return prev.ModerationStatus
+	}
+	if a.DisplayName != prev.DisplayName ||
+		a.Description != prev.Description ||
+		a.Preview != prev.Preview ||
+		!maps.Equal(a.Files, prev.Files) {
+		return ModerationPending
+	}
+	return ModerationVisible
+}
+
+// SubmitAssetForReview moves the asset of m from draft, or back from
+// rejected, to pending review, and records m as an audit record, which is
+// returned. Returns [ErrNotExist] if the asset does not exist, or
+// [ErrInvalidTransition] if it is in any other moderation status.
+func SubmitAssetForReview(ctx context.Context, db *sql.DB, m *AssetModeration) (*AssetModeration, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var record *AssetModeration
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		if err := lockAssetModerationStatus(ctx, tx, m.AssetID, []ModerationStatus{ModerationDraft, ModerationRejected}); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("UPDATE %s SET moderation_status = ? WHERE id = ?", TableAsset)
+		if _, err := tx.ExecContext(ctx, query, ModerationPending, m.AssetID); err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+
+		var err error
+		record, err = Create(ctx, tx, TableAssetModeration, m)
+		if err != nil {
+			logger.Printf("Create failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ModerateAsset applies the decision of m to the asset and records m as an
+// audit record, which is returned. Open reports of the asset are dismissed on
+// approval and resolved on rejection. Returns `ErrNotExist` if the asset does
+// not exist, or [ErrInvalidTransition] if it is a draft, which only its owner
+// may submit.
+func ModerateAsset(ctx context.Context, db *sql.DB, m *AssetModeration) (*AssetModeration, error) {
+	return moderateAsset(ctx, db, m, []ModerationStatus{ModerationPending, ModerationVisible, ModerationRejected})
+}
+
+// ReviewAsset is like [ModerateAsset], but only for assets pending review.
+// Returns [ErrInvalidTransition] if the asset is in any other moderation
+// status, e.g., a draft that was never submitted.
+func ReviewAsset(ctx context.Context, db *sql.DB, m *AssetModeration) (*AssetModeration, error) {
+	return moderateAsset(ctx, db, m, []ModerationStatus{ModerationPending})
+}
+
+// moderateAsset implements [ModerateAsset] and [ReviewAsset]. The asset must
+// be in one of the moderation statuses in from.
+func moderateAsset(ctx context.Context, db *sql.DB, m *AssetModeration, from []ModerationStatus) (*AssetModeration, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var (
+		moderationStatus ModerationStatus
+		reportState      AssetReportState
+	)
+	switch m.Decision {
+	case ModerationApprove:
+		moderationStatus, reportState = ModerationVisible, AssetReportDismissed
+	case ModerationReject:
+		moderationStatus, reportState = ModerationRejected, AssetReportResolved
+	default:
+		return nil, fmt.Errorf("unknown moderation decision: %q", m.Decision)
+	}
+
+	var record *AssetModeration
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		if err := lockAssetModerationStatus(ctx, tx, m.AssetID, from); err != nil {
+			return err
+		}
+
+		// The asset is known to exist from the lock above, and the update
+		// may change no rows, e.g., when approving a visible asset, so the
+		// number of affected rows is not checked.
+		now := time.Now().UTC()
+		query := fmt.Sprintf("UPDATE %s SET moderation_status = ? WHERE id = ? AND status != ?", TableAsset)
+		if _, err := tx.ExecContext(ctx, query, moderationStatus, m.AssetID, StatusDeleted); err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+
+		query = fmt.Sprintf("UPDATE %s SET u_time = ?, state = ? WHERE asset_id = ? AND state = ?", TableAssetReport)
+		if _, err := tx.ExecContext(ctx, query, now, reportState, m.AssetID, AssetReportOpen); err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+
+		var err error
+		record, err = Create(ctx, tx, TableAssetModeration, m)
+		if err != nil {
+			logger.Printf("Create failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
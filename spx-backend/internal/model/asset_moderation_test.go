@@ -0,0 +1,393 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModerateAsset(t *testing.T) {
+	t.Run("Approve", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationPending))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(ModerationVisible, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), AssetReportDismissed, "1", AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO asset_moderation \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision"}).
+				AddRow(1, 1, "fake-admin", ModerationApprove))
+		mock.ExpectCommit()
+		moderation, err := ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-admin", Decision: ModerationApprove})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, ModerationApprove, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationVisible))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(ModerationRejected, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), AssetReportResolved, "1", AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision"}).
+				AddRow(1, 1, "fake-admin", ModerationReject))
+		mock.ExpectCommit()
+		moderation, err := ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-admin", Decision: ModerationReject})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, ModerationReject, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ApproveVisible", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		// MySQL counts only changed rows as affected, so approving a visible
+		// asset affects none.
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationVisible))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(ModerationVisible, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), AssetReportDismissed, "1", AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision"}).
+				AddRow(1, 1, "fake-admin", ModerationApprove))
+		mock.ExpectCommit()
+		moderation, err := ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-admin", Decision: ModerationApprove})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, ModerationApprove, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RejectRejected", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		ctx := context.Background()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationRejected))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(ModerationRejected, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), AssetReportResolved, "1", AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_moderation \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision", "note"}).
+				AddRow(1, 1, "fake-admin", ModerationReject, "fake-note"))
+		mock.ExpectCommit()
+		moderation, err := ModerateAsset(ctx, db, &AssetModeration{AssetID: "1", Moderator: "fake-admin", Decision: ModerationReject, Note: "fake-note"})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, ModerationReject, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UnknownDecision", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Decision: "unknown"})
+		require.Error(t, err)
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}))
+		mock.ExpectRollback()
+		_, err = ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Decision: ModerationReject})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("Draft", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationDraft))
+		mock.ExpectRollback()
+		_, err = ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Decision: ModerationApprove})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConnForReportUpdateQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationRejected))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Decision: ModerationReject})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationPending))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ModerateAsset(context.Background(), db, &AssetModeration{AssetID: "1", Decision: ModerationReject})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestEditedModerationStatus(t *testing.T) {
+	prev := &Asset{
+		DisplayName:      "foo",
+		Description:      "fake-description",
+		Category:         "fake-category",
+		Files:            FileCollection{"index.json": "kodo://builder/files/fake-key"},
+		Preview:          "kodo://builder/files/fake-preview",
+		ModerationStatus: ModerationVisible,
+	}
+
+	t.Run("Unchanged", func(t *testing.T) {
+		a := *prev
+		a.Category = "another-fake-category"
+		assert.Equal(t, ModerationVisible, editedModerationStatus(prev, &a))
+	})
+
+	t.Run("DisplayNameChanged", func(t *testing.T) {
+		a := *prev
+		a.DisplayName = "bar"
+		assert.Equal(t, ModerationPending, editedModerationStatus(prev, &a))
+	})
+
+	t.Run("DescriptionChanged", func(t *testing.T) {
+		a := *prev
+		a.Description = "another-fake-description"
+		assert.Equal(t, ModerationPending, editedModerationStatus(prev, &a))
+	})
+
+	t.Run("FilesChanged", func(t *testing.T) {
+		a := *prev
+		a.Files = FileCollection{"index.json": "kodo://builder/files/another-fake-key"}
+		assert.Equal(t, ModerationPending, editedModerationStatus(prev, &a))
+	})
+
+	t.Run("PreviewChanged", func(t *testing.T) {
+		a := *prev
+		a.Preview = "kodo://builder/files/another-fake-preview"
+		assert.Equal(t, ModerationPending, editedModerationStatus(prev, &a))
+	})
+
+	t.Run("NotApproved", func(t *testing.T) {
+		for _, status := range []ModerationStatus{ModerationDraft, ModerationPending, ModerationRejected, ModerationBroken} {
+			before := *prev
+			before.ModerationStatus = status
+			a := before
+			a.Files = FileCollection{"index.json": "kodo://builder/files/another-fake-key"}
+			assert.Equal(t, status, editedModerationStatus(&before, &a))
+		}
+	})
+}
+
+func TestSubmitAssetForReview(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationDraft))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \?`).
+			WithArgs(ModerationPending, "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision"}).
+				AddRow(1, 1, "fake-name", ModerationSubmit))
+		mock.ExpectCommit()
+		moderation, err := SubmitAssetForReview(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-name", Decision: ModerationSubmit})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, ModerationSubmit, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Resubmit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationRejected))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \?`).
+			WithArgs(ModerationPending, "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+		_, err = SubmitAssetForReview(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-name", Decision: ModerationSubmit})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadySubmitted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationPending))
+		mock.ExpectRollback()
+		_, err = SubmitAssetForReview(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-name", Decision: ModerationSubmit})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}))
+		mock.ExpectRollback()
+		_, err = SubmitAssetForReview(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-name", Decision: ModerationSubmit})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = SubmitAssetForReview(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-name", Decision: ModerationSubmit})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestReviewAsset(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationPending))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND status != \?`).
+			WithArgs(ModerationVisible, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE asset_report SET u_time = \?, state = \? WHERE asset_id = \? AND state = \?`).
+			WithArgs(sqlmock.AnyArg(), AssetReportDismissed, "1", AssetReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO asset_moderation`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_moderation WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "moderator", "decision"}).
+				AddRow(1, 1, "fake-admin", ModerationApprove))
+		mock.ExpectCommit()
+		moderation, err := ReviewAsset(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-admin", Decision: ModerationApprove})
+		require.NoError(t, err)
+		require.NotNil(t, moderation)
+		assert.Equal(t, ModerationApprove, moderation.Decision)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NeverSubmitted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT moderation_status FROM asset WHERE id = \? AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"moderation_status"}).AddRow(ModerationDraft))
+		mock.ExpectRollback()
+		_, err = ReviewAsset(context.Background(), db, &AssetModeration{AssetID: "1", Moderator: "fake-admin", Decision: ModerationApprove})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// DisplayNamePolicy is how a display name already taken by another asset of
+// the same owner is handled.
+type DisplayNamePolicy string
+
+const (
+	// DisplayNameReject rejects the name with a [DisplayNameConflictError].
+	DisplayNameReject DisplayNamePolicy = "reject"
+
+	// DisplayNameSuffix appends the smallest free suffix like " (2)" to the
+	// name.
+	DisplayNameSuffix DisplayNamePolicy = "suffix"
+)
+
+// DisplayNameConflictError is returned when a display name is already taken by
+// another asset of the same owner. It matches [ErrExist].
+type DisplayNameConflictError struct {
+	// DisplayName is the taken display name.
+	DisplayName string
+
+	// Suggestion is a free display name similar to DisplayName.
+	Suggestion string
+}
+
+// Error implements [error].
+func (e *DisplayNameConflictError) Error() string {
+	return fmt.Sprintf("display name %q already taken, try %q", e.DisplayName, e.Suggestion)
+}
+
+// Unwrap returns [ErrExist].
+func (e *DisplayNameConflictError) Unwrap() error {
+	return ErrExist
+}
+
+// displayNameLikeEscaper escapes wildcards of LIKE patterns.
+var displayNameLikeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// resolveDisplayName returns the display name to use for an asset of owner
+// named displayName according to policy. The asset with excludeID, if any, is
+// not considered taking any name.
+//
+// Matching assets are locked until tx ends, so concurrent transactions cannot
+// both take the same name.
+func resolveDisplayName(ctx context.Context, tx *sql.Tx, owner, displayName, excludeID string, policy DisplayNamePolicy) (string, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE owner = ? AND id != ? AND status != ? AND (display_name = ? OR display_name LIKE ?) FOR UPDATE", TableAsset)
+	assets, err := queryRows[Asset](ctx, tx, query, owner, excludeID, StatusDeleted, displayName, displayNameLikeEscaper.Replace(displayName)+" (%)")
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return "", err
+	}
+
+	// Display names are compared case-insensitively, like the collation of
+	// the column.
+	taken := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		taken[strings.ToLower(asset.DisplayName)] = true
+	}
+	if !taken[strings.ToLower(displayName)] {
+		return displayName, nil
+	}
+	var suggestion string
+	for i := 2; ; i++ {
+		suggestion = fmt.Sprintf("%s (%d)", displayName, i)
+		if !taken[strings.ToLower(suggestion)] {
+			break
+		}
+	}
+	if policy == DisplayNameReject {
+		return "", &DisplayNameConflictError{DisplayName: displayName, Suggestion: suggestion}
+	}
+	return suggestion, nil
+}
+
+// DisplayNameDuplicate is a display name shared by multiple assets of the same
+// owner.
+type DisplayNameDuplicate struct {
+	// Owner is the name of the owner.
+	Owner string `db:"owner" json:"owner"`
+
+	// DisplayName is the shared display name.
+	DisplayName string `db:"display_name" json:"displayName"`
+
+	// AssetCount is the number of assets sharing the display name.
+	AssetCount int `db:"asset_count" json:"assetCount"`
+}
+
+// ListDisplayNameDuplicates lists display names shared by multiple assets of
+// the same owner with given pagination, e.g., created before names were
+// deduplicated.
+func ListDisplayNameDuplicates(ctx context.Context, db *sql.DB, pagination Pagination) ([]DisplayNameDuplicate, error) {
+	logger := log.GetReqLogger(ctx)
+
+	offset := (pagination.Index - 1) * pagination.Size
+	query := fmt.Sprintf(
+		"SELECT owner, display_name, COUNT(*) AS asset_count FROM %s WHERE status != ? GROUP BY owner, display_name HAVING COUNT(*) > 1 ORDER BY asset_count DESC, owner ASC, display_name ASC LIMIT ?, ?",
+		TableAsset,
+	)
+	duplicates, err := queryRows[DisplayNameDuplicate](ctx, db, query, StatusDeleted, offset, pagination.Size)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	if duplicates == nil {
+		duplicates = []DisplayNameDuplicate{}
+	}
+	return duplicates, nil
+}
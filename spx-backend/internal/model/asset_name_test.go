@@ -0,0 +1,155 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resolveDisplayNameInTx runs resolveDisplayName in a transaction of db.
+func resolveDisplayNameInTx(t *testing.T, db *sql.DB, displayName string, policy DisplayNamePolicy) (string, error) {
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	return resolveDisplayName(context.Background(), tx, "fake-name", displayName, "1", policy)
+}
+
+func TestResolveDisplayName(t *testing.T) {
+	const nameQuery = `SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`
+
+	t.Run("Free", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(nameQuery).
+			WithArgs("fake-name", "1", StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "fake-asset (2)"))
+		displayName, err := resolveDisplayNameInTx(t, db, "fake-asset", DisplayNameReject)
+		require.NoError(t, err)
+		assert.Equal(t, "fake-asset", displayName)
+	})
+
+	t.Run("Suffix", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(nameQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "fake-asset").
+				AddRow(3, "fake-asset (2)").
+				AddRow(4, "fake-asset (4)"))
+		displayName, err := resolveDisplayNameInTx(t, db, "fake-asset", DisplayNameSuffix)
+		require.NoError(t, err)
+		assert.Equal(t, "fake-asset (3)", displayName)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(nameQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "fake-asset"))
+		_, err = resolveDisplayNameInTx(t, db, "fake-asset", DisplayNameReject)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrExist)
+		var conflictErr *DisplayNameConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, "fake-asset", conflictErr.DisplayName)
+		assert.Equal(t, "fake-asset (2)", conflictErr.Suggestion)
+	})
+
+	t.Run("CaseInsensitive", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(nameQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "FAKE-ASSET").
+				AddRow(3, "Fake-Asset (2)"))
+		displayName, err := resolveDisplayNameInTx(t, db, "fake-asset", DisplayNameSuffix)
+		require.NoError(t, err)
+		assert.Equal(t, "fake-asset (3)", displayName)
+	})
+
+	t.Run("EscapedPattern", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(nameQuery).
+			WithArgs("fake-name", "1", StatusDeleted, `100%_a\b`, `100\%\_a\\b (%)`).
+			WillReturnRows(mock.NewRows(nil))
+		displayName, err := resolveDisplayNameInTx(t, db, `100%_a\b`, DisplayNameSuffix)
+		require.NoError(t, err)
+		assert.Equal(t, `100%_a\b`, displayName)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(nameQuery).
+			WillReturnError(sql.ErrConnDone)
+		_, err = resolveDisplayNameInTx(t, db, "fake-asset", DisplayNameSuffix)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListDisplayNameDuplicates(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT owner, display_name, COUNT\(\*\) AS asset_count FROM asset WHERE status != \? GROUP BY owner, display_name HAVING COUNT\(\*\) > 1 ORDER BY asset_count DESC, owner ASC, display_name ASC LIMIT \?, \?`).
+			WithArgs(StatusDeleted, 10, 10).
+			WillReturnRows(mock.NewRows([]string{"owner", "display_name", "asset_count"}).
+				AddRow("fake-name", "fake-asset", 3))
+		duplicates, err := ListDisplayNameDuplicates(context.Background(), db, Pagination{Index: 2, Size: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []DisplayNameDuplicate{{Owner: "fake-name", DisplayName: "fake-asset", AssetCount: 3}}, duplicates)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT owner, display_name, COUNT\(\*\) AS asset_count FROM asset`).
+			WillReturnRows(mock.NewRows([]string{"owner", "display_name", "asset_count"}))
+		duplicates, err := ListDisplayNameDuplicates(context.Background(), db, Pagination{Index: 1, Size: 10})
+		require.NoError(t, err)
+		require.NotNil(t, duplicates)
+		assert.Empty(t, duplicates)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT owner, display_name, COUNT\(\*\) AS asset_count FROM asset`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListDisplayNameDuplicates(context.Background(), db, Pagination{Index: 1, Size: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
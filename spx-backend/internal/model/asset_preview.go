@@ -0,0 +1,45 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// TableAssetPreviewFailure is the table name of failed attempts to render
+// previews of assets in database. Each row counts the failed attempts of an
+// asset, so assets whose previews cannot be rendered are not retried forever.
+const TableAssetPreviewFailure = "asset_preview_failure"
+
+// AddAssetPreviewFailure records a failed attempt to render the preview of
+// asset with given id at given time. A permanent failure, e.g., of a format
+// that cannot be rendered, stops all further attempts.
+func AddAssetPreviewFailure(ctx context.Context, db *sql.DB, assetID string, t time.Time, permanent bool) error {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (asset_id, attempts, failed_at, permanent) VALUES (?, 1, ?, ?) ON DUPLICATE KEY UPDATE attempts = attempts + 1, failed_at = VALUES(failed_at), permanent = VALUES(permanent)",
+		TableAssetPreviewFailure,
+	)
+	if _, err := db.ExecContext(ctx, query, assetID, t, permanent); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteAssetPreviewFailure forgets failed attempts to render the preview of
+// asset with given id, e.g., after its files are updated.
+func DeleteAssetPreviewFailure(ctx context.Context, db *sql.DB, assetID string) error {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE asset_id = ?", TableAssetPreviewFailure)
+	if _, err := db.ExecContext(ctx, query, assetID); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAssetPreviewFailure(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectExec(`INSERT INTO asset_preview_failure \(asset_id, attempts, failed_at, permanent\) VALUES \(\?, 1, \?, \?\) ON DUPLICATE KEY UPDATE attempts = attempts \+ 1, failed_at = VALUES\(failed_at\), permanent = VALUES\(permanent\)`).
+			WithArgs("1", now, false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = AddAssetPreviewFailure(context.Background(), db, "1", now, false)
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_preview_failure`).
+			WillReturnError(sql.ErrConnDone)
+		err = AddAssetPreviewFailure(context.Background(), db, "1", time.Now(), true)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestDeleteAssetPreviewFailure(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`DELETE FROM asset_preview_failure WHERE asset_id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = DeleteAssetPreviewFailure(context.Background(), db, "1")
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`DELETE FROM asset_preview_failure WHERE asset_id = \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = DeleteAssetPreviewFailure(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// AssetReport is the model for a report of an inappropriate asset.
+type AssetReport struct {
+	// ID is the globally unique identifier.
+	ID string `db:"id" json:"id"`
+
+	// CTime is the creation time.
+	CTime time.Time `db:"c_time" json:"cTime"`
+
+	// UTime is the last update time.
+	UTime time.Time `db:"u_time" json:"uTime"`
+
+	// AssetID is the id of the reported asset.
+	AssetID string `db:"asset_id" json:"assetId"`
+
+	// Reporter is the name of the user who reported the asset.
+	Reporter string `db:"reporter" json:"reporter"`
+
+	// Reason is why the asset is reported.
+	Reason AssetReportReason `db:"reason" json:"reason"`
+
+	// Details is the free-form details provided by the reporter.
+	Details string `db:"details" json:"details"`
+
+	// State indicates if the report has been handled.
+	State AssetReportState `db:"state" json:"state"`
+
+	// Status indicates if the report is deleted.
+	Status Status `db:"status" json:"status"`
+}
+
+// TableAssetReport is the table name of [AssetReport] in database.
+const TableAssetReport = "asset_report"
+
+// AssetReportReason is the reason of an asset report.
+type AssetReportReason string
+
+const (
+	AssetReportSpam          AssetReportReason = "spam"
+	AssetReportInappropriate AssetReportReason = "inappropriate"
+	AssetReportCopyright     AssetReportReason = "copyright"
+	AssetReportOther         AssetReportReason = "other"
+)
+
+// AssetReportState is the handling state of an asset report.
+type AssetReportState int
+
+const (
+	AssetReportOpen AssetReportState = iota
+	AssetReportResolved
+	AssetReportDismissed
+)
+
+// AssetReportCount is the number of open reports of an asset with a reason.
+type AssetReportCount struct {
+	// AssetID is the id of the reported asset.
+	AssetID string `db:"asset_id" json:"assetId"`
+
+	// Reason is the reason of the reports.
+	Reason AssetReportReason `db:"reason" json:"reason"`
+
+	// Count is the number of open reports.
+	Count int `db:"count" json:"count"`
+}
+
+// CountAssetReportsByReporter counts reports created by reporter since given
+// time.
+func CountAssetReportsByReporter(ctx context.Context, db *sql.DB, reporter string, since time.Time) (int, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE reporter = ? AND c_time >= ?", TableAssetReport)
+	if err := db.QueryRowContext(ctx, query, reporter, since).Scan(&count); err != nil {
+		logger.Printf("db.QueryRowContext failed: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// AddAssetReport adds a report. It reports whether the report is added, i.e.,
+// the reporter has not reported the asset before.
+//
+// Once the asset has at least threshold open reports, it is moved to
+// [ModerationPending] in the same transaction, hiding it from public listings
+// until it is reviewed.
+func AddAssetReport(ctx context.Context, db *sql.DB, r *AssetReport, threshold int) (bool, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var added bool
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		// The unique key on (asset_id, reporter) deduplicates reports of the
+		// same reporter.
+		now := time.Now().UTC()
+		query := fmt.Sprintf("INSERT IGNORE INTO %s (c_time, u_time, asset_id, reporter, reason, details, state, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", TableAssetReport)
+		result, err := tx.ExecContext(ctx, query, now, now, r.AssetID, r.Reporter, r.Reason, r.Details, AssetReportOpen, StatusNormal)
+		if err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			logger.Printf("result.RowsAffected failed: %v", err)
+			return err
+		} else if rowsAffected == 0 {
+			return nil
+		}
+		added = true
+
+		var openReports int
+		query = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE asset_id = ? AND state = ? AND status != ?", TableAssetReport)
+		if err := tx.QueryRowContext(ctx, query, r.AssetID, AssetReportOpen, StatusDeleted).Scan(&openReports); err != nil {
+			logger.Printf("tx.QueryRowContext failed: %v", err)
+			return err
+		}
+		if openReports < threshold {
+			return nil
+		}
+		query = fmt.Sprintf("UPDATE %s SET moderation_status = ? WHERE id = ? AND moderation_status = ?", TableAsset)
+		if _, err := tx.ExecContext(ctx, query, ModerationPending, r.AssetID, ModerationVisible); err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+// ListAssetReports lists asset reports with given pagination, where conditions
+// and order by conditions.
+func ListAssetReports(ctx context.Context, db *sql.DB, paginaton Pagination, filters []FilterCondition, orderBy []OrderByCondition) (*ByPage[AssetReport], error) {
+	return QueryByPage[AssetReport](ctx, db, TableAssetReport, paginaton, filters, orderBy)
+}
+
+// OpenAssetReportsFilter returns a condition matching assets with at least one
+// open report.
+func OpenAssetReportsFilter() FilterCondition {
+	query := fmt.Sprintf("SELECT asset_id FROM %s WHERE state = ? AND status != ?", TableAssetReport)
+	return FilterCondition{Column: "id", Operation: "IN", Value: Subquery{Query: query, Args: []any{AssetReportOpen, StatusDeleted}}}
+}
+
+// ListOpenAssetReportCounts counts open reports of assets with given ids by
+// reason.
+func ListOpenAssetReportCounts(ctx context.Context, db *sql.DB, assetIDs []string) ([]AssetReportCount, error) {
+	logger := log.GetReqLogger(ctx)
+
+	if len(assetIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, len(assetIDs)+2)
+	for _, id := range assetIDs {
+		args = append(args, id)
+	}
+	args = append(args, AssetReportOpen, StatusDeleted)
+	query := fmt.Sprintf(
+		"SELECT asset_id, reason, COUNT(*) AS count FROM %s WHERE asset_id IN (%s) AND state = ? AND status != ? GROUP BY asset_id, reason ORDER BY asset_id ASC, reason ASC",
+		TableAssetReport, placeholders(len(assetIDs)),
+	)
+	counts, err := queryRows[AssetReportCount](ctx, db, query, args...)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return counts, nil
+}
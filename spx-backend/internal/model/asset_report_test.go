@@ -0,0 +1,234 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountAssetReportsByReporter(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		since := time.Now().Add(-time.Hour)
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE reporter = \? AND c_time >= \?`).
+			WithArgs("fake-name", since).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+		count, err := CountAssetReportsByReporter(context.Background(), db, "fake-name", since)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("ClosedConnForCountQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE reporter = \? AND c_time >= \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = CountAssetReportsByReporter(context.Background(), db, "fake-name", time.Now())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestAddAssetReport(t *testing.T) {
+	report := &AssetReport{
+		AssetID:  "1",
+		Reporter: "fake-name",
+		Reason:   AssetReportSpam,
+		Details:  "fake-details",
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report \(c_time, u_time, asset_id, reporter, reason, details, state, status\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "fake-name", AssetReportSpam, "fake-details", AssetReportOpen, StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE asset_id = \? AND state = \? AND status != \?`).
+			WithArgs("1", AssetReportOpen, StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectCommit()
+		added, err := AddAssetReport(context.Background(), db, report, 5)
+		require.NoError(t, err)
+		assert.True(t, added)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ThresholdReached", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE asset_id = \? AND state = \? AND status != \?`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(5))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND moderation_status = \?`).
+			WithArgs(ModerationPending, "1", ModerationVisible).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		added, err := AddAssetReport(context.Background(), db, report, 5)
+		require.NoError(t, err)
+		assert.True(t, added)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		added, err := AddAssetReport(context.Background(), db, report, 5)
+		require.NoError(t, err)
+		assert.False(t, added)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = AddAssetReport(context.Background(), db, report, 5)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForCountQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = AddAssetReport(context.Background(), db, report, 5)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_report`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(5))
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = AddAssetReport(context.Background(), db, report, 5)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListAssetReports(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE state = \? AND status != \?`).
+			WithArgs(AssetReportOpen, StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset_report WHERE state = \? AND status != \? ORDER BY c_time DESC LIMIT \?, \?`).
+			WithArgs(AssetReportOpen, StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "reporter", "reason"}).
+				AddRow(1, 1, "fake-name", AssetReportSpam))
+		reports, err := ListAssetReports(context.Background(), db, Pagination{Index: 1, Size: 10},
+			[]FilterCondition{{Column: "state", Operation: "=", Value: AssetReportOpen}},
+			[]OrderByCondition{{Column: "c_time", Direction: "DESC"}})
+		require.NoError(t, err)
+		require.NotNil(t, reports)
+		assert.Equal(t, 1, reports.Total)
+		require.Len(t, reports.Data, 1)
+		assert.Equal(t, AssetReportSpam, reports.Data[0].Reason)
+	})
+
+	t.Run("ClosedConnForCountQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_report WHERE status != \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListAssetReports(context.Background(), db, Pagination{Index: 1, Size: 10}, nil, nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestOpenAssetReportsFilter(t *testing.T) {
+	whereClause, args := buildWhereClause([]FilterCondition{OpenAssetReportsFilter()})
+	assert.Equal(t, "WHERE id IN (SELECT asset_id FROM asset_report WHERE state = ? AND status != ?) AND status != ?", whereClause)
+	assert.Equal(t, []any{AssetReportOpen, StatusDeleted, StatusDeleted}, args)
+}
+
+func TestListOpenAssetReportCounts(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT asset_id, reason, COUNT\(\*\) AS count FROM asset_report WHERE asset_id IN \(\?,\?\) AND state = \? AND status != \? GROUP BY asset_id, reason ORDER BY asset_id ASC, reason ASC`).
+			WithArgs("1", "2", AssetReportOpen, StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"asset_id", "reason", "count"}).
+				AddRow(1, AssetReportSpam, 2).
+				AddRow(2, AssetReportOther, 1))
+		counts, err := ListOpenAssetReportCounts(context.Background(), db, []string{"1", "2"})
+		require.NoError(t, err)
+		require.Len(t, counts, 2)
+		assert.Equal(t, AssetReportCount{AssetID: "1", Reason: AssetReportSpam, Count: 2}, counts[0])
+		assert.Equal(t, AssetReportCount{AssetID: "2", Reason: AssetReportOther, Count: 1}, counts[1])
+	})
+
+	t.Run("NoAssets", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		counts, err := ListOpenAssetReportCounts(context.Background(), db, nil)
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+
+	t.Run("ClosedConnForQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT asset_id, reason, COUNT\(\*\) AS count FROM asset_report`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListOpenAssetReportCounts(context.Background(), db, []string{"1"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// AssetStats is the figures of a single asset.
+type AssetStats struct {
+	// AssetID is the id of the asset.
+	AssetID string `db:"id" json:"assetId"`
+
+	// DisplayName is the display name of the asset.
+	DisplayName string `db:"display_name" json:"displayName"`
+
+	// ClickCount is the number of clicks on the asset.
+	ClickCount int64 `db:"click_count" json:"clickCount"`
+
+	// DownloadCount is the number of downloads of the asset.
+	DownloadCount int64 `db:"download_count" json:"downloadCount"`
+
+	// ForkCount is the number of forks of the asset.
+	ForkCount int64 `db:"fork_count" json:"forkCount"`
+}
+
+// AssetStatsTotal is the figures of a set of assets added up.
+type AssetStatsTotal struct {
+	// AssetCount is the number of assets.
+	AssetCount int64 `db:"asset_count" json:"assetCount"`
+
+	// ClickCount is the total number of clicks on the assets.
+	ClickCount int64 `db:"click_count" json:"clickCount"`
+
+	// DownloadCount is the total number of downloads of the assets.
+	DownloadCount int64 `db:"download_count" json:"downloadCount"`
+
+	// ForkCount is the total number of forks of the assets.
+	ForkCount int64 `db:"fork_count" json:"forkCount"`
+}
+
+// DailyCount is the number of events on a date.
+type DailyCount struct {
+	// Date is the date in UTC, formatted as "2006-01-02".
+	Date string `db:"date" json:"date"`
+
+	// Count is the number of events on the date.
+	Count int64 `db:"count" json:"count"`
+}
+
+// ListOwnerAssetStats lists figures of assets of owner, most clicked first.
+func ListOwnerAssetStats(ctx context.Context, db *sql.DB, owner string) ([]AssetStats, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("SELECT id, display_name, click_count, download_count, fork_count FROM %s WHERE owner = ? AND status != ? ORDER BY click_count DESC, id DESC", TableAsset)
+	stats, err := queryRows[AssetStats](ctx, db, query, owner, StatusDeleted)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	if stats == nil {
+		stats = []AssetStats{}
+	}
+	return stats, nil
+}
+
+// OwnerAssetStatsTotal adds up figures of assets of owner.
+func OwnerAssetStatsTotal(ctx context.Context, db *sql.DB, owner string) (*AssetStatsTotal, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf(
+		"SELECT COUNT(*) AS asset_count, COALESCE(SUM(click_count), 0) AS click_count, COALESCE(SUM(download_count), 0) AS download_count, COALESCE(SUM(fork_count), 0) AS fork_count FROM %s WHERE owner = ? AND status != ?",
+		TableAsset,
+	)
+	totals, err := queryRows[AssetStatsTotal](ctx, db, query, owner, StatusDeleted)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	if len(totals) == 0 {
+		return &AssetStatsTotal{}, nil
+	}
+	return &totals[0], nil
+}
+
+// ListOwnerDailyClicks lists the number of clicks on assets of owner per day
+// since the given time, ordered by date. Days without clicks are omitted.
+func ListOwnerDailyClicks(ctx context.Context, db *sql.DB, owner string, since time.Time) ([]DailyCount, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf(
+		"SELECT DATE_FORMAT(%[1]s.c_time, '%%Y-%%m-%%d') AS date, COUNT(*) AS count FROM %[1]s JOIN %[2]s ON %[2]s.id = %[1]s.asset_id WHERE %[2]s.owner = ? AND %[2]s.status != ? AND %[1]s.event_type = ? AND %[1]s.c_time >= ? GROUP BY date ORDER BY date ASC",
+		TableAssetEvent, TableAsset,
+	)
+	counts, err := queryRows[DailyCount](ctx, db, query, owner, StatusDeleted, AssetEventClick, since)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return counts, nil
+}
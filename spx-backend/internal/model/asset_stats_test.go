@@ -0,0 +1,112 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOwnerAssetStats(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT id, display_name, click_count, download_count, fork_count FROM asset WHERE owner = \? AND status != \? ORDER BY click_count DESC, id DESC`).
+			WithArgs("fake-name", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "click_count", "download_count", "fork_count"}).
+				AddRow("1", "fake-asset", 10, 3, 1))
+		stats, err := ListOwnerAssetStats(context.Background(), db, "fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, []AssetStats{{AssetID: "1", DisplayName: "fake-asset", ClickCount: 10, DownloadCount: 3, ForkCount: 1}}, stats)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT id, display_name, click_count, download_count, fork_count FROM asset`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "click_count", "download_count", "fork_count"}))
+		stats, err := ListOwnerAssetStats(context.Background(), db, "fake-name")
+		require.NoError(t, err)
+		require.NotNil(t, stats)
+		assert.Empty(t, stats)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT id, display_name, click_count, download_count, fork_count FROM asset`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListOwnerAssetStats(context.Background(), db, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestOwnerAssetStatsTotal(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) AS asset_count, COALESCE\(SUM\(click_count\), 0\) AS click_count, COALESCE\(SUM\(download_count\), 0\) AS download_count, COALESCE\(SUM\(fork_count\), 0\) AS fork_count FROM asset WHERE owner = \? AND status != \?`).
+			WithArgs("fake-name", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"asset_count", "click_count", "download_count", "fork_count"}).
+				AddRow(2, 15, 4, 1))
+		total, err := OwnerAssetStatsTotal(context.Background(), db, "fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, &AssetStatsTotal{AssetCount: 2, ClickCount: 15, DownloadCount: 4, ForkCount: 1}, total)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) AS asset_count`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = OwnerAssetStatsTotal(context.Background(), db, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListOwnerDailyClicks(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT DATE_FORMAT\(asset_event.c_time, '%Y-%m-%d'\) AS date, COUNT\(\*\) AS count FROM asset_event JOIN asset ON asset.id = asset_event.asset_id WHERE asset.owner = \? AND asset.status != \? AND asset_event.event_type = \? AND asset_event.c_time >= \? GROUP BY date ORDER BY date ASC`).
+			WithArgs("fake-name", StatusDeleted, AssetEventClick, since).
+			WillReturnRows(mock.NewRows([]string{"date", "count"}).
+				AddRow("2024-01-01", 3).
+				AddRow("2024-01-03", 1))
+		counts, err := ListOwnerDailyClicks(context.Background(), db, "fake-name", since)
+		require.NoError(t, err)
+		assert.Equal(t, []DailyCount{{Date: "2024-01-01", Count: 3}, {Date: "2024-01-03", Count: 1}}, counts)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT DATE_FORMAT`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListOwnerDailyClicks(context.Background(), db, "fake-name", since)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
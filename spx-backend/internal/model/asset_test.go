@@ -3,7 +3,11 @@ package model
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -39,6 +43,28 @@ func TestAssetByID(t *testing.T) {
 	})
 }
 
+func TestAssetLicense(t *testing.T) {
+	for _, tt := range []struct {
+		license   AssetLicense
+		valid     bool
+		remixable bool
+	}{
+		{LicenseCC0, true, true},
+		{LicenseCCBY, true, true},
+		{LicenseCCBYSA, true, true},
+		{LicenseCCBYNC, true, false},
+		{LicenseCCBYND, true, false},
+		{LicenseAllRightsReserved, true, false},
+		{"", false, false},
+		{"GPL", false, false},
+	} {
+		t.Run(string(tt.license), func(t *testing.T) {
+			assert.Equal(t, tt.valid, tt.license.IsValid())
+			assert.Equal(t, tt.remixable, tt.license.Remixable())
+		})
+	}
+}
+
 func TestListAssets(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -73,18 +99,59 @@ func TestListAssets(t *testing.T) {
 	})
 }
 
+func TestListAssetsWithDeleted(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset WHERE owner = \?$`).
+			WithArgs("fake-name").
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? ORDER BY id ASC LIMIT \?, \?`).
+			WithArgs("fake-name", 0, 1).
+			WillReturnRows(mock.NewRows([]string{"display_name", "status"}).
+				AddRow("foo", StatusDeleted))
+		assets, err := ListAssetsWithDeleted(context.Background(), db, Pagination{Index: 1, Size: 1}, []FilterCondition{{Column: "owner", Operation: "=", Value: "fake-name"}}, nil)
+		require.NoError(t, err)
+		require.Len(t, assets.Data, 1)
+		assert.Equal(t, StatusDeleted, assets.Data[0].Status)
+	})
+
+	t.Run("NoFilters", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset\s*$`).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(0))
+		mock.ExpectQuery(`SELECT \* FROM asset\s+ORDER BY id ASC LIMIT \?, \?`).
+			WithArgs(0, 1).
+			WillReturnRows(mock.NewRows([]string{"display_name"}))
+		assets, err := ListAssetsWithDeleted(context.Background(), db, Pagination{Index: 1, Size: 1}, nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, assets.Data)
+	})
+}
+
 func TestAddAsset(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"display_name"}).
 				AddRow("foo"))
-		asset, err := AddAsset(context.Background(), db, &Asset{DisplayName: "foo"})
+		mock.ExpectCommit()
+		asset, err := AddAsset(context.Background(), db, &Asset{DisplayName: "foo"}, DisplayNameSuffix)
 		require.NoError(t, err)
 		require.NotNil(t, asset)
 		assert.Equal(t, "foo", asset.DisplayName)
@@ -95,9 +162,13 @@ func TestAddAsset(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
 			WillReturnError(sql.ErrConnDone)
-		asset, err := AddAsset(context.Background(), db, &Asset{DisplayName: "foo"})
+		mock.ExpectRollback()
+		asset, err := AddAsset(context.Background(), db, &Asset{DisplayName: "foo"}, DisplayNameSuffix)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 		assert.Nil(t, asset)
@@ -110,57 +181,117 @@ func TestUpdateAssetByID(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,preview=\?,is_public=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), "foo", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "1").
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(1, "bar"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name", "editor"}).
+				AddRow(1, 1, "bar", "fake-name"))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WithArgs("1", 20).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), "foo", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), ModerationPending, "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
 			WillReturnRows(mock.NewRows([]string{"display_name"}).
 				AddRow("foo"))
-		asset, err := UpdateAssetByID(context.Background(), db, "1", &Asset{DisplayName: "foo"})
+		asset, err := UpdateAssetByID(context.Background(), db, "1", &Asset{DisplayName: "foo"}, "fake-name", 20, DisplayNameSuffix)
 		require.NoError(t, err)
 		require.NotNil(t, asset)
 		assert.Equal(t, "foo", asset.DisplayName)
 	})
 
+	t.Run("NoAsset", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows(nil))
+		mock.ExpectRollback()
+		asset, err := UpdateAssetByID(context.Background(), db, "1", &Asset{DisplayName: "foo"}, "fake-name", 20, DisplayNameSuffix)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+		assert.Nil(t, asset)
+	})
+
 	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,preview=\?,is_public=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), "foo", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "1").
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(1, "bar"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id"}).
+				AddRow(1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,description=\?,category=\?,asset_type=\?,files=\?,files_hash=\?,files_meta=\?,preview=\?,is_public=\?,license=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), "foo", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "1").
 			WillReturnError(sql.ErrConnDone)
-		asset, err := UpdateAssetByID(context.Background(), db, "1", &Asset{DisplayName: "foo"})
+		mock.ExpectRollback()
+		asset, err := UpdateAssetByID(context.Background(), db, "1", &Asset{DisplayName: "foo"}, "fake-name", 20, DisplayNameSuffix)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 		assert.Nil(t, asset)
 	})
 }
 
-func TestIncreaseAssetClickCount(t *testing.T) {
+func TestForkAsset(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time = \?, click_count = click_count \+ 1 WHERE id = \?`).
-			WithArgs(sqlmock.AnyArg(), "1").
-			WillReturnResult(sqlmock.NewResult(1, 1))
-		err = IncreaseAssetClickCount(context.Background(), db, "1")
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WithArgs("2", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "forked_from"}).
+				AddRow(2, "foo", "1"))
+		mock.ExpectExec(`UPDATE asset SET fork_count = fork_count \+ 1 WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		fork, err := ForkAsset(context.Background(), db, &Asset{DisplayName: "foo", ForkedFrom: "1"}, DisplayNameSuffix)
 		require.NoError(t, err)
+		require.NotNil(t, fork)
+		assert.Equal(t, "2", fork.ID)
+		assert.Equal(t, "1", fork.ForkedFrom)
 	})
 
-	t.Run("NotExist", func(t *testing.T) {
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time = \?, click_count = click_count \+ 1 WHERE id = \?`).
-			WithArgs(sqlmock.AnyArg(), "1").
-			WillReturnResult(sqlmock.NewResult(1, 0))
-		err = IncreaseAssetClickCount(context.Background(), db, "1")
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = ForkAsset(context.Background(), db, &Asset{DisplayName: "foo", ForkedFrom: "1"}, DisplayNameSuffix)
 		require.Error(t, err)
-		assert.ErrorIs(t, err, ErrNotExist)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
 	})
 
 	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
@@ -168,51 +299,1010 @@ func TestIncreaseAssetClickCount(t *testing.T) {
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time = \?, click_count = click_count \+ 1 WHERE id = \?`).
-			WithArgs(sqlmock.AnyArg(), "1").
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset`).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectExec(`UPDATE asset SET fork_count = fork_count \+ 1 WHERE id = \?`).
 			WillReturnError(sql.ErrConnDone)
-		err = IncreaseAssetClickCount(context.Background(), db, "1")
+		mock.ExpectRollback()
+		_, err = ForkAsset(context.Background(), db, &Asset{DisplayName: "foo", ForkedFrom: "1"}, DisplayNameSuffix)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 	})
+}
 
-	t.Run("ClosedConnForRowsAffected", func(t *testing.T) {
+func TestUpdateAssetVisibilityByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time = \?, click_count = click_count \+ 1 WHERE id = \?`).
-			WithArgs(sqlmock.AnyArg(), "1").
-			WillReturnResult(sqlmock.NewErrorResult(sql.ErrConnDone))
-		err = IncreaseAssetClickCount(context.Background(), db, "1")
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,is_public=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), Public, "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"display_name", "is_public"}).
+				AddRow("foo", Public))
+		asset, err := UpdateAssetVisibilityByID(context.Background(), db, "1", Public)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, Public, asset.IsPublic)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,is_public=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), Personal, "1").
+			WillReturnError(sql.ErrConnDone)
+		asset, err := UpdateAssetVisibilityByID(context.Background(), db, "1", Personal)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, asset)
 	})
 }
 
-func TestDeleteAssetByID(t *testing.T) {
+func TestUpdateAssetDisplayNameByID(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,status=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), StatusDeleted, "1").
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "foo", "fake-name"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WithArgs("fake-name", "1", StatusDeleted, "bar", "bar (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		err = DeleteAssetByID(context.Background(), db, "1")
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name", "editor"}).
+				AddRow(1, 1, "foo", "fake-name"))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WithArgs("1", 20).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), "bar", ModerationPending, "1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"display_name"}).
+				AddRow("bar"))
+		asset, err := UpdateAssetDisplayNameByID(context.Background(), db, "1", "bar", "fake-name", 20, DisplayNameSuffix)
 		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "bar", asset.DisplayName)
 	})
 
-	t.Run("ClosedConnForDeleteQuery", func(t *testing.T) {
+	t.Run("Conflict", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(`UPDATE asset SET u_time=\?,status=\? WHERE id=\?`).
-			WithArgs(sqlmock.AnyArg(), StatusDeleted, "1").
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "foo", "fake-name"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow(2, "bar"))
+		mock.ExpectRollback()
+		asset, err := UpdateAssetDisplayNameByID(context.Background(), db, "1", "bar", "fake-name", 20, DisplayNameReject)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrExist)
+		assert.Nil(t, asset)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow(1, "foo", "fake-name"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name", "editor"}).
+				AddRow(1, 1, "foo", "fake-name"))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WithArgs("1", 20).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		mock.ExpectExec(`UPDATE asset SET u_time=\?,display_name=\?,moderation_status=\? WHERE id=\?`).
+			WithArgs(sqlmock.AnyArg(), "bar", sqlmock.AnyArg(), "1").
 			WillReturnError(sql.ErrConnDone)
-		err = DeleteAssetByID(context.Background(), db, "1")
+		mock.ExpectRollback()
+		asset, err := UpdateAssetDisplayNameByID(context.Background(), db, "1", "bar", "fake-name", 20, DisplayNameSuffix)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, asset)
+	})
+}
+
+func TestListAssetsByIDs(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?,\?\) AND status != \?`).
+			WithArgs("1", "2", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow("2", "bar").
+				AddRow("1", "foo"))
+		assets, err := ListAssetsByIDs(context.Background(), db, []string{"1", "2"})
+		require.NoError(t, err)
+		require.Len(t, assets, 2)
+		assert.Equal(t, "bar", assets[0].DisplayName)
+		assert.Equal(t, "foo", assets[1].DisplayName)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\) AND status != \?`).
+			WillReturnError(sql.ErrConnDone)
+		assets, err := ListAssetsByIDs(context.Background(), db, []string{"1"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, assets)
+	})
+}
+
+func TestListAssetsByIDsWithDeleted(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?,\?\)$`).
+			WithArgs("1", "2").
+			WillReturnRows(mock.NewRows([]string{"id", "status"}).
+				AddRow("1", StatusNormal).
+				AddRow("2", StatusDeleted))
+		assets, err := ListAssetsByIDsWithDeleted(context.Background(), db, []string{"1", "2"})
+		require.NoError(t, err)
+		require.Len(t, assets, 2)
+		assert.Equal(t, StatusDeleted, assets[1].Status)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\)$`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListAssetsByIDsWithDeleted(context.Background(), db, []string{"1"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListAssetCategories(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COALESCE\(NULLIF\(TRIM\(category\), ''\), \?\) AS category, COUNT\(\*\) AS asset_count FROM asset WHERE is_public = \? AND status != \? GROUP BY 1 ORDER BY asset_count DESC, category ASC`).
+			WithArgs(UncategorizedCategory, Public, StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"category", "asset_count"}).
+				AddRow("animals", 3).
+				AddRow(UncategorizedCategory, 2))
+		categoryCounts, err := ListAssetCategories(context.Background(), db, []FilterCondition{{Column: "is_public", Operation: "=", Value: Public}})
+		require.NoError(t, err)
+		assert.Equal(t, []CategoryCount{
+			{Category: "animals", AssetCount: 3},
+			{Category: UncategorizedCategory, AssetCount: 2},
+		}, categoryCounts)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COALESCE\(NULLIF\(TRIM\(category\), ''\), \?\) AS category`).
+			WillReturnRows(mock.NewRows([]string{"category", "asset_count"}))
+		categoryCounts, err := ListAssetCategories(context.Background(), db, nil)
+		require.NoError(t, err)
+		require.NotNil(t, categoryCounts)
+		assert.Empty(t, categoryCounts)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COALESCE\(NULLIF\(TRIM\(category\), ''\), \?\) AS category`).
+			WillReturnError(sql.ErrConnDone)
+		categoryCounts, err := ListAssetCategories(context.Background(), db, nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, categoryCounts)
+	})
+}
+
+// pivotRecorder is a [sqlmock.Argument] recording the values it matches.
+type pivotRecorder struct {
+	pivots []int64
+}
+
+// Match implements [sqlmock.Argument].
+func (r *pivotRecorder) Match(v driver.Value) bool {
+	pivot, ok := v.(int64)
+	if ok {
+		r.pivots = append(r.pivots, pivot)
+	}
+	return ok
+}
+
+func TestListRandomAssets(t *testing.T) {
+	filters := []FilterCondition{{Column: "asset_type", Operation: "=", Value: AssetTypeBackdrop}}
+
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT MIN\(id\), MAX\(id\) FROM asset WHERE asset_type = \? AND status != \?`).
+			WithArgs(AssetTypeBackdrop, StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"MIN(id)", "MAX(id)"}).
+				AddRow(1, 10))
+		for _, id := range []string{"3", "3", "7"} {
+			mock.ExpectQuery(`SELECT \* FROM asset WHERE id >= \? AND asset_type = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+				WithArgs(sqlmock.AnyArg(), AssetTypeBackdrop, StatusDeleted).
+				WillReturnRows(mock.NewRows([]string{"id"}).
+					AddRow(id))
+		}
+		assets, err := ListRandomAssets(context.Background(), db, filters, 2)
+		require.NoError(t, err)
+		require.Len(t, assets, 2)
+		assert.Equal(t, "3", assets[0].ID)
+		assert.Equal(t, "7", assets[1].ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoAssets", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT MIN\(id\), MAX\(id\) FROM asset WHERE asset_type = \? AND status != \?`).
+			WillReturnRows(mock.NewRows([]string{"MIN(id)", "MAX(id)"}).
+				AddRow(nil, nil))
+		assets, err := ListRandomAssets(context.Background(), db, filters, 2)
+		require.NoError(t, err)
+		require.NotNil(t, assets)
+		assert.Empty(t, assets)
+	})
+
+	t.Run("TooFewAssets", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT MIN\(id\), MAX\(id\) FROM asset WHERE asset_type = \? AND status != \?`).
+			WillReturnRows(mock.NewRows([]string{"MIN(id)", "MAX(id)"}).
+				AddRow(5, 5))
+		for i := 0; i < 2*randomAssetAttemptsFactor; i++ {
+			mock.ExpectQuery(`SELECT \* FROM asset WHERE id >= \? AND asset_type = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+				WithArgs(int64(5), AssetTypeBackdrop, StatusDeleted).
+				WillReturnRows(mock.NewRows([]string{"id"}).
+					AddRow("5"))
+		}
+		assets, err := ListRandomAssets(context.Background(), db, filters, 2)
+		require.NoError(t, err)
+		require.Len(t, assets, 1)
+		assert.Equal(t, "5", assets[0].ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UniformPivots", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		// With dense ids, the sampled asset is the one at the pivot, so
+		// uniform pivots mean uniformly sampled assets.
+		const minID, maxID, samples = 1, 10, 2000
+		recorder := &pivotRecorder{}
+		for i := 0; i < samples; i++ {
+			mock.ExpectQuery(`SELECT MIN\(id\), MAX\(id\) FROM asset`).
+				WillReturnRows(mock.NewRows([]string{"MIN(id)", "MAX(id)"}).
+					AddRow(minID, maxID))
+			mock.ExpectQuery(`SELECT \* FROM asset WHERE id >= \?`).
+				WithArgs(recorder, AssetTypeBackdrop, StatusDeleted).
+				WillReturnRows(mock.NewRows([]string{"id"}).
+					AddRow("1"))
+			_, err := ListRandomAssets(context.Background(), db, filters, 1)
+			require.NoError(t, err)
+		}
+
+		counts := make(map[int64]int, maxID-minID+1)
+		for _, pivot := range recorder.pivots {
+			require.GreaterOrEqual(t, pivot, int64(minID))
+			require.LessOrEqual(t, pivot, int64(maxID))
+			counts[pivot]++
+		}
+		expected := samples / (maxID - minID + 1)
+		for id := int64(minID); id <= maxID; id++ {
+			// About 6 standard deviations away from the expectation.
+			assert.InDelta(t, expected, counts[id], 80, "id %d", id)
+		}
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT MIN\(id\), MAX\(id\) FROM asset WHERE asset_type = \? AND status != \?`).
+			WillReturnError(sql.ErrConnDone)
+		assets, err := ListRandomAssets(context.Background(), db, filters, 2)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, assets)
+	})
+}
+
+func TestListAssetsWithoutFilesMeta(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND files_meta IS NULL AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", StatusDeleted, 100).
+			WillReturnRows(mock.NewRows([]string{"id", "files"}).
+				AddRow("1", []byte(`{"a":"kodo://builder/a"}`)))
+		assets, err := ListAssetsWithoutFilesMeta(context.Background(), db, "", 100)
+		require.NoError(t, err)
+		require.Len(t, assets, 1)
+		assert.Equal(t, "1", assets[0].ID)
+		assert.Nil(t, assets[0].FilesMeta)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND files_meta IS NULL AND status != \? ORDER BY id ASC LIMIT \?`).
+			WillReturnError(sql.ErrConnDone)
+		assets, err := ListAssetsWithoutFilesMeta(context.Background(), db, "1", 100)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, assets)
+	})
+}
+
+func TestUpdateAssetFilesMetaByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET files_meta = \? WHERE id = \?`).
+			WithArgs([]byte(`{"a":{"size":1,"contentType":"text/plain"}}`), "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = UpdateAssetFilesMetaByID(context.Background(), db, "1", FileMetaCollection{"a": {Size: 1, ContentType: "text/plain"}})
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET files_meta = \? WHERE id = \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = UpdateAssetFilesMetaByID(context.Background(), db, "1", FileMetaCollection{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListAssetsWithoutPreview(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND \(preview IS NULL OR preview = ''\) AND asset_type IN \(\?, \?, \?\) AND id NOT IN \(SELECT asset_id FROM asset_preview_failure WHERE permanent = 1 OR attempts >= \? OR failed_at > DATE_SUB\(\?, INTERVAL \(1 << \(attempts - 1\)\) HOUR\)\) AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", AssetTypeSprite, AssetTypeBackdrop, AssetTypeSound, 8, now, StatusDeleted, 100).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_type", "preview"}).
+				AddRow("1", AssetTypeSprite, ""))
+		assets, err := ListAssetsWithoutPreview(context.Background(), db, "", 100, 8, now)
+		require.NoError(t, err)
+		require.Len(t, assets, 1)
+		assert.Equal(t, "1", assets[0].ID)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND \(preview IS NULL OR preview = ''\) AND asset_type IN \(\?, \?, \?\) AND id NOT IN \(SELECT asset_id FROM asset_preview_failure WHERE permanent = 1 OR attempts >= \? OR failed_at > DATE_SUB\(\?, INTERVAL \(1 << \(attempts - 1\)\) HOUR\)\) AND status != \? ORDER BY id ASC LIMIT \?`).
+			WillReturnError(sql.ErrConnDone)
+		assets, err := ListAssetsWithoutPreview(context.Background(), db, "1", 100, 8, time.Now())
 		require.Error(t, err)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, assets)
+	})
+}
+
+func TestUpdateAssetPreviewByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WithArgs("kodo://builder/previews/a.png", "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = UpdateAssetPreviewByID(context.Background(), db, "1", "kodo://builder/previews/a.png")
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET preview = \? WHERE id = \? AND \(preview IS NULL OR preview = ''\)`).
+			WillReturnError(sql.ErrConnDone)
+		err = UpdateAssetPreviewByID(context.Background(), db, "1", "kodo://builder/previews/a.png")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestListAssetsAfter(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("1", StatusDeleted, 100).
+			WillReturnRows(mock.NewRows([]string{"id"}).
+				AddRow("2").
+				AddRow("3"))
+		assets, err := ListAssetsAfter(context.Background(), db, "1", 100)
+		require.NoError(t, err)
+		require.Len(t, assets, 2)
+		assert.Equal(t, "2", assets[0].ID)
+		assert.Equal(t, "3", assets[1].ID)
+	})
+
+	t.Run("FromFirst", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \? AND status != \? ORDER BY id ASC LIMIT \?`).
+			WithArgs("0", StatusDeleted, 100).
+			WillReturnRows(mock.NewRows([]string{"id"}).
+				AddRow("1"))
+		assets, err := ListAssetsAfter(context.Background(), db, "", 100)
+		require.NoError(t, err)
+		require.Len(t, assets, 1)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id > \?`).
+			WillReturnError(sql.ErrConnDone)
+		assets, err := ListAssetsAfter(context.Background(), db, "1", 100)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, assets)
+	})
+}
+
+func TestSetAssetBrokenByID(t *testing.T) {
+	t.Run("Broken", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND moderation_status = \?`).
+			WithArgs(ModerationBroken, "1", ModerationVisible).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = SetAssetBrokenByID(context.Background(), db, "1", true)
+		require.NoError(t, err)
+	})
+
+	t.Run("Intact", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \? WHERE id = \? AND moderation_status = \?`).
+			WithArgs(ModerationVisible, "1", ModerationBroken).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		err = SetAssetBrokenByID(context.Background(), db, "1", false)
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET moderation_status = \?`).
+			WillReturnError(sql.ErrConnDone)
+		err = SetAssetBrokenByID(context.Background(), db, "1", true)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestIncrementAssetClickCount(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(11, 1))
+		mock.ExpectExec(`INSERT INTO asset_event \(c_time, asset_id, event_type\) VALUES \(\?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", AssetEventClick).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE`).
+			WithArgs("1", sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		clickCount, err := IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), clickCount)
+	})
+
+	t.Run("KeepsUpdateTime", func(t *testing.T) {
+		// Clicks must not touch u_time, or popular assets would float to the
+		// top of listings ordered by update time without being edited.
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(expectedSQL, actualSQL string) error {
+			if strings.HasPrefix(actualSQL, "UPDATE asset ") && strings.Contains(actualSQL, "u_time") {
+				return fmt.Errorf("click updates u_time: %s", actualSQL)
+			}
+			return sqlmock.QueryMatcherRegexp.Match(expectedSQL, actualSQL)
+		})))
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count`).
+			WillReturnResult(sqlmock.NewResult(11, 1))
+		mock.ExpectExec(`INSERT INTO asset_event`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO asset_view_daily`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DuplicateClick", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT click_count FROM asset WHERE id = \?`).
+			WithArgs("1").
+			WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(10))
+		mock.ExpectExec(`INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE`).
+			WithArgs("1", sqlmock.AnyArg(), 0).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		clickCount, err := IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), clickCount)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DuplicateClickNotExist", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT click_count FROM asset WHERE id = \?`).
+			WillReturnRows(sqlmock.NewRows([]string{"click_count"}))
+		mock.ExpectRollback()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(1, 0))
+		mock.ExpectRollback()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("ClosedConnForClickInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForRowsAffected", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewErrorResult(sql.ErrConnDone))
+		mock.ExpectRollback()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForEventInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", "user:fake-name", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO asset_event \(c_time, asset_id, event_type\) VALUES \(\?, \?, \?\)`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+
+	t.Run("ClosedConnForViewUpsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT IGNORE INTO asset_click \(c_time, asset_id, viewer, click_date\) VALUES \(\?, \?, \?, \?\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT click_count FROM asset WHERE id = \?`).
+			WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(10))
+		mock.ExpectExec(`INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = IncrementAssetClickCount(context.Background(), db, "1", "user:fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+// TestIncrementAssetCountIsSingleUpdate checks the shape of the queries of an
+// increment only. It cannot detect lost updates under concurrency, which rely
+// on the database applying the single UPDATE statement atomically.
+func TestIncrementAssetCountIsSingleUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// No read-modify-write queries are expected, the new count is read back
+	// from LAST_INSERT_ID of the update itself.
+	mock.ExpectExec(`^UPDATE asset SET click_count = LAST_INSERT_ID\(click_count \+ 1\) WHERE id = \?$`).
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(42, 1))
+	clickCount, err := incrementAssetCount(context.Background(), db, "1", "click_count")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), clickCount)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIncrementAssetDownloadCount(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE asset SET download_count = LAST_INSERT_ID\(download_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(4, 1))
+		mock.ExpectExec(`INSERT INTO asset_event \(c_time, asset_id, event_type\) VALUES \(\?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), "1", AssetEventDownload).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		downloadCount, err := IncrementAssetDownloadCount(context.Background(), db, "1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), downloadCount)
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE asset SET download_count = LAST_INSERT_ID\(download_count \+ 1\) WHERE id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		_, err = IncrementAssetDownloadCount(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+}
+
+func TestDeleteAssetByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`^UPDATE asset SET deleted_at = \?, status = \? WHERE id = \? AND status != \?$`).
+			WithArgs(sqlmock.AnyArg(), StatusDeleted, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = DeleteAssetByID(context.Background(), db, "1")
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyDeleted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET deleted_at = \?, status = \? WHERE id = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), StatusDeleted, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		err = DeleteAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("ClosedConnForDeleteQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET deleted_at = \?, status = \? WHERE id = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), StatusDeleted, "1", StatusDeleted).
+			WillReturnError(sql.ErrConnDone)
+		err = DeleteAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestDeleteOwnedAssets(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?,\?,\?\) AND status != \? FOR UPDATE`).
+			WithArgs("1", "2", "3", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "owner"}).
+				AddRow(1, "fake-name").
+				AddRow(2, "another-fake-name"))
+		mock.ExpectExec(`UPDATE asset SET deleted_at = \?, status = \? WHERE id IN \(\?\)`).
+			WithArgs(sqlmock.AnyArg(), StatusDeleted, "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		owners, err := DeleteOwnedAssets(context.Background(), db, []string{"1", "2", "3"}, "fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"1": "fake-name", "2": "another-fake-name"}, owners)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoneOwned", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\) AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner"}).
+				AddRow(1, "another-fake-name"))
+		mock.ExpectCommit()
+		owners, err := DeleteOwnedAssets(context.Background(), db, []string{"1"}, "fake-name")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"1": "another-fake-name"}, owners)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id IN \(\?\) AND status != \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "owner"}).
+				AddRow(1, "fake-name"))
+		mock.ExpectExec(`UPDATE asset SET deleted_at = \?, status = \? WHERE id IN \(\?\)`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		_, err = DeleteOwnedAssets(context.Background(), db, []string{"1"}, "fake-name")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestDeletedAssetByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"display_name", "deleted_at", "status"}).
+				AddRow("foo", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), StatusDeleted))
+		asset, err := DeletedAssetByID(context.Background(), db, "1")
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, "foo", asset.DisplayName)
+		require.NotNil(t, asset.DeletedAt)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *asset.DeletedAt)
+		assert.Equal(t, StatusDeleted, asset.Status)
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows(nil))
+		asset, err := DeletedAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+		assert.Nil(t, asset)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status = \? LIMIT 1`).
+			WithArgs("1", StatusDeleted).
+			WillReturnError(sql.ErrConnDone)
+		asset, err := DeletedAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, asset)
+	})
+}
+
+func TestRestoreAssetByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		// The update time is left untouched.
+		mock.ExpectExec(`^UPDATE asset SET deleted_at = NULL, status = \? WHERE id = \? AND status = \?$`).
+			WithArgs(StatusNormal, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"display_name", "deleted_at", "status"}).
+				AddRow("foo", nil, StatusNormal))
+		asset, err := RestoreAssetByID(context.Background(), db, "1")
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.Equal(t, StatusNormal, asset.Status)
+		assert.Nil(t, asset.DeletedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET deleted_at = NULL, status = \? WHERE id = \? AND status = \?`).
+			WithArgs(StatusNormal, "1", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		asset, err := RestoreAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+		assert.Nil(t, asset)
+	})
+
+	t.Run("ClosedConnForUpdateQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`UPDATE asset SET deleted_at = NULL, status = \? WHERE id = \? AND status = \?`).
+			WithArgs(StatusNormal, "1", StatusDeleted).
+			WillReturnError(sql.ErrConnDone)
+		asset, err := RestoreAssetByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, asset)
 	})
 }
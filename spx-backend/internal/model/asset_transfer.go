@@ -0,0 +1,128 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// AssetTransfer is the model for an audit record of an ownership transfer of
+// an asset.
+type AssetTransfer struct {
+	// ID is the globally unique identifier.
+	ID string `db:"id" json:"id"`
+
+	// CTime is the creation time.
+	CTime time.Time `db:"c_time" json:"cTime"`
+
+	// UTime is the last update time.
+	UTime time.Time `db:"u_time" json:"uTime"`
+
+	// AssetID is the id of the transferred asset.
+	AssetID string `db:"asset_id" json:"assetId"`
+
+	// FromOwner is the name of the owner before the transfer.
+	FromOwner string `db:"from_owner" json:"fromOwner"`
+
+	// ToOwner is the name of the owner after the transfer.
+	ToOwner string `db:"to_owner" json:"toOwner"`
+
+	// Actor is the name of the user who made the transfer, either FromOwner
+	// or an admin.
+	Actor string `db:"actor" json:"actor"`
+
+	// Status indicates if the record is deleted.
+	Status Status `db:"status" json:"status"`
+}
+
+// TableAssetTransfer is the table name of [AssetTransfer] in database.
+const TableAssetTransfer = "asset_transfer"
+
+// transferAsset moves asset from fromOwner to toOwner and records the
+// transfer. A display name already taken by another asset of toOwner is
+// handled according to policy. Returns `ErrNotExist` if the asset does not
+// exist or is not owned by fromOwner.
+func transferAsset(ctx context.Context, tx *sql.Tx, asset *Asset, fromOwner, toOwner, actor string, t time.Time, policy DisplayNamePolicy) error {
+	logger := log.GetReqLogger(ctx)
+
+	displayName, err := resolveDisplayName(ctx, tx, toOwner, asset.DisplayName, asset.ID, policy)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET u_time = ?, owner = ?, display_name = ? WHERE id = ? AND owner = ? AND status != ?", TableAsset)
+	result, err := tx.ExecContext(ctx, query, t, toOwner, displayName, asset.ID, fromOwner, StatusDeleted)
+	if err != nil {
+		logger.Printf("tx.ExecContext failed: %v", err)
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Printf("result.RowsAffected failed: %v", err)
+		return err
+	} else if rowsAffected == 0 {
+		return ErrNotExist
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (c_time, u_time, asset_id, from_owner, to_owner, actor, status) VALUES (?, ?, ?, ?, ?, ?, ?)", TableAssetTransfer)
+	if _, err := tx.ExecContext(ctx, query, t, t, asset.ID, fromOwner, toOwner, actor, StatusNormal); err != nil {
+		logger.Printf("tx.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// TransferAsset moves asset with given id from fromOwner to toOwner on behalf
+// of actor, and records the transfer. A display name already taken by another
+// asset of toOwner is handled according to policy. Returns `ErrNotExist` if
+// the asset does not exist or is not owned by fromOwner.
+func TransferAsset(ctx context.Context, db *sql.DB, id, fromOwner, toOwner, actor string, policy DisplayNamePolicy) error {
+	logger := log.GetReqLogger(ctx)
+
+	return runInTx(ctx, db, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT * FROM %s WHERE id = ? AND owner = ? AND status != ? FOR UPDATE", TableAsset)
+		assets, err := queryRows[Asset](ctx, tx, query, id, fromOwner, StatusDeleted)
+		if err != nil {
+			logger.Printf("queryRows failed: %v", err)
+			return err
+		} else if len(assets) == 0 {
+			return ErrNotExist
+		}
+		return transferAsset(ctx, tx, &assets[0], fromOwner, toOwner, actor, time.Now().UTC(), policy)
+	})
+}
+
+// TransferAssets moves at most limit assets from fromOwner to toOwner on behalf
+// of actor in a single transaction, recording each transfer. Display names
+// already taken by other assets of toOwner are handled according to policy. It
+// returns the number of assets moved, which is less than limit once all are
+// moved.
+func TransferAssets(ctx context.Context, db *sql.DB, fromOwner, toOwner, actor string, limit int, policy DisplayNamePolicy) (int, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var n int
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT * FROM %s WHERE owner = ? AND status != ? ORDER BY id ASC LIMIT ? FOR UPDATE", TableAsset)
+		assets, err := queryRows[Asset](ctx, tx, query, fromOwner, StatusDeleted, limit)
+		if err != nil {
+			logger.Printf("queryRows failed: %v", err)
+			return err
+		}
+
+		now := time.Now().UTC()
+		for i := range assets {
+			if err := transferAsset(ctx, tx, &assets[i], fromOwner, toOwner, actor, now, policy); err != nil {
+				logger.Printf("transferAsset failed: %v", err)
+				return err
+			}
+		}
+		n = len(assets)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
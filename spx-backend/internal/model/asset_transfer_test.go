@@ -0,0 +1,201 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferAsset(t *testing.T) {
+	const (
+		selectQuery = `SELECT \* FROM asset WHERE id = \? AND owner = \? AND status != \? FOR UPDATE`
+		nameQuery   = `SELECT \* FROM asset WHERE owner = \? AND id != \? AND status != \? AND \(display_name = \? OR display_name LIKE \?\) FOR UPDATE`
+		updateQuery = `UPDATE asset SET u_time = \?, owner = \?, display_name = \? WHERE id = \? AND owner = \? AND status != \?`
+	)
+
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).
+			WithArgs("1", "alice", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow("1", "fake-asset", "alice"))
+		mock.ExpectQuery(nameQuery).
+			WithArgs("bob", "1", StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(updateQuery).
+			WithArgs(sqlmock.AnyArg(), "bob", "fake-asset", "1", "alice", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer \(c_time, u_time, asset_id, from_owner, to_owner, actor, status\) VALUES \(\?, \?, \?, \?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "alice", "bob", "admin", StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		err = TransferAsset(context.Background(), db, "1", "alice", "bob", "admin", DisplayNameReject)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DisplayNameSuffix", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow("1", "fake-asset", "alice"))
+		mock.ExpectQuery(nameQuery).
+			WithArgs("bob", "1", StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow("2", "fake-asset"))
+		mock.ExpectExec(updateQuery).
+			WithArgs(sqlmock.AnyArg(), "bob", "fake-asset (2)", "1", "alice", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		err = TransferAsset(context.Background(), db, "1", "alice", "bob", "alice", DisplayNameSuffix)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DisplayNameReject", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow("1", "fake-asset", "alice"))
+		mock.ExpectQuery(nameQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow("2", "fake-asset"))
+		mock.ExpectRollback()
+		err = TransferAsset(context.Background(), db, "1", "alice", "bob", "alice", DisplayNameReject)
+		require.Error(t, err)
+		var conflictErr *DisplayNameConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, "fake-asset (2)", conflictErr.Suggestion)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}))
+		mock.ExpectRollback()
+		err = TransferAsset(context.Background(), db, "1", "alice", "bob", "alice", DisplayNameReject)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow("1", "fake-asset", "alice"))
+		mock.ExpectQuery(nameQuery).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(updateQuery).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		err = TransferAsset(context.Background(), db, "1", "alice", "bob", "alice", DisplayNameReject)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestTransferAssets(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND status != \? ORDER BY id ASC LIMIT \? FOR UPDATE`).
+			WithArgs("alice", StatusDeleted, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow("1", "fake-asset", "alice").
+				AddRow("2", "fake-asset", "alice"))
+		// The second asset sees the first one already moved to bob in the
+		// same transaction.
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \?`).
+			WithArgs("bob", "1", StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}))
+		mock.ExpectExec(`UPDATE asset SET u_time = \?, owner = \?, display_name = \? WHERE id = \? AND owner = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), "bob", "fake-asset", "1", "alice", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "alice", "bob", "alice", StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \?`).
+			WithArgs("bob", "2", StatusDeleted, "fake-asset", "fake-asset (%)").
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow("1", "fake-asset"))
+		mock.ExpectExec(`UPDATE asset SET u_time = \?, owner = \?, display_name = \? WHERE id = \? AND owner = \? AND status != \?`).
+			WithArgs(sqlmock.AnyArg(), "bob", "fake-asset (2)", "2", "alice", StatusDeleted).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO asset_transfer`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "2", "alice", "bob", "alice", StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		n, err := TransferAssets(context.Background(), db, "alice", "bob", "alice", 10, DisplayNameSuffix)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DisplayNameReject", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND status != \? ORDER BY id ASC LIMIT \? FOR UPDATE`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name", "owner"}).
+				AddRow("1", "fake-asset", "alice"))
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \? AND id != \?`).
+			WillReturnRows(mock.NewRows([]string{"id", "display_name"}).
+				AddRow("2", "fake-asset"))
+		mock.ExpectRollback()
+		n, err := TransferAssets(context.Background(), db, "alice", "bob", "alice", 10, DisplayNameReject)
+		require.Error(t, err)
+		var conflictErr *DisplayNameConflictError
+		assert.ErrorAs(t, err, &conflictErr)
+		assert.Zero(t, n)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConnForSelectQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM asset WHERE owner = \?`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		n, err := TransferAssets(context.Background(), db, "alice", "bob", "alice", 10, DisplayNameReject)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Zero(t, n)
+	})
+}
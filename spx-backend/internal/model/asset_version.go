@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// AssetVersion is the model for a snapshot of an asset taken right before it
+// was updated.
+type AssetVersion struct {
+	// ID is the globally unique identifier.
+	ID string `db:"id" json:"id"`
+
+	// CTime is the creation time, i.e., when the snapshot was replaced.
+	CTime time.Time `db:"c_time" json:"cTime"`
+
+	// UTime is the last update time.
+	UTime time.Time `db:"u_time" json:"uTime"`
+
+	// AssetID is the ID of the asset the snapshot belongs to.
+	AssetID string `db:"asset_id" json:"assetId"`
+
+	// DisplayName is the asset's name to display.
+	DisplayName string `db:"display_name" json:"displayName"`
+
+	// Description is the asset's description.
+	Description string `db:"description" json:"description"`
+
+	// Category is the asset category.
+	Category string `db:"category" json:"category"`
+
+	// AssetType indicates the type of the asset.
+	AssetType AssetType `db:"asset_type" json:"assetType"`
+
+	// Files contains the asset's files.
+	Files FileCollection `db:"files" json:"files"`
+
+	// FilesHash is the hash of the asset's files.
+	FilesHash string `db:"files_hash" json:"filesHash"`
+
+	// FilesMeta contains metadata of the asset's files.
+	FilesMeta FileMetaCollection `db:"files_meta" json:"filesMeta"`
+
+	// Preview is the URL for the asset preview.
+	Preview string `db:"preview" json:"preview"`
+
+	// License is the license under which others may use the asset.
+	License AssetLicense `db:"license" json:"license"`
+
+	// IsPublic indicates if the asset is public.
+	IsPublic IsPublic `db:"is_public" json:"isPublic"`
+
+	// Editor is the name of the user whose update replaced the snapshot.
+	Editor string `db:"editor" json:"editor"`
+
+	// Status indicates if the version is deleted.
+	Status Status `db:"status" json:"status"`
+}
+
+// TableAssetVersion is the table name of [AssetVersion] in database.
+const TableAssetVersion = "asset_version"
+
+// AssetVersionByID gets asset version with given id. Returns `ErrNotExist` if it does not exist.
+func AssetVersionByID(ctx context.Context, db *sql.DB, id string) (*AssetVersion, error) {
+	return QueryByID[AssetVersion](ctx, db, TableAssetVersion, id)
+}
+
+// ListAssetVersions lists versions of asset with given id, newest first.
+func ListAssetVersions(ctx context.Context, db *sql.DB, assetID string, paginaton Pagination) (*ByPage[AssetVersion], error) {
+	where := []FilterCondition{{Column: "asset_id", Operation: "=", Value: assetID}}
+	orderBy := []OrderByCondition{{Column: "id", Direction: "DESC"}}
+	return QueryByPage[AssetVersion](ctx, db, TableAssetVersion, paginaton, where, orderBy)
+}
+
+// addAssetVersion adds a snapshot of the given asset edited by editor, then
+// prunes the oldest versions of the asset so that at most maxVersions remain.
+func addAssetVersion(ctx context.Context, db Queryer, a *Asset, editor string, maxVersions int) error {
+	logger := log.GetReqLogger(ctx)
+
+	if _, err := Create(ctx, db, TableAssetVersion, &AssetVersion{
+		AssetID:     a.ID,
+		DisplayName: a.DisplayName,
+		Description: a.Description,
+		Category:    a.Category,
+		AssetType:   a.AssetType,
+		Files:       a.Files,
+		FilesHash:   a.FilesHash,
+		FilesMeta:   a.FilesMeta,
+		Preview:     a.Preview,
+		License:     a.License,
+		IsPublic:    a.IsPublic,
+		Editor:      editor,
+	}); err != nil {
+		logger.Printf("Create failed: %v", err)
+		return err
+	}
+
+	// Find the newest version that falls out of the limit, and delete it
+	// together with all versions older than it.
+	var cutoffID int64
+	query := fmt.Sprintf("SELECT id FROM %s WHERE asset_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?", TableAssetVersion)
+	if err := db.QueryRowContext(ctx, query, a.ID, maxVersions).Scan(&cutoffID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		logger.Printf("db.QueryRowContext failed: %v", err)
+		return err
+	}
+	query = fmt.Sprintf("DELETE FROM %s WHERE asset_id = ? AND id <= ?", TableAssetVersion)
+	if _, err := db.ExecContext(ctx, query, a.ID, cutoffID); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetVersionByID(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id", "display_name"}).
+				AddRow(1, 2, "foo"))
+		version, err := AssetVersionByID(context.Background(), db, "1")
+		require.NoError(t, err)
+		require.NotNil(t, version)
+		assert.Equal(t, "1", version.ID)
+		assert.Equal(t, "2", version.AssetID)
+		assert.Equal(t, "foo", version.DisplayName)
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows(nil))
+		version, err := AssetVersionByID(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+		assert.Nil(t, version)
+	})
+}
+
+func TestListAssetVersions(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_version WHERE asset_id = \? AND status != \?`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"COUNT(*)"}).
+				AddRow(2))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE asset_id = \? AND status != \? ORDER BY id DESC LIMIT \?, \?`).
+			WithArgs("1", StatusDeleted, 0, 10).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(2, 1).
+				AddRow(1, 1))
+		versions, err := ListAssetVersions(context.Background(), db, "1", Pagination{Index: 1, Size: 10})
+		require.NoError(t, err)
+		require.NotNil(t, versions)
+		assert.Equal(t, 2, versions.Total)
+		require.Len(t, versions.Data, 2)
+		assert.Equal(t, "2", versions.Data[0].ID)
+		assert.Equal(t, "1", versions.Data[1].ID)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM asset_version WHERE asset_id = \? AND status != \?`).
+			WillReturnError(sql.ErrConnDone)
+		versions, err := ListAssetVersions(context.Background(), db, "1", Pagination{Index: 1, Size: 10})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, versions)
+	})
+}
+
+func TestAddAssetVersion(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(3, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WithArgs("1", 5).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+		err = addAssetVersion(context.Background(), db, &Asset{ID: "1", DisplayName: "foo"}, "fake-name", 5)
+		require.NoError(t, err)
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectQuery(`SELECT \* FROM asset_version WHERE id = \? AND status != \? ORDER BY id ASC LIMIT 1`).
+			WillReturnRows(mock.NewRows([]string{"id", "asset_id"}).
+				AddRow(3, 1))
+		mock.ExpectQuery(`SELECT id FROM asset_version WHERE asset_id = \? ORDER BY id DESC LIMIT 1 OFFSET \?`).
+			WithArgs("1", 2).
+			WillReturnRows(mock.NewRows([]string{"id"}).
+				AddRow(1))
+		mock.ExpectExec(`DELETE FROM asset_version WHERE asset_id = \? AND id <= \?`).
+			WithArgs("1", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = addAssetVersion(context.Background(), db, &Asset{ID: "1", DisplayName: "foo"}, "fake-name", 2)
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_version \(.+\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?,\?\)`).
+			WillReturnError(sql.ErrConnDone)
+		err = addAssetVersion(context.Background(), db, &Asset{ID: "1", DisplayName: "foo"}, "fake-name", 2)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
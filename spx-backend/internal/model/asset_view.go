@@ -0,0 +1,87 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// TableAssetViewDaily is the table name of daily asset views in database. Each
+// row rolls up the views of an asset on a date.
+const TableAssetViewDaily = "asset_view_daily"
+
+// AssetViewDay is the views of an asset on a date.
+type AssetViewDay struct {
+	// Date is the date in UTC, formatted as "2006-01-02".
+	Date string `db:"date" json:"date"`
+
+	// Views is the number of views on the date, including repeated views of
+	// the same viewer.
+	Views int64 `db:"views" json:"views"`
+
+	// UniqueViewers is the number of distinct viewers on the date.
+	UniqueViewers int64 `db:"unique_viewers" json:"uniqueViewers"`
+}
+
+// addAssetView adds a view of asset with given id at given time to the rollup
+// of its date. isNewViewer tells whether it is the first view of the viewer on
+// the date.
+//
+// It is a single upsert, so concurrent views of the same asset never lose
+// counts and need no lock.
+func addAssetView(ctx context.Context, db Queryer, assetID string, t time.Time, isNewViewer bool) error {
+	logger := log.GetReqLogger(ctx)
+
+	var newViewers int
+	if isNewViewer {
+		newViewers = 1
+	}
+	query := fmt.Sprintf(
+		"INSERT INTO %s (asset_id, view_date, views, unique_viewers) VALUES (?, ?, 1, ?) ON DUPLICATE KEY UPDATE views = views + 1, unique_viewers = unique_viewers + VALUES(unique_viewers)",
+		TableAssetViewDaily,
+	)
+	if _, err := db.ExecContext(ctx, query, assetID, clickDate(t), newViewers); err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ListAssetDailyViews lists views of asset with given id per date since the
+// date of since, oldest first. Dates without views are left out.
+func ListAssetDailyViews(ctx context.Context, db *sql.DB, assetID string, since time.Time) ([]AssetViewDay, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf(
+		"SELECT DATE_FORMAT(view_date, '%%Y-%%m-%%d') AS date, views, unique_viewers FROM %s WHERE asset_id = ? AND view_date >= ? ORDER BY view_date ASC",
+		TableAssetViewDaily,
+	)
+	days, err := queryRows[AssetViewDay](ctx, db, query, assetID, clickDate(since))
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return days, nil
+}
+
+// DeleteAssetViewsBefore deletes daily asset views on dates before the date of
+// t. It returns the number of deleted rows.
+func DeleteAssetViewsBefore(ctx context.Context, db *sql.DB, t time.Time) (int64, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE view_date < ?", TableAssetViewDaily)
+	result, err := db.ExecContext(ctx, query, clickDate(t))
+	if err != nil {
+		logger.Printf("db.ExecContext failed: %v", err)
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Printf("result.RowsAffected failed: %v", err)
+		return 0, err
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,38 @@
+//go:build integration
+
+package model
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAssetViewConcurrently(t *testing.T) {
+	db := newIntegrationDB(t)
+	id := addIntegrationAsset(t, db)
+
+	const n = 100
+	now := time.Now().UTC()
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(isNewViewer bool) {
+			defer wg.Done()
+			assert.NoError(t, addAssetView(context.Background(), db, id, now, isNewViewer))
+		}(i%2 == 0)
+	}
+	wg.Wait()
+
+	// Every view lands in the single row of the date.
+	views, err := ListAssetDailyViews(context.Background(), db, id, now)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, clickDate(now), views[0].Date)
+	assert.Equal(t, int64(n), views[0].Views)
+	assert.Equal(t, int64(n/2), views[0].UniqueViewers)
+}
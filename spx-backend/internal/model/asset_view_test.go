@@ -0,0 +1,127 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAssetView(t *testing.T) {
+	t.Run("NewViewer", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE views = views \+ 1, unique_viewers = unique_viewers \+ VALUES\(unique_viewers\)`).
+			WithArgs("1", "2024-01-01", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = addAssetView(context.Background(), db, "1", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), true)
+		require.NoError(t, err)
+	})
+
+	t.Run("RepeatedViewer", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE`).
+			WithArgs("1", "2024-01-01", 0).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		err = addAssetView(context.Background(), db, "1", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), false)
+		require.NoError(t, err)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`INSERT INTO asset_view_daily`).
+			WillReturnError(sql.ErrConnDone)
+		err = addAssetView(context.Background(), db, "1", time.Now(), true)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+// TestAddAssetViewIsSingleUpsert checks the shape of the queries of a view
+// only. It cannot detect lost counts under concurrency, which rely on the
+// database applying the single upsert statement atomically.
+func TestAddAssetViewIsSingleUpsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// No read-modify-write queries are expected, both counters are increased
+	// in place by the upsert.
+	mock.ExpectExec(`^INSERT INTO asset_view_daily \(asset_id, view_date, views, unique_viewers\) VALUES \(\?, \?, 1, \?\) ON DUPLICATE KEY UPDATE views = views \+ 1, unique_viewers = unique_viewers \+ VALUES\(unique_viewers\)$`).
+		WithArgs("1", "2024-01-01", 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	err = addAssetView(context.Background(), db, "1", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), true)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAssetDailyViews(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT DATE_FORMAT\(view_date, '%Y-%m-%d'\) AS date, views, unique_viewers FROM asset_view_daily WHERE asset_id = \? AND view_date >= \? ORDER BY view_date ASC`).
+			WithArgs("1", "2024-01-01").
+			WillReturnRows(mock.NewRows([]string{"date", "views", "unique_viewers"}).
+				AddRow("2024-01-01", 5, 3).
+				AddRow("2024-01-03", 2, 2))
+		views, err := ListAssetDailyViews(context.Background(), db, "1", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, []AssetViewDay{
+			{Date: "2024-01-01", Views: 5, UniqueViewers: 3},
+			{Date: "2024-01-03", Views: 2, UniqueViewers: 2},
+		}, views)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT DATE_FORMAT\(view_date`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = ListAssetDailyViews(context.Background(), db, "1", time.Now())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestDeleteAssetViewsBefore(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`DELETE FROM asset_view_daily WHERE view_date < \?`).
+			WithArgs("2024-01-01").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		n, err := DeleteAssetViewsBefore(context.Background(), db, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(`DELETE FROM asset_view_daily WHERE view_date < \?`).
+			WillReturnError(sql.ErrConnDone)
+		_, err = DeleteAssetViewsBefore(context.Background(), db, time.Now())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
@@ -2,46 +2,146 @@ package model
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 )
 
 // FilterCondition represents a condition to filter rows.
 type FilterCondition struct {
 	Column    string // column name
-	Operation string // "=", "<", "!=" ...
-	Value     any    // value
+	Operation string // "=", "<", "!=", "IN", "MATCH", "OR" ...
+	Value     any    // value, a slice or a [Subquery] for "IN", a []FilterCondition for "OR"
+}
+
+// Subquery is a parameterized query used as the value of an "IN" filter
+// condition.
+type Subquery struct {
+	Query string // query with placeholders
+	Args  []any  // arguments for the placeholders
 }
 
 // Expr returns the expression of the condition for use in a parameterized query.
+//
+// For the "IN" operation, a placeholder is generated for each element of the
+// slice value. An empty slice matches no rows.
+//
+// For the "MATCH" operation, the column is a comma-separated list of columns
+// covered by a full-text index, and the value is a boolean mode search query.
+//
+// For the "OR" operation, the column is ignored, and the value is a slice of
+// conditions of which any must match. An empty slice matches no rows.
 func (cond *FilterCondition) Expr() string {
+	if cond.Operation == "MATCH" {
+		return matchExpr(cond.Column)
+	}
+	if conds, ok := cond.Value.([]FilterCondition); ok && cond.Operation == "OR" {
+		if len(conds) == 0 {
+			return "FALSE"
+		}
+		exprs := make([]string, 0, len(conds))
+		for _, c := range conds {
+			exprs = append(exprs, c.Expr())
+		}
+		return "(" + strings.Join(exprs, " OR ") + ")"
+	}
+	if subquery, ok := cond.Value.(Subquery); ok && cond.Operation == "IN" {
+		return fmt.Sprintf("%s IN (%s)", cond.Column, subquery.Query)
+	}
+	if values, ok := cond.inValues(); ok {
+		return fmt.Sprintf("%s IN (%s)", cond.Column, placeholders(len(values)))
+	}
 	return fmt.Sprintf("%s %s ?", cond.Column, cond.Operation)
 }
 
+// Args returns the arguments of the condition for use in a parameterized query.
+func (cond *FilterCondition) Args() []any {
+	if conds, ok := cond.Value.([]FilterCondition); ok && cond.Operation == "OR" {
+		var args []any
+		for _, c := range conds {
+			args = append(args, c.Args()...)
+		}
+		return args
+	}
+	if subquery, ok := cond.Value.(Subquery); ok && cond.Operation == "IN" {
+		return subquery.Args
+	}
+	if values, ok := cond.inValues(); ok {
+		return values
+	}
+	return []any{cond.Value}
+}
+
+// inValues returns the elements of the value if the condition is an "IN"
+// operation with a slice value.
+func (cond *FilterCondition) inValues() ([]any, bool) {
+	if cond.Operation != "IN" {
+		return nil, false
+	}
+	v := reflect.ValueOf(cond.Value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	values := make([]any, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, true
+}
+
+// placeholders returns n comma-separated placeholders, or "NULL" if n is 0 so
+// that an "IN" list stays valid and matches no rows.
+func placeholders(n int) string {
+	if n == 0 {
+		return "NULL"
+	}
+	return strings.Repeat(",?", n)[1:]
+}
+
 // buildWhereClause builds a WHERE clause from the given conditions.
 //
 // The deleted items are filtered out by default.
 func buildWhereClause(conds []FilterCondition) (string, []any) {
+	// Filter out deleted items.
+	return buildWhereClauseWithDeleted(append(conds[:len(conds):len(conds)], FilterCondition{Column: "status", Operation: "!=", Value: StatusDeleted}))
+}
+
+// buildWhereClauseWithDeleted builds a WHERE clause from the given conditions,
+// including deleted items.
+func buildWhereClauseWithDeleted(conds []FilterCondition) (string, []any) {
+	if len(conds) == 0 {
+		return "", nil
+	}
 	var (
-		exprs = make([]string, 0, len(conds)+1)
-		args  = make([]any, 0, len(conds)+1)
+		exprs = make([]string, 0, len(conds))
+		args  = make([]any, 0, len(conds))
 	)
 	for _, cond := range conds {
 		exprs = append(exprs, cond.Expr())
-		args = append(args, cond.Value)
+		args = append(args, cond.Args()...)
 	}
 
-	// Filter out deleted items.
-	exprs = append(exprs, "status != ?")
-	args = append(args, StatusDeleted)
-
 	whereClause := "WHERE " + strings.Join(exprs, " AND ")
 	return whereClause, args
 }
 
+// matchExpr returns a full-text search expression over the given
+// comma-separated columns, taking the search query as a placeholder.
+func matchExpr(columns string) string {
+	return fmt.Sprintf("MATCH (%s) AGAINST (? IN BOOLEAN MODE)", columns)
+}
+
 // OrderByCondition represents a condition to order rows.
 type OrderByCondition struct {
-	Column    string // column name
+	Column    string // column name or expression
 	Direction string // ASC or DESC
+	Args      []any  // arguments for placeholders in the column expression
+}
+
+// MatchRelevanceOrder returns a condition ordering rows by full-text search
+// relevance of the given boolean mode query over the given comma-separated
+// columns, most relevant first.
+func MatchRelevanceOrder(columns string, query string) OrderByCondition {
+	return OrderByCondition{Column: matchExpr(columns), Direction: "DESC", Args: []any{query}}
 }
 
 // Expr returns the expression of the condition for use in a parameterized query.
@@ -52,14 +152,18 @@ func (cond *OrderByCondition) Expr() string {
 // buildOrderByClause builds an ORDER BY clause from the given conditions.
 //
 // If no conditions are given, the default order is by ID in ascending order.
-func buildOrderByClause(conds []OrderByCondition) string {
+func buildOrderByClause(conds []OrderByCondition) (string, []any) {
 	if len(conds) == 0 {
-		return "ORDER BY id ASC"
+		return "ORDER BY id ASC", nil
 	}
-	exprs := make([]string, 0, len(conds))
+	var (
+		exprs = make([]string, 0, len(conds))
+		args  []any
+	)
 	for _, cond := range conds {
 		exprs = append(exprs, cond.Expr())
+		args = append(args, cond.Args...)
 	}
 	orderByClause := "ORDER BY " + strings.Join(exprs, ", ")
-	return orderByClause
+	return orderByClause, args
 }
@@ -12,12 +12,70 @@ func TestFilterConditionExpr(t *testing.T) {
 		assert.Equal(t, "a = ?", cond.Expr())
 	})
 
+	t.Run("In", func(t *testing.T) {
+		cond := FilterCondition{"a", "IN", []int{1, 2, 3}}
+		assert.Equal(t, "a IN (?,?,?)", cond.Expr())
+	})
+
+	t.Run("InEmpty", func(t *testing.T) {
+		cond := FilterCondition{"a", "IN", []int{}}
+		assert.Equal(t, "a IN (NULL)", cond.Expr())
+	})
+
+	t.Run("InSubquery", func(t *testing.T) {
+		cond := FilterCondition{"a", "IN", Subquery{Query: "SELECT b FROM c WHERE d = ?", Args: []any{1}}}
+		assert.Equal(t, "a IN (SELECT b FROM c WHERE d = ?)", cond.Expr())
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		cond := FilterCondition{"a, b", "MATCH", `+"foo"`}
+		assert.Equal(t, "MATCH (a, b) AGAINST (? IN BOOLEAN MODE)", cond.Expr())
+		assert.Equal(t, []any{`+"foo"`}, cond.Args())
+	})
+
+	t.Run("Or", func(t *testing.T) {
+		cond := FilterCondition{Operation: "OR", Value: []FilterCondition{
+			{"a", "=", 1},
+			{"b", "IN", Subquery{Query: "SELECT c FROM d WHERE e LIKE ?", Args: []any{"%f%"}}},
+		}}
+		assert.Equal(t, "(a = ? OR b IN (SELECT c FROM d WHERE e LIKE ?))", cond.Expr())
+		assert.Equal(t, []any{1, "%f%"}, cond.Args())
+	})
+
+	t.Run("OrEmpty", func(t *testing.T) {
+		cond := FilterCondition{Operation: "OR", Value: []FilterCondition{}}
+		assert.Equal(t, "FALSE", cond.Expr())
+		assert.Empty(t, cond.Args())
+	})
+
 	t.Run("Empty", func(t *testing.T) {
 		cond := FilterCondition{}
 		assert.Equal(t, "  ?", cond.Expr())
 	})
 }
 
+func TestFilterConditionArgs(t *testing.T) {
+	t.Run("Equal", func(t *testing.T) {
+		cond := FilterCondition{"a", "=", 1}
+		assert.Equal(t, []any{1}, cond.Args())
+	})
+
+	t.Run("In", func(t *testing.T) {
+		cond := FilterCondition{"a", "IN", []int{1, 2, 3}}
+		assert.Equal(t, []any{1, 2, 3}, cond.Args())
+	})
+
+	t.Run("InEmpty", func(t *testing.T) {
+		cond := FilterCondition{"a", "IN", []int{}}
+		assert.Empty(t, cond.Args())
+	})
+
+	t.Run("InSubquery", func(t *testing.T) {
+		cond := FilterCondition{"a", "IN", Subquery{Query: "SELECT b FROM c WHERE d = ?", Args: []any{1}}}
+		assert.Equal(t, []any{1}, cond.Args())
+	})
+}
+
 func TestBuildWhereClause(t *testing.T) {
 	t.Run("Nil", func(t *testing.T) {
 		clause, args := buildWhereClause(nil)
@@ -41,11 +99,20 @@ func TestBuildWhereClause(t *testing.T) {
 		assert.Equal(t, "WHERE a = ? AND b != ? AND status != ?", clause)
 		assert.Equal(t, []any{1, 2, StatusDeleted}, args)
 	})
+
+	t.Run("InCondition", func(t *testing.T) {
+		clause, args := buildWhereClause([]FilterCondition{
+			{"a", "IN", []int{1, 2}},
+			{"b", "=", 3},
+		})
+		assert.Equal(t, "WHERE a IN (?,?) AND b = ? AND status != ?", clause)
+		assert.Equal(t, []any{1, 2, 3, StatusDeleted}, args)
+	})
 }
 
 func TestOrderByConditionExpr(t *testing.T) {
 	t.Run("ASC", func(t *testing.T) {
-		cond := OrderByCondition{"a", "ASC"}
+		cond := OrderByCondition{Column: "a", Direction: "ASC"}
 		assert.Equal(t, "a ASC", cond.Expr())
 	})
 
@@ -57,22 +124,34 @@ func TestOrderByConditionExpr(t *testing.T) {
 
 func TestBuildOrderByClause(t *testing.T) {
 	t.Run("Nil", func(t *testing.T) {
-		clause := buildOrderByClause(nil)
+		clause, args := buildOrderByClause(nil)
 		assert.Equal(t, "ORDER BY id ASC", clause)
+		assert.Empty(t, args)
 	})
 
 	t.Run("OneCondition", func(t *testing.T) {
-		clause := buildOrderByClause([]OrderByCondition{
-			{"a", "ASC"},
+		clause, args := buildOrderByClause([]OrderByCondition{
+			{Column: "a", Direction: "ASC"},
 		})
 		assert.Equal(t, "ORDER BY a ASC", clause)
+		assert.Empty(t, args)
 	})
 
 	t.Run("MultipleConditions", func(t *testing.T) {
-		clause := buildOrderByClause([]OrderByCondition{
-			{"a", "ASC"},
-			{"b", "DESC"},
+		clause, args := buildOrderByClause([]OrderByCondition{
+			{Column: "a", Direction: "ASC"},
+			{Column: "b", Direction: "DESC"},
 		})
 		assert.Equal(t, "ORDER BY a ASC, b DESC", clause)
+		assert.Empty(t, args)
+	})
+
+	t.Run("MatchRelevance", func(t *testing.T) {
+		clause, args := buildOrderByClause([]OrderByCondition{
+			MatchRelevanceOrder("a, b", `+"foo"`),
+			{Column: "id", Direction: "ASC"},
+		})
+		assert.Equal(t, "ORDER BY MATCH (a, b) AGAINST (? IN BOOLEAN MODE) DESC, id ASC", clause)
+		assert.Equal(t, []any{`+"foo"`}, args)
 	})
 }
@@ -32,3 +32,53 @@ func (fc *FileCollection) Scan(src any) error {
 func (fc FileCollection) Value() (driver.Value, error) {
 	return json.Marshal(fc)
 }
+
+// FileMeta holds metadata of a file.
+type FileMeta struct {
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+
+	// ContentType is the MIME type of the file.
+	ContentType string `json:"contentType"`
+
+	// Width is the width of the image in pixels. It is zero for files that are
+	// not images or whose dimensions are unknown.
+	Width int `json:"width,omitempty"`
+
+	// Height is the height of the image in pixels. It is zero for files that
+	// are not images or whose dimensions are unknown.
+	Height int `json:"height,omitempty"`
+
+	// Hash is the content hash of the file reported by the storage, if any.
+	Hash string `json:"hash,omitempty"`
+}
+
+// FileMetaCollection is a map from relative path to metadata of the file. A
+// nil collection means the metadata has not been probed yet.
+type FileMetaCollection map[string]FileMeta
+
+// Scan implements [sql.Scanner].
+func (fmc *FileMetaCollection) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		var parsed FileMetaCollection
+		if err := json.Unmarshal(src, &parsed); err != nil {
+			return fmt.Errorf("failed to unmarshal FileMetaCollection: %w", err)
+		}
+		*fmc = parsed
+	case nil:
+		*fmc = nil
+	default:
+		return errors.New("incompatible type for FileMetaCollection")
+	}
+	return nil
+}
+
+// Value implements [driver.Valuer]. A nil collection is stored as NULL so it
+// can be found and probed later.
+func (fmc FileMetaCollection) Value() (driver.Value, error) {
+	if fmc == nil {
+		return nil, nil
+	}
+	return json.Marshal(fmc)
+}
@@ -69,3 +69,64 @@ func TestFileCollectionValue(t *testing.T) {
 		assert.Equal(t, `{}`, string(v.([]byte)))
 	})
 }
+
+func TestFileMetaCollectionScan(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		var fmc FileMetaCollection
+		err := fmc.Scan([]byte(`{"a":{"size":1,"contentType":"image/png","width":2,"height":3}}`))
+		require.NoError(t, err)
+		assert.Equal(t, FileMetaCollection{"a": {Size: 1, ContentType: "image/png", Width: 2, Height: 3}}, fmc)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		fmc := FileMetaCollection{}
+		err := fmc.Scan(nil)
+		require.NoError(t, err)
+		assert.Nil(t, fmc)
+	})
+
+	t.Run("EmptyJSON", func(t *testing.T) {
+		var fmc FileMetaCollection
+		err := fmc.Scan([]byte(`{}`))
+		require.NoError(t, err)
+		require.NotNil(t, fmc)
+		assert.Empty(t, fmc)
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		var fmc FileMetaCollection
+		err := fmc.Scan([]byte(`{`))
+		require.Error(t, err)
+		assert.ErrorAs(t, err, new(*json.SyntaxError))
+	})
+
+	t.Run("IncompatibleType", func(t *testing.T) {
+		var fmc FileMetaCollection
+		err := fmc.Scan(true)
+		require.Error(t, err)
+		assert.EqualError(t, err, "incompatible type for FileMetaCollection")
+	})
+}
+
+func TestFileMetaCollectionValue(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		fmc := FileMetaCollection{"a": {Size: 1, ContentType: "text/plain"}}
+		v, err := fmc.Value()
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":{"size":1,"contentType":"text/plain"}}`, string(v.([]byte)))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		fmc := FileMetaCollection{}
+		v, err := fmc.Value()
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(v.([]byte)))
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var fmc FileMetaCollection
+		v, err := fmc.Value()
+		require.NoError(t, err)
+		assert.Nil(t, v)
+	})
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// minFullTextTermLen is the minimum length in runes of a term that can be
+// searched with the full-text index. It matches the default token size of the
+// ngram parser, shorter terms never match.
+const minFullTextTermLen = 2
+
+// fullTextOperators are characters with special meaning in boolean mode
+// full-text search queries.
+const fullTextOperators = `+-<>()~*"@`
+
+// FullTextQuery converts a user-provided keyword into a boolean mode search
+// query requiring all of its terms. Operators in the keyword are treated as
+// term separators so they cannot change the semantics of the query.
+//
+// It returns false if the keyword cannot be searched with the full-text index,
+// e.g., it is empty or contains a term that is too short, in which case the
+// caller should fall back to substring matching.
+func FullTextQuery(keyword string) (string, bool) {
+	terms := strings.FieldsFunc(keyword, func(r rune) bool {
+		return strings.ContainsRune(fullTextOperators, r) || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(terms) == 0 {
+		return "", false
+	}
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < minFullTextTermLen {
+			return "", false
+		}
+		quoted = append(quoted, `+"`+term+`"`)
+	}
+	return strings.Join(quoted, " "), true
+}
+
+// mysqlErrFullTextIndexMissing is the MySQL error number for a MATCH without
+// a full-text index on the given columns.
+const mysqlErrFullTextIndexMissing = 1191
+
+// IsFullTextIndexMissing reports whether err is caused by a missing full-text
+// index, e.g., on a database not migrated yet.
+func IsFullTextIndexMissing(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrFullTextIndexMissing
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullTextQuery(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		query, ok := FullTextQuery("winter boss")
+		assert.True(t, ok)
+		assert.Equal(t, `+"winter" +"boss"`, query)
+	})
+
+	t.Run("Operators", func(t *testing.T) {
+		query, ok := FullTextQuery(`-winter +"boss*" (pixel-art)~ <@>`)
+		assert.True(t, ok)
+		assert.Equal(t, `+"winter" +"boss" +"pixel" +"art"`, query)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, ok := FullTextQuery("  +-  ")
+		assert.False(t, ok)
+	})
+
+	t.Run("ShortTerm", func(t *testing.T) {
+		_, ok := FullTextQuery("a boss")
+		assert.False(t, ok)
+	})
+
+	t.Run("CJK", func(t *testing.T) {
+		query, ok := FullTextQuery("小猫")
+		assert.True(t, ok)
+		assert.Equal(t, `+"小猫"`, query)
+	})
+}
+
+func TestIsFullTextIndexMissing(t *testing.T) {
+	t.Run("Missing", func(t *testing.T) {
+		err := fmt.Errorf("query failed: %w", &mysql.MySQLError{Number: 1191, Message: "Can't find FULLTEXT index matching the column list"})
+		assert.True(t, IsFullTextIndexMissing(err))
+	})
+
+	t.Run("OtherMySQLError", func(t *testing.T) {
+		err := &mysql.MySQLError{Number: 1054, Message: "Unknown column"}
+		assert.False(t, IsFullTextIndexMissing(err))
+	})
+
+	t.Run("OtherError", func(t *testing.T) {
+		assert.False(t, IsFullTextIndexMissing(sql.ErrConnDone))
+	})
+}
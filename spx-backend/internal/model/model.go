@@ -5,6 +5,10 @@ import "errors"
 var (
 	ErrExist    = errors.New("item already existed")
 	ErrNotExist = errors.New("item does not exist")
+
+	// ErrInvalidTransition is returned when an item is not in a state it
+	// can move to the requested state from.
+	ErrInvalidTransition = errors.New("invalid state transition")
 )
 
 // IsPublic indicates the visibility of an item.
@@ -12,15 +12,51 @@ import (
 	"github.com/goplus/builder/spx-backend/internal/log"
 )
 
-// Query queries a table.
-func Query[T any](ctx context.Context, db *sql.DB, table string, where []FilterCondition, orderBy []OrderByCondition) ([]T, error) {
+// Queryer is the common interface of [sql.DB] and [sql.Tx], so the query
+// helpers can run both directly and inside a transaction.
+type Queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// runInTx runs fn in a transaction. The transaction is committed if fn returns
+// nil, and rolled back otherwise.
+func runInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
 	logger := log.GetReqLogger(ctx)
 
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Printf("db.BeginTx failed: %v", err)
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if err := tx.Rollback(); err != nil {
+			logger.Printf("tx.Rollback failed: %v", err)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		logger.Printf("tx.Commit failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Query queries a table.
+func Query[T any](ctx context.Context, db Queryer, table string, where []FilterCondition, orderBy []OrderByCondition) ([]T, error) {
 	whereClause, whereArgs := buildWhereClause(where)
-	orderByClause := buildOrderByClause(orderBy)
+	orderByClause, orderByArgs := buildOrderByClause(orderBy)
 
 	query := fmt.Sprintf("SELECT * FROM %s %s %s", table, whereClause, orderByClause)
-	rows, err := db.QueryContext(ctx, query, whereArgs...)
+	return queryRows[T](ctx, db, query, append(whereArgs, orderByArgs...)...)
+}
+
+// queryRows runs a raw query and scans all resulting rows.
+func queryRows[T any](ctx context.Context, db Queryer, query string, args ...any) ([]T, error) {
+	logger := log.GetReqLogger(ctx)
+
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		logger.Printf("db.QueryContext failed: %v", err)
 		return nil, err
@@ -52,11 +88,16 @@ type ByPage[T any] struct {
 }
 
 // QueryByPage queries a table by page.
-func QueryByPage[T any](ctx context.Context, db *sql.DB, table string, paginaton Pagination, where []FilterCondition, orderBy []OrderByCondition) (*ByPage[T], error) {
+func QueryByPage[T any](ctx context.Context, db Queryer, table string, paginaton Pagination, where []FilterCondition, orderBy []OrderByCondition) (*ByPage[T], error) {
+	whereClause, whereArgs := buildWhereClause(where)
+	return queryByPage[T](ctx, db, table, paginaton, whereClause, whereArgs, orderBy)
+}
+
+// queryByPage queries a table by page with a built WHERE clause.
+func queryByPage[T any](ctx context.Context, db Queryer, table string, paginaton Pagination, whereClause string, whereArgs []any, orderBy []OrderByCondition) (*ByPage[T], error) {
 	logger := log.GetReqLogger(ctx)
 
-	whereClause, whereArgs := buildWhereClause(where)
-	orderByClause := buildOrderByClause(orderBy)
+	orderByClause, orderByArgs := buildOrderByClause(orderBy)
 
 	var total int
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", table, whereClause)
@@ -67,7 +108,7 @@ func QueryByPage[T any](ctx context.Context, db *sql.DB, table string, paginaton
 
 	offset := (paginaton.Index - 1) * paginaton.Size
 	query := fmt.Sprintf("SELECT * FROM %s %s %s LIMIT ?, ?", table, whereClause, orderByClause)
-	args := append(whereArgs, offset, paginaton.Size)
+	args := append(append(whereArgs, orderByArgs...), offset, paginaton.Size)
 	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		logger.Printf("db.QueryContext failed: %v", err)
@@ -92,14 +133,14 @@ func QueryByPage[T any](ctx context.Context, db *sql.DB, table string, paginaton
 }
 
 // QueryFirst queries a table and returns the first result. Returns [ErrNotExist] if it does not exist.
-func QueryFirst[T any](ctx context.Context, db *sql.DB, table string, where []FilterCondition, orderBy []OrderByCondition) (*T, error) {
+func QueryFirst[T any](ctx context.Context, db Queryer, table string, where []FilterCondition, orderBy []OrderByCondition) (*T, error) {
 	logger := log.GetReqLogger(ctx)
 
 	whereClause, whereArgs := buildWhereClause(where)
-	orderByClause := buildOrderByClause(orderBy)
+	orderByClause, orderByArgs := buildOrderByClause(orderBy)
 
 	query := fmt.Sprintf("SELECT * FROM %s %s %s LIMIT 1", table, whereClause, orderByClause)
-	rows, err := db.QueryContext(ctx, query, whereArgs...)
+	rows, err := db.QueryContext(ctx, query, append(whereArgs, orderByArgs...)...)
 	if err != nil {
 		logger.Printf("db.QueryContext failed: %v", err)
 		return nil, err
@@ -118,13 +159,13 @@ func QueryFirst[T any](ctx context.Context, db *sql.DB, table string, where []Fi
 }
 
 // QueryByID queries an item by ID. Returns [ErrNotExist] if it does not exist.
-func QueryByID[T any](ctx context.Context, db *sql.DB, table string, id string) (*T, error) {
+func QueryByID[T any](ctx context.Context, db Queryer, table string, id string) (*T, error) {
 	where := []FilterCondition{{Column: "id", Operation: "=", Value: id}}
 	return QueryFirst[T](ctx, db, table, where, nil)
 }
 
 // Create creates an item.
-func Create[T any](ctx context.Context, db *sql.DB, table string, item *T) (*T, error) {
+func Create[T any](ctx context.Context, db Queryer, table string, item *T) (*T, error) {
 	logger := log.GetReqLogger(ctx)
 
 	itemValue, dbFields, err := reflectModelItem(item)
@@ -175,7 +216,7 @@ func Create[T any](ctx context.Context, db *sql.DB, table string, item *T) (*T,
 }
 
 // UpdateByID updates an item by ID.
-func UpdateByID[T any](ctx context.Context, db *sql.DB, table string, id string, item *T, columns ...string) error {
+func UpdateByID[T any](ctx context.Context, db Queryer, table string, id string, item *T, columns ...string) error {
 	logger := log.GetReqLogger(ctx)
 
 	itemValue, dbFields, err := reflectModelItem(item)
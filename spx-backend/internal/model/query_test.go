@@ -11,6 +11,53 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestRunInTx(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE user SET name = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err = runInTx(context.Background(), db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(context.Background(), "UPDATE user SET name = ?", "foo")
+			return err
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err = runInTx(context.Background(), db, func(tx *sql.Tx) error {
+			return ErrNotExist
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClosedConnForBegin", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+		err = runInTx(context.Background(), db, func(tx *sql.Tx) error {
+			return nil
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
 func TestQuery(t *testing.T) {
 	type User struct {
 		ID     int    `db:"id"`
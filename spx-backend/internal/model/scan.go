@@ -9,8 +9,19 @@ import (
 
 // dbFieldsForRegisteredModels is a map of registered models to their database fields.
 var dbFieldsForRegisteredModels = map[reflect.Type]map[string]reflect.StructField{
-	reflect.TypeOf(Project{}): reflectModelDBFields(reflect.TypeOf(Project{})),
-	reflect.TypeOf(Asset{}):   reflectModelDBFields(reflect.TypeOf(Asset{})),
+	reflect.TypeOf(Project{}):              reflectModelDBFields(reflect.TypeOf(Project{})),
+	reflect.TypeOf(Asset{}):                reflectModelDBFields(reflect.TypeOf(Asset{})),
+	reflect.TypeOf(AssetVersion{}):         reflectModelDBFields(reflect.TypeOf(AssetVersion{})),
+	reflect.TypeOf(AssetReport{}):          reflectModelDBFields(reflect.TypeOf(AssetReport{})),
+	reflect.TypeOf(AssetModeration{}):      reflectModelDBFields(reflect.TypeOf(AssetModeration{})),
+	reflect.TypeOf(AssetReportCount{}):     reflectModelDBFields(reflect.TypeOf(AssetReportCount{})),
+	reflect.TypeOf(AssetStats{}):           reflectModelDBFields(reflect.TypeOf(AssetStats{})),
+	reflect.TypeOf(AssetStatsTotal{}):      reflectModelDBFields(reflect.TypeOf(AssetStatsTotal{})),
+	reflect.TypeOf(AssetTransfer{}):        reflectModelDBFields(reflect.TypeOf(AssetTransfer{})),
+	reflect.TypeOf(DailyCount{}):           reflectModelDBFields(reflect.TypeOf(DailyCount{})),
+	reflect.TypeOf(DisplayNameDuplicate{}): reflectModelDBFields(reflect.TypeOf(DisplayNameDuplicate{})),
+	reflect.TypeOf(Tag{}):                  reflectModelDBFields(reflect.TypeOf(Tag{})),
+	reflect.TypeOf(TagCount{}):             reflectModelDBFields(reflect.TypeOf(TagCount{})),
 }
 
 // reflectModelDBFields returns a map of database columns to struct fields based
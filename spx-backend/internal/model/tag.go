@@ -0,0 +1,148 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/goplus/builder/spx-backend/internal/log"
+)
+
+// Tag is the model for a free-form label attached to assets.
+type Tag struct {
+	// ID is the globally unique identifier.
+	ID string `db:"id" json:"id"`
+
+	// CTime is the creation time.
+	CTime time.Time `db:"c_time" json:"cTime"`
+
+	// UTime is the last update time.
+	UTime time.Time `db:"u_time" json:"uTime"`
+
+	// Name is the normalized name of the tag, unique across all tags.
+	Name string `db:"name" json:"name"`
+
+	// Status indicates if the tag is deleted.
+	Status Status `db:"status" json:"status"`
+}
+
+// TableTag is the table name of [Tag] in database.
+const TableTag = "tag"
+
+// TableAssetTag is the table name of the relation between assets and tags in
+// database.
+const TableAssetTag = "asset_tag"
+
+// TagCount is a tag name with the number of public assets carrying it.
+type TagCount struct {
+	// Name is the name of the tag.
+	Name string `db:"name" json:"name"`
+
+	// AssetCount is the number of public assets with the tag.
+	AssetCount int `db:"asset_count" json:"assetCount"`
+}
+
+// AssetTagsFilter returns a condition matching assets that carry all of the
+// given tags.
+func AssetTagsFilter(tags []string) FilterCondition {
+	args := make([]any, 0, len(tags)+2)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	args = append(args, StatusDeleted, len(tags))
+	query := fmt.Sprintf(
+		"SELECT %[1]s.asset_id FROM %[1]s JOIN %[2]s ON %[2]s.id = %[1]s.tag_id WHERE %[2]s.name IN (%[3]s) AND %[2]s.status != ? GROUP BY %[1]s.asset_id HAVING COUNT(DISTINCT %[2]s.id) = ?",
+		TableAssetTag, TableTag, placeholders(len(tags)),
+	)
+	return FilterCondition{Column: "id", Operation: "IN", Value: Subquery{Query: query, Args: args}}
+}
+
+// AssetTagNameFilter returns a condition matching assets that carry a tag
+// whose name is like the given pattern.
+func AssetTagNameFilter(pattern string) FilterCondition {
+	query := fmt.Sprintf(
+		"SELECT %[1]s.asset_id FROM %[1]s JOIN %[2]s ON %[2]s.id = %[1]s.tag_id WHERE %[2]s.name LIKE ? AND %[2]s.status != ?",
+		TableAssetTag, TableTag,
+	)
+	return FilterCondition{Column: "id", Operation: "IN", Value: Subquery{Query: query, Args: []any{pattern, StatusDeleted}}}
+}
+
+// ListAssetTags lists tags of asset with given id, ordered by name.
+func ListAssetTags(ctx context.Context, db Queryer, assetID string) ([]Tag, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf(
+		"SELECT %[1]s.* FROM %[1]s JOIN %[2]s ON %[2]s.tag_id = %[1]s.id WHERE %[2]s.asset_id = ? AND %[1]s.status != ? ORDER BY %[1]s.name ASC",
+		TableTag, TableAssetTag,
+	)
+	tags, err := queryRows[Tag](ctx, db, query, assetID, StatusDeleted)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SetAssetTags replaces tags of asset with given id by the given tag names.
+// Tags that do not exist yet are created. The names are expected to be
+// normalized already.
+func SetAssetTags(ctx context.Context, db *sql.DB, assetID string, names []string) ([]Tag, error) {
+	logger := log.GetReqLogger(ctx)
+
+	var tags []Tag
+	if err := runInTx(ctx, db, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("DELETE FROM %s WHERE asset_id = ?", TableAssetTag)
+		if _, err := tx.ExecContext(ctx, query, assetID); err != nil {
+			logger.Printf("tx.ExecContext failed: %v", err)
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		for _, name := range names {
+			query := fmt.Sprintf("INSERT IGNORE INTO %s (c_time, u_time, name, status) VALUES (?, ?, ?, ?)", TableTag)
+			if _, err := tx.ExecContext(ctx, query, now, now, name, StatusNormal); err != nil {
+				logger.Printf("tx.ExecContext failed: %v", err)
+				return err
+			}
+		}
+
+		var err error
+		tags, err = Query[Tag](ctx, tx, TableTag, []FilterCondition{{Column: "name", Operation: "IN", Value: names}}, []OrderByCondition{{Column: "name", Direction: "ASC"}})
+		if err != nil {
+			logger.Printf("Query failed: %v", err)
+			return err
+		}
+		for _, tag := range tags {
+			query := fmt.Sprintf("INSERT INTO %s (c_time, u_time, asset_id, tag_id, status) VALUES (?, ?, ?, ?, ?)", TableAssetTag)
+			if _, err := tx.ExecContext(ctx, query, now, now, assetID, tag.ID, StatusNormal); err != nil {
+				logger.Printf("tx.ExecContext failed: %v", err)
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ListPopularTags lists at most limit tags carried by the most public assets.
+// Assets hidden by moderation are not counted.
+func ListPopularTags(ctx context.Context, db *sql.DB, limit int) ([]TagCount, error) {
+	logger := log.GetReqLogger(ctx)
+
+	query := fmt.Sprintf(
+		"SELECT %[1]s.name AS name, COUNT(*) AS asset_count FROM %[1]s JOIN %[2]s ON %[2]s.tag_id = %[1]s.id JOIN %[3]s ON %[3]s.id = %[2]s.asset_id WHERE %[1]s.status != ? AND %[3]s.status != ? AND %[3]s.is_public = ? AND %[3]s.moderation_status = ? GROUP BY %[1]s.id, %[1]s.name ORDER BY asset_count DESC, name ASC LIMIT ?",
+		TableTag, TableAssetTag, TableAsset,
+	)
+	tagCounts, err := queryRows[TagCount](ctx, db, query, StatusDeleted, StatusDeleted, Public, ModerationVisible, limit)
+	if err != nil {
+		logger.Printf("queryRows failed: %v", err)
+		return nil, err
+	}
+	return tagCounts, nil
+}
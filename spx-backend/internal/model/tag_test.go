@@ -0,0 +1,155 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetTagsFilter(t *testing.T) {
+	cond := AssetTagsFilter([]string{"winter", "boss"})
+	assert.Equal(t, "id IN (SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name IN (?,?) AND tag.status != ? GROUP BY asset_tag.asset_id HAVING COUNT(DISTINCT tag.id) = ?)", cond.Expr())
+	assert.Equal(t, []any{"winter", "boss", StatusDeleted, 2}, cond.Args())
+}
+
+func TestAssetTagNameFilter(t *testing.T) {
+	cond := AssetTagNameFilter("%boss%")
+	assert.Equal(t, "id IN (SELECT asset_tag.asset_id FROM asset_tag JOIN tag ON tag.id = asset_tag.tag_id WHERE tag.name LIKE ? AND tag.status != ?)", cond.Expr())
+	assert.Equal(t, []any{"%boss%", StatusDeleted}, cond.Args())
+}
+
+func TestListAssetTags(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT tag.\* FROM tag JOIN asset_tag ON asset_tag.tag_id = tag.id WHERE asset_tag.asset_id = \? AND tag.status != \? ORDER BY tag.name ASC`).
+			WithArgs("1", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "name"}).
+				AddRow(2, "boss").
+				AddRow(1, "winter"))
+		tags, err := ListAssetTags(context.Background(), db, "1")
+		require.NoError(t, err)
+		require.Len(t, tags, 2)
+		assert.Equal(t, "boss", tags[0].Name)
+		assert.Equal(t, "winter", tags[1].Name)
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT tag.\* FROM tag JOIN asset_tag`).
+			WillReturnError(sql.ErrConnDone)
+		tags, err := ListAssetTags(context.Background(), db, "1")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, tags)
+	})
+}
+
+func TestSetAssetTags(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset_tag WHERE asset_id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT IGNORE INTO tag \(c_time, u_time, name, status\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "winter", StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT IGNORE INTO tag \(c_time, u_time, name, status\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "boss", StatusNormal).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT \* FROM tag WHERE name IN \(\?,\?\) AND status != \? ORDER BY name ASC`).
+			WithArgs("winter", "boss", StatusDeleted).
+			WillReturnRows(mock.NewRows([]string{"id", "name"}).
+				AddRow(2, "boss").
+				AddRow(1, "winter"))
+		mock.ExpectExec(`INSERT INTO asset_tag \(c_time, u_time, asset_id, tag_id, status\) VALUES \(\?, \?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "2", StatusNormal).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO asset_tag \(c_time, u_time, asset_id, tag_id, status\) VALUES \(\?, \?, \?, \?, \?\)`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", "1", StatusNormal).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		tags, err := SetAssetTags(context.Background(), db, "1", []string{"winter", "boss"})
+		require.NoError(t, err)
+		require.Len(t, tags, 2)
+		assert.Equal(t, "boss", tags[0].Name)
+		assert.Equal(t, "winter", tags[1].Name)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset_tag WHERE asset_id = \?`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		tags, err := SetAssetTags(context.Background(), db, "1", nil)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("ClosedConnForInsertQuery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM asset_tag WHERE asset_id = \?`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT IGNORE INTO tag`).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		tags, err := SetAssetTags(context.Background(), db, "1", []string{"winter"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, tags)
+	})
+}
+
+func TestListPopularTags(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT tag.name AS name, COUNT\(\*\) AS asset_count FROM tag JOIN asset_tag ON asset_tag.tag_id = tag.id JOIN asset ON asset.id = asset_tag.asset_id WHERE tag.status != \? AND asset.status != \? AND asset.is_public = \? AND asset.moderation_status = \? GROUP BY tag.id, tag.name ORDER BY asset_count DESC, name ASC LIMIT \?`).
+			WithArgs(StatusDeleted, StatusDeleted, Public, ModerationVisible, 10).
+			WillReturnRows(mock.NewRows([]string{"name", "asset_count"}).
+				AddRow("winter", 3).
+				AddRow("boss", 1))
+		tagCounts, err := ListPopularTags(context.Background(), db, 10)
+		require.NoError(t, err)
+		require.Len(t, tagCounts, 2)
+		assert.Equal(t, TagCount{Name: "winter", AssetCount: 3}, tagCounts[0])
+		assert.Equal(t, TagCount{Name: "boss", AssetCount: 1}, tagCounts[1])
+	})
+
+	t.Run("ClosedConn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(`SELECT tag.name AS name`).
+			WillReturnError(sql.ErrConnDone)
+		tagCounts, err := ListPopularTags(context.Background(), db, 10)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.Nil(t, tagCounts)
+	})
+}